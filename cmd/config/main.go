@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"tasius.my.id/todolistapi/internal/config"
+)
+
+const usage = `Usage: config [-config FILE] [-set KEY=VALUE] print [--redacted]
+
+Prints the effective configuration as KEY=value lines with the source of each value,
+then reports invalid settings. Exits with status 1 if the configuration is invalid.
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if flag.NArg() < 1 || flag.Arg(0) != "print" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	printFlags := flag.NewFlagSet("print", flag.ExitOnError)
+	redacted := printFlags.Bool("redacted", false, "Mask passwords, keys and other secrets")
+	printFlags.Parse(flag.Args()[1:])
+
+	settings, err := config.Inspect(configFlags)
+	if settings == nil && err != nil {
+		log.Fatal(err)
+	}
+
+	for _, setting := range settings {
+		value := setting.Value
+		if *redacted {
+			value = setting.Redacted()
+		}
+		fmt.Printf("%s=%s # %s\n", setting.Key, quote(value), setting.Source)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// quote quotes values that wouldn't survive an env file as they are
+func quote(value string) string {
+	if strings.ContainsAny(value, " \t\n\"'#\\") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -12,6 +12,8 @@ import (
 
 func main() {
 	outputDir := flag.String("output", "./keys", "Output directory for keys")
+	algorithm := flag.String("algorithm", crypto.KeyTypeRSA, "Key type: 'rsa' (RSA-4096), 'x25519' or 'p256'. ECDH keys are much cheaper to decrypt with than RSA")
+	passphrase := flag.String("passphrase", os.Getenv("HYBRID_ENCRYPTION_PRIVATE_KEY_PASSPHRASE"), "Encrypt the private key with this passphrase (defaults to HYBRID_ENCRYPTION_PRIVATE_KEY_PASSPHRASE)")
 	flag.Parse()
 
 	// Create output directory if it doesn't exist
@@ -20,23 +22,34 @@ func main() {
 		log.Fatalf("Failed to create output directory: %v", err)
 	}
 
-	// Generate RSA key pair
-	privateKey, publicKey, err := crypto.GenerateRSAKeyPair()
+	// Generate key pair
+	privateKey, err := crypto.GenerateKey(*algorithm)
 	if err != nil {
-		log.Fatalf("Failed to generate RSA key pair: %v", err)
+		log.Fatalf("Failed to generate key pair: %v", err)
 	}
 
 	// Save private key
 	privateKeyPath := filepath.Join(*outputDir, "private.pem")
-	if err := crypto.SavePEMKey(privateKeyPath, privateKey); err != nil {
+	if err := crypto.SavePrivateKey(privateKeyPath, privateKey, []byte(*passphrase)); err != nil {
 		log.Fatalf("Failed to save private key: %v", err)
 	}
 
 	// Save public key
 	publicKeyPath := filepath.Join(*outputDir, "public.pem")
-	if err := crypto.SavePublicPEMKey(publicKeyPath, publicKey); err != nil {
+	if err := crypto.SavePublicKey(publicKeyPath, privateKey.Public()); err != nil {
 		log.Fatalf("Failed to save public key: %v", err)
 	}
 
-	fmt.Printf("Successfully generated key pair:\n  Private key: %s\n  Public key:  %s\n", privateKeyPath, publicKeyPath)
+	keyID, err := crypto.KeyID(privateKey.Public())
+	if err != nil {
+		log.Fatalf("Failed to compute key ID: %v", err)
+	}
+
+	encrypted := "no"
+	if *passphrase != "" {
+		encrypted = "yes"
+	}
+
+	fmt.Printf("Successfully generated key pair:\n  Private key: %s (encrypted: %s)\n  Public key:  %s\n  Algorithm:   %s\n  Key ID:      %s\n",
+		privateKeyPath, encrypted, publicKeyPath, privateKey.Public().Algorithm(), keyID)
 }
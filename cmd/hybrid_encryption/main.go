@@ -6,6 +6,7 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"strings"
 
 	"tasius.my.id/todolistapi/internal/utils/crypto"
@@ -13,10 +14,14 @@ import (
 
 func main() {
 	// Define command-line flags
-	action := flag.String("action", "encrypt", "Action to perform: 'encrypt' or 'decrypt'")
+	action := flag.String("action", "encrypt", "Action to perform: 'encrypt', 'decrypt' or 'decrypt-response'")
 	input := flag.String("input", "", "Input JSON string to encrypt/decrypt (e.g., '{\"key\":\"value\"}')")
 	privateKeyPath := flag.String("private", "./keys/private.pem", "Path to private key")
+	passphrase := flag.String("passphrase", os.Getenv("HYBRID_ENCRYPTION_PRIVATE_KEY_PASSPHRASE"), "Passphrase of an encrypted private key")
 	publicKeyPath := flag.String("public", "./keys/public.pem", "Path to public key")
+	sessionKey := flag.String("key", "", "AES session key (hex) printed by 'encrypt', used by 'decrypt-response'")
+	method := flag.String("method", "POST", "HTTP method of the request the data is encrypted for")
+	path := flag.String("path", "/api/auth/login", "Path of the request the data is encrypted for")
 	flag.Parse()
 
 	switch *action {
@@ -26,9 +31,9 @@ func main() {
 		if err := json.Unmarshal([]byte(*input), &data); err != nil {
 			log.Fatalf("Failed to parse input as JSON: %v", err)
 		}
-		encryptMessage(*publicKeyPath, data)
+		encryptMessage(*publicKeyPath, *method, *path, data)
 	case "decrypt":
-		result, err := decryptMessage(*privateKeyPath, *input)
+		result, err := decryptMessage(*privateKeyPath, []byte(*passphrase), *method, *path, *input)
 		if err != nil {
 			log.Fatalf("Decryption failed: %v", err)
 		}
@@ -40,98 +45,131 @@ func main() {
 		}
 		fmt.Println("Decrypted data:")
 		fmt.Println(string(jsonOutput))
+	case "decrypt-response":
+		result, err := decryptResponse(*sessionKey, *input)
+		if err != nil {
+			log.Fatalf("Decryption failed: %v", err)
+		}
+
+		fmt.Println("Decrypted response:")
+		fmt.Println(string(result))
 	default:
-		log.Fatalf("Invalid action: %s. Use 'encrypt' or 'decrypt'", *action)
+		log.Fatalf("Invalid action: %s. Use 'encrypt', 'decrypt' or 'decrypt-response'", *action)
 	}
 }
 
-func encryptMessage(publicKeyPath string, data interface{}) {
+func encryptMessage(publicKeyPath, method, path string, data interface{}) {
 	// Load public key
 	publicKey, err := crypto.LoadPublicKey(publicKeyPath)
 	if err != nil {
 		log.Fatalf("Failed to load public key: %v", err)
 	}
 
-	// Convert data to JSON
-	jsonData, err := json.Marshal(data)
+	// The key ID tells the server which of its keys to decrypt with
+	keyID, err := crypto.KeyID(publicKey)
 	if err != nil {
-		log.Fatalf("Failed to marshal data to JSON: %v", err)
+		log.Fatalf("Failed to compute key ID: %v", err)
 	}
 
-	// Generate a new AES key
-	aesKey, err := crypto.GenerateAESKey()
+	// Convert data to JSON
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		log.Fatalf("Failed to generate AES key: %v", err)
+		log.Fatalf("Failed to marshal data to JSON: %v", err)
 	}
 
-	// Encrypt the JSON data with AES
-	ciphertext, err := crypto.EncryptAES(aesKey, jsonData)
+	// Encrypt the JSON data for this request, it is only valid for a few minutes and can be sent once
+	envelope, aesKey, err := crypto.SealEnvelope(publicKey, keyID, method, path, jsonData)
 	if err != nil {
 		log.Fatalf("Failed to encrypt data: %v", err)
 	}
 
-	// Encrypt the AES key with RSA
-	encryptedKey, err := crypto.EncryptWithPublicKey(publicKey, aesKey)
-	if err != nil {
-		log.Fatalf("Failed to encrypt AES key: %v", err)
-	}
-
-	// Output the encrypted key and data in a format that can be easily used for decryption
-	encryptedKeyHex := hex.EncodeToString(encryptedKey)
-	encryptedDataHex := hex.EncodeToString(ciphertext)
-
-	fmt.Println("Encrypted data (use this for decryption):")
-	fmt.Printf("%s:%s\n", encryptedKeyHex, encryptedDataHex)
+	fmt.Printf("Encrypted data for %s %s (use this for decryption):\n", strings.ToUpper(method), path)
+	fmt.Println(envelope.String())
 
 	fmt.Println("\nDebug info:")
-	fmt.Printf("Encrypted AES key (hex): %s\n", encryptedKeyHex)
-	fmt.Printf("Encrypted data (hex): %s\n", encryptedDataHex)
+	fmt.Printf("Key ID: %s\n", keyID)
+	fmt.Printf("Nonce: %s\n", envelope.Nonce)
+	fmt.Printf("AES session key (hex, decrypts the response): %s\n", hex.EncodeToString(aesKey))
 }
 
-func decryptMessage(privateKeyPath, input string) (map[string]interface{}, error) {
+func decryptMessage(privateKeyPath string, passphrase []byte, method, path, input string) (map[string]interface{}, error) {
 	// Load private key
-	privateKey, err := crypto.LoadPrivateKey(privateKeyPath)
+	privateKey, err := crypto.LoadPrivateKey(privateKeyPath, passphrase)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load private key: %v", err)
 	}
 
-	// Split the input into encrypted key and data
+	var jsonData []byte
+	if crypto.IsEnvelope(input) {
+		envelope, err := crypto.ParseEnvelope(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if jsonData, _, err = envelope.Open(privateKey, method, path); err != nil {
+			return nil, err
+		}
+	} else if jsonData, err = decryptLegacy(privateKey, input); err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the JSON data
+	var result map[string]interface{}
+	if err := json.Unmarshal(jsonData, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON data: %v", err)
+	}
+
+	return result, nil
+}
+
+// decryptLegacy decrypts the unversioned [<key_id>:]<encrypted_key_hex>:<encrypted_data_hex> format
+func decryptLegacy(privateKey crypto.PrivateKey, input string) ([]byte, error) {
+	// Split the input into key ID, encrypted key and data, the key ID is optional
 	parts := strings.Split(input, ":")
+	if len(parts) == 3 {
+		parts = parts[1:]
+	}
 	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid input format. Expected format: <encrypted_key_hex>:<encrypted_data_hex>")
+		return nil, fmt.Errorf("invalid input format. Expected a v1 envelope or [<key_id>:]<encrypted_key_hex>:<encrypted_data_hex>")
 	}
 
-	encryptedKeyHex := parts[0]
-	encryptedDataHex := parts[1]
-
 	// Decode hex strings to bytes
-	encryptedKey, err := hex.DecodeString(encryptedKeyHex)
+	encryptedKey, err := hex.DecodeString(parts[0])
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode encrypted key: %v", err)
 	}
 
-	encryptedData, err := hex.DecodeString(encryptedDataHex)
+	encryptedData, err := hex.DecodeString(parts[1])
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode encrypted data: %v", err)
 	}
 
-	// Decrypt the AES key with RSA
-	aesKey, err := crypto.DecryptWithPrivateKey(privateKey, encryptedKey)
+	// Decrypt the AES key
+	aesKey, err := privateKey.Decapsulate(encryptedKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt AES key: %v", err)
 	}
 
 	// Decrypt the data with AES
-	jsonData, err := crypto.DecryptAES(aesKey, encryptedData)
+	jsonData, err := crypto.DecryptAES(aesKey, encryptedData, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decrypt data: %v", err)
 	}
 
-	// Unmarshal the JSON data
-	var result map[string]interface{}
-	if err := json.Unmarshal(jsonData, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal JSON data: %v", err)
+	return jsonData, nil
+}
+
+// decryptResponse decrypts the "data" field of a response encrypted with the request's session key
+func decryptResponse(sessionKeyHex, input string) ([]byte, error) {
+	sessionKey, err := hex.DecodeString(sessionKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode session key: %v", err)
 	}
 
-	return result, nil
-}
\ No newline at end of file
+	encryptedData, err := hex.DecodeString(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted data: %v", err)
+	}
+
+	return crypto.DecryptAES(sessionKey, encryptedData, nil)
+}
@@ -1,76 +1,222 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"tasius.my.id/todolistapi/internal/config"
 	"tasius.my.id/todolistapi/internal/infrastructure/db"
+	"tasius.my.id/todolistapi/internal/infrastructure/fieldcrypto"
+	"tasius.my.id/todolistapi/internal/infrastructure/mailer"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/interfaces/http/problem"
 	"tasius.my.id/todolistapi/internal/interfaces/http/routes"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+	"tasius.my.id/todolistapi/internal/utils/health"
 	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lifecycle"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
+	"tasius.my.id/todolistapi/internal/utils/logging"
+	"tasius.my.id/todolistapi/internal/utils/tracing"
 )
 
-func main()  {
+// backgroundTaskTimeout bounds a single background task, like a cache invalidation
+const backgroundTaskTimeout = 10 * time.Second
 
-	cfg := config.Load()
+func main() {
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	// Invalid settings are all reported at once, production refuses to start with default secrets
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Everything logs through the structured logger from here on, including the standard log package
+	logger := logging.New(&cfg.Log, os.Stdout)
+	slog.SetDefault(logger)
+
+	// Spans are exported with the configured exporter, the exporter is flushed last on shutdown
+	shutdownTracing, err := tracing.Setup(cfg)
+	if err != nil {
+		fatal("Failed to set up tracing", err)
+	}
 
 	// Connect to database without running migrations
 	postgres, err := db.ConnectWithoutMigration(cfg)
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		fatal("Failed to connect to database", err)
+	}
+	if err := postgres.Use(db.MetricsPlugin{}); err != nil {
+		fatal("Failed to register database metrics", err)
+	}
+	if err := postgres.Use(db.TracingPlugin{}); err != nil {
+		fatal("Failed to register database tracing", err)
 	}
 
 	redis, err := db.NewRedisConnection(cfg)
 	if err != nil {
-		log.Fatal("Failed to connect to Redis:", err)
+		fatal("Failed to connect to Redis", err)
 	}
+	redis.AddHook(db.RedisTracingHook{})
 
 	// Initialize JWT manager
 	jwtManager, err := jwt.NewTokenManager(&cfg.JWT, redis)
 	if err != nil {
-		log.Fatal("Failed to initialize JWT manager:", err)
+		fatal("Failed to initialize JWT manager", err)
 	}
 
-	app := fiber.New(fiber.Config{
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			code := fiber.StatusInternalServerError
-			if e, ok := err.(*fiber.Error); ok {
-				code = e.Code
-			}
-			return c.Status(code).JSON(fiber.Map{
-				"error":   true,
-				"message": err.Error(),
-			})
+	// Load the hybrid encryption keys once, they are reloaded on SIGHUP or when the files change
+	keyring, err := crypto.NewKeyring([]byte(cfg.HybridEncryption.PrivateKeyPassphrase), cfg.HybridEncryption.PrivateKeyPath, cfg.HybridEncryption.PreviousPrivateKeyPaths...)
+	if err != nil {
+		fatal("Failed to load encryption keys", err)
+	}
+
+	// Todo contents are encrypted at rest when a master key is configured
+	fieldEncryptor, err := fieldcrypto.NewEncryptor(&cfg.FieldEncryption, repositories.NewDataKeyRepository(postgres))
+	if err != nil {
+		fatal("Failed to initialize field encryption", err)
+	}
+
+	tasks := lifecycle.NewTasks(backgroundTaskTimeout)
+
+	// Readiness fails while a dependency is down, in maintenance mode and once shutdown begins
+	healthChecker := health.NewChecker(cfg.Health.CheckTimeout, health.NewMaintenance(cfg.Health.MaintenanceMode, redis),
+		health.Check{
+			Name: "postgres",
+			Ping: func(ctx context.Context) error {
+				sqlDB, err := postgres.DB()
+				if err != nil {
+					return err
+				}
+				return sqlDB.PingContext(ctx)
+			},
+		},
+		health.Check{
+			Name: "redis",
+			Ping: func(ctx context.Context) error {
+				return redis.Ping(ctx).Err()
+			},
 		},
+	)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: problem.Write,
 	})
 
 	app.Use(requestid.New())
-
-	app.Use(logger.New(logger.Config{
-		Format: "------------------------\n Time: ${time}\n Status: ${status}\n Latency: ${latency}\n IP: ${ip}\n Method: ${method}\n Path: ${path} \n RequestId: ${locals:requestid}\n------------------------\n",
-	}))
+	app.Use(middleware.TracingMiddleware())
+	app.Use(middleware.MetricsMiddleware())
+	app.Use(middleware.RequestLogger(logger))
 
 	app.Use(recover.New())
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowMethods: "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
-		AllowHeaders: "Origin,Content-Type,Accept,Authorization",
+		AllowHeaders: "Origin,Content-Type,Accept,Authorization," + middleware.APIKeyHeader + "," + middleware.ClientPublicKeyHeader,
+		// Browser clients only see the headers listed here, they need to know the response is encrypted
+		ExposeHeaders: middleware.EncryptedResponseHeader,
 	}))
 
 	routes.SetupRoutes(app, routes.RoutesDependencies{
-		Db:         postgres,
-		RedisClient: redis,
-		Config:     cfg,
-		JWTManager: jwtManager,
+		Db:             postgres,
+		RedisClient:    redis,
+		Config:         cfg,
+		JWTManager:     jwtManager,
+		Mailer:         mailer.NewMailer(&cfg.Mail, logger),
+		LoginTracker:   lockout.NewAttemptTracker(&cfg.Lockout, redis),
+		Keyring:        keyring,
+		Tasks:          tasks,
+		FieldEncryptor: fieldEncryptor,
+		Health:         healthChecker,
+		Logger:         logger,
+	})
+
+	// Components start in this order and stop in reverse: readiness fails first so load balancers stop
+	// sending requests, the server drains, then background tasks finish, and the connections they use
+	// are closed last
+	manager := lifecycle.NewManager()
+	manager.Add(lifecycle.Component{
+		Name: "tracing",
+		Stop: shutdownTracing,
+	})
+	manager.Add(lifecycle.Component{
+		Name: "postgres",
+		Stop: func(context.Context) error {
+			sqlDB, err := postgres.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.Close()
+		},
+	})
+	manager.Add(lifecycle.Component{
+		Name: "redis",
+		Stop: func(context.Context) error {
+			return redis.Close()
+		},
+	})
+	manager.Add(lifecycle.Worker(manager, "encryption key watcher", func(ctx context.Context) {
+		keyring.Watch(ctx, cfg.HybridEncryption.ReloadInterval)
+	}))
+	manager.Add(lifecycle.Component{
+		Name: "background tasks",
+		Stop: tasks.Stop,
 	})
-	
-	log.Printf("Server starting on port %s", cfg.AppPort)
-	if err := app.Listen(":" + cfg.AppPort); err != nil {
-		log.Fatal("Failed to start server:", err)
+	manager.Add(lifecycle.Component{
+		Name: "http server",
+		Start: func(context.Context) error {
+			// Listening up front reports a port in use as a start failure
+			listener, err := net.Listen("tcp", ":"+cfg.AppPort)
+			if err != nil {
+				return err
+			}
+			slog.Info("Server starting", "port", cfg.AppPort)
+			manager.Go("http server", func() error {
+				return app.Listener(listener)
+			})
+			return nil
+		},
+		Stop: app.ShutdownWithContext,
+	})
+	manager.Add(lifecycle.Component{
+		Name: "readiness",
+		Stop: func(ctx context.Context) error {
+			healthChecker.SetDraining()
+			// Keep serving while load balancers notice the failing probe
+			select {
+			case <-time.After(cfg.Health.DrainDelay):
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := manager.Run(ctx, cfg.ShutdownTimeout); err != nil {
+		fatal("Server stopped with an error", err)
 	}
-	
-}
\ No newline at end of file
+	slog.Info("Server stopped")
+}
+
+// fatal logs a startup or shutdown failure and exits
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/infrastructure/db"
+	"tasius.my.id/todolistapi/internal/utils/health"
+)
+
+func main() {
+	enable := flag.Bool("enable", false, "Take every instance out of rotation")
+	disable := flag.Bool("disable", false, "Put the instances back into rotation")
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *enable == *disable {
+		log.Fatal("Exactly one of -enable or -disable is required")
+	}
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	redis, err := db.NewRedisConnection(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+	defer redis.Close()
+
+	maintenance := health.NewMaintenance(false, redis)
+	if err := maintenance.Set(context.Background(), *enable); err != nil {
+		log.Fatalf("Failed to set maintenance mode: %v", err)
+	}
+
+	if *enable {
+		fmt.Println("Maintenance mode enabled, /readyz now fails on every instance")
+	} else {
+		fmt.Println("Maintenance mode disabled")
+		if cfg.Health.MaintenanceMode {
+			fmt.Println("MAINTENANCE_MODE is still set in the configuration of this environment")
+		}
+	}
+}
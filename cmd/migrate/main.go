@@ -1,26 +1,163 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"os"
+	"strconv"
 
 	"tasius.my.id/todolistapi/internal/config"
 	"tasius.my.id/todolistapi/internal/infrastructure/db"
 )
 
+const usage = `Usage: migrate <command> [flags]
+
+Commands:
+  up [-dry-run]          Apply all pending migrations (default)
+  down [-dry-run] N      Roll back the last N migrations
+  status                 List migrations and when they were applied
+  create [-dir DIR] NAME Create empty up and down files for a new migration
+`
+
+var (
+	dryRun      *bool
+	configFlags *config.Flags
+)
+
 func main() {
-	// Load configuration
-	cfg := config.Load()
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	// -dry-run is accepted before the command as well
+	dryRun = flag.Bool("dry-run", false, "Print what would change without changing anything")
+	configFlags = config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	command, args := "up", flag.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "up":
+		up(args)
+	case "down":
+		down(args)
+	case "status":
+		status()
+	case "create":
+		create(args)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func up(args []string) {
+	flags := flag.NewFlagSet("up", flag.ExitOnError)
+	flags.BoolVar(dryRun, "dry-run", *dryRun, "Print the migrations that would be applied without applying them")
+	flags.Parse(args)
+
+	migrations, err := newMigrator().Up(context.Background(), *dryRun)
+	for _, migration := range migrations {
+		printMigration("Applied", migration, migration.Up, *dryRun)
+	}
+	if err != nil {
+		log.Fatal("Failed to run migrations: ", err)
+	}
+
+	if len(migrations) == 0 {
+		log.Println("No pending migrations")
+	} else if !*dryRun {
+		log.Println("Migrations completed successfully")
+	}
+}
+
+func down(args []string) {
+	flags := flag.NewFlagSet("down", flag.ExitOnError)
+	flags.BoolVar(dryRun, "dry-run", *dryRun, "Print the migrations that would be rolled back without rolling them back")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("Usage: migrate down [-dry-run] N")
+	}
+	steps, err := strconv.Atoi(flags.Arg(0))
+	if err != nil || steps < 1 {
+		log.Fatalf("Invalid number of migrations to roll back: %s", flags.Arg(0))
+	}
+
+	migrations, err := newMigrator().Down(context.Background(), steps, *dryRun)
+	for _, migration := range migrations {
+		printMigration("Rolled back", migration, migration.Down, *dryRun)
+	}
+	if err != nil {
+		log.Fatal("Failed to roll back migrations: ", err)
+	}
+
+	if len(migrations) == 0 {
+		log.Println("No migrations to roll back")
+	}
+}
+
+func status() {
+	statuses, err := newMigrator().Status(context.Background())
+	if err != nil {
+		log.Fatal("Failed to read migration status: ", err)
+	}
+
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Unknown:
+			state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05") + " (not in this build)"
+		case status.AppliedAt != nil:
+			state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+	}
+}
+
+func create(args []string) {
+	flags := flag.NewFlagSet("create", flag.ExitOnError)
+	dir := flags.String("dir", db.MigrationsDir, "Directory of the migration files")
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		log.Fatal("Usage: migrate create [-dir DIR] NAME")
+	}
+
+	upPath, downPath, err := db.CreateMigration(*dir, flags.Arg(0))
+	if err != nil {
+		log.Fatal("Failed to create migration: ", err)
+	}
+
+	fmt.Printf("Created %s\nCreated %s\n", upPath, downPath)
+}
+
+func newMigrator() *db.Migrator {
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	// Connect to database
 	dbConn, err := db.ConnectWithoutMigration(cfg)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	// Run migrations
-	if err := db.Migrate(dbConn); err != nil {
-		log.Fatal("Failed to run migrations:", err)
+	migrator, err := db.NewMigrator(dbConn)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
 	}
+	return migrator
+}
 
-	log.Println("Migrations completed successfully")
+func printMigration(action string, migration db.Migration, script string, dryRun bool) {
+	if !dryRun {
+		fmt.Printf("%s %04d_%s\n", action, migration.Version, migration.Name)
+		return
+	}
+	fmt.Printf("-- Would run %04d_%s\n%s\n", migration.Version, migration.Name, script)
 }
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/infrastructure/db"
+	"tasius.my.id/todolistapi/internal/infrastructure/fieldcrypto"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+)
+
+// reencrypt rewraps the data keys of all users with the current master key after a rotation,
+// and with -backfill encrypts todos that were stored before field encryption was enabled.
+func main() {
+	backfill := flag.Bool("backfill", false, "Also encrypt todos still stored in plaintext")
+	batchSize := flag.Int("batch-size", 100, "Number of rows processed per query")
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *batchSize < 1 {
+		log.Fatal("-batch-size must be positive")
+	}
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dbConn, err := db.ConnectWithoutMigration(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	encryptor, err := fieldcrypto.NewEncryptor(&cfg.FieldEncryption, repositories.NewDataKeyRepository(dbConn))
+	if err != nil {
+		log.Fatal("Failed to initialize field encryption:", err)
+	}
+	if encryptor == nil {
+		log.Fatal("Field encryption is not configured, set FIELD_ENCRYPTION_MASTER_KEY_ID and FIELD_ENCRYPTION_MASTER_KEYS")
+	}
+
+	ctx := context.Background()
+
+	rewrapped, err := encryptor.RewrapDataKeys(ctx, *batchSize)
+	if err != nil {
+		log.Fatalf("Failed to rewrap data keys after %d keys: %v", rewrapped, err)
+	}
+	fmt.Printf("Rewrapped %d data keys with master key %s\n", rewrapped, cfg.FieldEncryption.MasterKeyID)
+
+	if *backfill {
+		encrypted, err := repositories.EncryptTodoFields(ctx, dbConn, encryptor, *batchSize)
+		if err != nil {
+			log.Fatalf("Failed to encrypt todos after %d todos: %v", encrypted, err)
+		}
+		fmt.Printf("Encrypted %d todos\n", encrypted)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/infrastructure/db"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+)
+
+func main() {
+	email := flag.String("email", "", "Email of the user")
+	role := flag.String("role", entities.RoleAdmin, "Role to assign: 'user' or 'admin'")
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("-email is required")
+	}
+	if *role != entities.RoleUser && *role != entities.RoleAdmin {
+		log.Fatalf("Invalid role: %s. Use 'user' or 'admin'", *role)
+	}
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dbConn, err := db.ConnectWithoutMigration(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	userRepo := repositories.NewUserRepository(dbConn)
+	ctx := context.Background()
+
+	user, err := userRepo.GetByEmail(ctx, *email)
+	if err != nil {
+		log.Fatalf("Failed to get user: %v", err)
+	}
+	if user == nil {
+		log.Fatalf("User not found: %s", *email)
+	}
+
+	user.Role = *role
+	if err := userRepo.Update(ctx, user); err != nil {
+		log.Fatalf("Failed to update user: %v", err)
+	}
+
+	fmt.Printf("User %s now has the role %s, they need to log in again for it to take effect\n", *email, *role)
+}
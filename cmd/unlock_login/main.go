@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/infrastructure/db"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
+)
+
+func main() {
+	email := flag.String("email", "", "Email address to unlock")
+	ip := flag.String("ip", "", "IP address to unlock")
+	configFlags := config.RegisterFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *email == "" && *ip == "" {
+		log.Fatal("Either -email or -ip is required")
+	}
+
+	cfg, err := config.Load(configFlags)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	redis, err := db.NewRedisConnection(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to Redis:", err)
+	}
+	defer redis.Close()
+
+	tracker := lockout.NewAttemptTracker(&cfg.Lockout, redis)
+	ctx := context.Background()
+
+	if *email != "" {
+		if err := tracker.Unlock(ctx, *email); err != nil {
+			log.Fatalf("Failed to unlock email: %v", err)
+		}
+		fmt.Printf("Unlocked login for email %s\n", *email)
+	}
+
+	if *ip != "" {
+		if err := tracker.UnlockIP(ctx, *ip); err != nil {
+			log.Fatalf("Failed to unlock IP: %v", err)
+		}
+		fmt.Printf("Unlocked login for IP %s\n", *ip)
+	}
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type ListUsersRequest struct {
+	Search         string `query:"q"`
+	Role           string `query:"role" validate:"omitempty,enum=role"`
+	IsActive       *bool  `query:"is_active"`
+	OrganizationID string `query:"organization_id" validate:"omitempty,uuid"`
+	Page           int    `query:"page" validate:"min=0"`
+	Limit          int    `query:"limit" validate:"min=0,max=100"`
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,enum=role"`
+}
+
+type AdminUserResponse struct {
+	ID               string    `json:"id"`
+	Email            string    `json:"email"`
+	Name             string    `json:"name"`
+	Role             string    `json:"role"`
+	IsActive         bool      `json:"is_active"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+type UserListResponse struct {
+	Users []AdminUserResponse `json:"users"`
+	Total int64               `json:"total"`
+	Page  int                 `json:"page"`
+	Limit int                 `json:"limit"`
+}
+
+type UserStatsResponse struct {
+	UserID           string    `json:"user_id"`
+	TodoCount        int64     `json:"todo_count"`
+	APIKeyCount      int64     `json:"api_key_count"`
+	TwoFactorEnabled bool      `json:"two_factor_enabled"`
+	IsActive         bool      `json:"is_active"`
+	MemberSince      time.Time `json:"member_since"`
+}
+
+func NewAdminUserResponse(user *entities.User) *AdminUserResponse {
+	return &AdminUserResponse{
+		ID:               user.ID,
+		Email:            user.Email,
+		Name:             user.Name,
+		Role:             user.Role,
+		IsActive:         user.IsActive,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		CreatedAt:        user.CreatedAt,
+		UpdatedAt:        user.UpdatedAt,
+	}
+}
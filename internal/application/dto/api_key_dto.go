@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"time"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" validate:"required,min=3,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,enum=scope"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=0,max=365"`
+}
+
+type APIKeyResponse struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Prefix         string     `json:"prefix"`
+	Scopes         []string   `json:"scopes"`
+	OrganizationID *string    `json:"organization_id"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// CreatedAPIKeyResponse is only returned once, the plaintext key can't be retrieved later
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+func NewAPIKeyResponse(apiKey *entities.APIKey) *APIKeyResponse {
+	return &APIKeyResponse{
+		ID:             apiKey.ID,
+		Name:           apiKey.Name,
+		Prefix:         apiKey.Prefix,
+		Scopes:         apiKey.Scopes,
+		OrganizationID: apiKey.OrganizationID,
+		ExpiresAt:      apiKey.ExpiresAt,
+		LastUsedAt:     apiKey.LastUsedAt,
+		CreatedAt:      apiKey.CreatedAt,
+	}
+}
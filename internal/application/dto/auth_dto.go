@@ -7,18 +7,31 @@ type RegisterRequest struct {
 }
 
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email    string   `json:"email" validate:"required,email"`
+	Password string   `json:"password" validate:"required"`
+	Scopes   []string `json:"scopes,omitempty"`
 }
 
 type AuthResponse struct {
-	User         *UserResponse `json:"user"`
-	AccessToken  string        `json:"access_token"`
-	RefreshToken string        `json:"refresh_token"`
-	TokenType    string        `json:"token_type"`
-	ExpiresIn    int64         `json:"expires_in"`
+	User           *UserResponse `json:"user,omitempty"`
+	AccessToken    string        `json:"access_token,omitempty"`
+	RefreshToken   string        `json:"refresh_token,omitempty"`
+	TokenType      string        `json:"token_type,omitempty"`
+	ExpiresIn      int64         `json:"expires_in,omitempty"`
+	Scopes         []string      `json:"scopes,omitempty"`
+	OrganizationID string        `json:"organization_id,omitempty"`
+	MFARequired    bool          `json:"mfa_required,omitempty"`
+	MFAToken       string        `json:"mfa_token,omitempty"`
 }
 
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
-}
\ No newline at end of file
+}
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
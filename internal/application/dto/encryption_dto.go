@@ -0,0 +1,7 @@
+package dto
+
+type PublicKeyResponse struct {
+	KeyID     string `json:"key_id"`
+	Algorithm string `json:"algorithm"`
+	PublicKey string `json:"public_key"`
+}
@@ -0,0 +1,66 @@
+package dto
+
+import (
+	"time"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type CreateOrganizationRequest struct {
+	Name string `json:"name" validate:"required,min=2,max=100"`
+}
+
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type OrganizationMemberResponse struct {
+	UserID   string    `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,enum=organization_role"`
+}
+
+type InviteResponse struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type AcceptInviteRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SwitchOrganizationRequest selects the organization new tokens act in, empty for the personal space
+type SwitchOrganizationRequest struct {
+	OrganizationID string `json:"organization_id" validate:"omitempty,uuid"`
+}
+
+func NewOrganizationResponse(organization *entities.Organization, role string) *OrganizationResponse {
+	return &OrganizationResponse{
+		ID:        organization.ID,
+		Name:      organization.Name,
+		Role:      role,
+		CreatedAt: organization.CreatedAt,
+	}
+}
+
+func NewOrganizationMemberResponse(member *entities.OrganizationMember) *OrganizationMemberResponse {
+	return &OrganizationMemberResponse{
+		UserID:   member.UserID,
+		Name:     member.User.Name,
+		Email:    member.User.Email,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt,
+	}
+}
@@ -12,13 +12,10 @@ type UpdateTodoRequest struct {
 	Description string `json:"description" validate:"required,min=5"`
 }
 
-type DeleteTodoRequest struct {
-	ID string `json:"id"`
-}
-
 type TodoResponse struct {
-	ID          string `json:"id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	UserID      string `json:"user_id"`
+	ID             string `json:"id"`
+	Title          string `json:"title"`
+	Description    string `json:"description"`
+	UserID         string `json:"user_id"`
+	OrganizationID string `json:"organization_id,omitempty"`
 }
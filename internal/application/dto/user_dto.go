@@ -8,6 +8,7 @@ type UserResponse struct {
 	ID        string    `json:"id"`
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
+	Role      string    `json:"role"`
 }
 
 func NewUserResponse(user *entities.User) *UserResponse {
@@ -15,5 +16,28 @@ func NewUserResponse(user *entities.User) *UserResponse {
 		ID:        user.ID,
 		Email:     user.Email,
 		Name:      user.Name,
+		Role:      user.Role,
 	}
-}
\ No newline at end of file
+}
+type UpdateProfileRequest struct {
+	Name  string `json:"name,omitempty" validate:"required_without=Email,omitempty,min=2"`
+	Email string `json:"email,omitempty" validate:"required_without=Name,omitempty,email"`
+}
+
+type ProfileUpdateResponse struct {
+	User         *UserResponse `json:"user"`
+	PendingEmail string        `json:"pending_email,omitempty"`
+}
+
+type VerifyEmailChangeRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=6"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" validate:"required"`
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
+)
+
+const (
+	defaultPageLimit = 20
+)
+
+var (
+	errSelfChange   = apperrors.Forbidden("admin_self_change", "admins can't change their own account")
+	errUserInactive = apperrors.Conflict("user_inactive", "user is inactive")
+)
+
+type adminService struct {
+	userRepo     repositories.UserRepository
+	todoRepo     repositories.TodoRepository
+	apiKeyRepo   repositories.APIKeyRepository
+	authService  services.AuthService
+	jwtManager   *jwt.TokenManager
+	loginTracker *lockout.AttemptTracker
+}
+
+func NewAdminService(userRepo repositories.UserRepository, todoRepo repositories.TodoRepository, apiKeyRepo repositories.APIKeyRepository, authService services.AuthService, jwtManager *jwt.TokenManager, loginTracker *lockout.AttemptTracker) services.AdminService {
+	return &adminService{
+		userRepo:     userRepo,
+		todoRepo:     todoRepo,
+		apiKeyRepo:   apiKeyRepo,
+		authService:  authService,
+		jwtManager:   jwtManager,
+		loginTracker: loginTracker,
+	}
+}
+
+// ListUsers implements services.AdminService.
+func (s *adminService) ListUsers(ctx context.Context, req *dto.ListUsersRequest) (*dto.UserListResponse, error) {
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.Limit < 1 {
+		req.Limit = defaultPageLimit
+	}
+
+	users, total, err := s.userRepo.List(ctx, repositories.UserFilter{
+		Search:         req.Search,
+		Role:           req.Role,
+		IsActive:       req.IsActive,
+		OrganizationID: req.OrganizationID,
+		Offset:         (req.Page - 1) * req.Limit,
+		Limit:          req.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.AdminUserResponse, 0, len(users))
+	for _, user := range users {
+		result = append(result, *dto.NewAdminUserResponse(&user))
+	}
+
+	return &dto.UserListResponse{
+		Users: result,
+		Total: total,
+		Page:  req.Page,
+		Limit: req.Limit,
+	}, nil
+}
+
+// GetUser implements services.AdminService.
+func (s *adminService) GetUser(ctx context.Context, id string) (*dto.AdminUserResponse, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewAdminUserResponse(user), nil
+}
+
+// SetUserActive implements services.AdminService.
+func (s *adminService) SetUserActive(ctx context.Context, principal *entities.Principal, id string, active bool) (*dto.AdminUserResponse, error) {
+	user, err := s.getOtherUser(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.IsActive = active
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// A deactivated user is signed out everywhere right away
+	if !active {
+		if err := s.jwtManager.RevokeAllSessions(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return dto.NewAdminUserResponse(user), nil
+}
+
+// UpdateUserRole implements services.AdminService.
+func (s *adminService) UpdateUserRole(ctx context.Context, principal *entities.Principal, id string, req *dto.UpdateRoleRequest) (*dto.AdminUserResponse, error) {
+	user, err := s.getOtherUser(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Role = req.Role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	// Existing tokens carry the scopes of the old role
+	if err := s.jwtManager.RevokeAllSessions(user.ID); err != nil {
+		return nil, err
+	}
+
+	return dto.NewAdminUserResponse(user), nil
+}
+
+// ForceLogout implements services.AdminService.
+func (s *adminService) ForceLogout(ctx context.Context, id string) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.jwtManager.RevokeAllSessions(user.ID)
+}
+
+// ResetUserPassword implements services.AdminService.
+func (s *adminService) ResetUserPassword(ctx context.Context, principal *entities.Principal, id string) error {
+	user, err := s.getOtherUser(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		return errUserInactive
+	}
+
+	if err := s.jwtManager.RevokeAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	// The user picks the new password themselves through the reset link
+	return s.authService.SendResetLink(ctx, user.Email)
+}
+
+// UnlockUser implements services.AdminService.
+func (s *adminService) UnlockUser(ctx context.Context, id string) error {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return s.loginTracker.Unlock(ctx, user.Email)
+}
+
+// GetUserStats implements services.AdminService.
+func (s *adminService) GetUserStats(ctx context.Context, id string) (*dto.UserStatsResponse, error) {
+	user, err := s.getUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	todoCount, err := s.todoRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyCount, err := s.apiKeyRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.UserStatsResponse{
+		UserID:           user.ID,
+		TodoCount:        todoCount,
+		APIKeyCount:      apiKeyCount,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+		IsActive:         user.IsActive,
+		MemberSince:      user.CreatedAt,
+	}, nil
+}
+
+func (s *adminService) getUser(ctx context.Context, id string) (*entities.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errUserNotFound
+	}
+
+	return user, nil
+}
+
+// getOtherUser loads a user other than the calling admin, so admins can't lock themselves out
+func (s *adminService) getOtherUser(ctx context.Context, principal *entities.Principal, id string) (*entities.User, error) {
+	if principal.UserID == id {
+		return nil, errSelfChange
+	}
+
+	return s.getUser(ctx, id)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/password"
+)
+
+const (
+	apiKeyPrefix        = "tdl"
+	apiKeyLength        = 40
+	apiKeyDisplayLength = 12 // "tdl_" followed by the first 8 characters of the key
+	lastUsedUpdateEvery = time.Minute
+)
+
+var (
+	errInvalidAPIKey  = apperrors.Unauthenticated("invalid_api_key", "invalid or expired api key")
+	errAPIKeyNotFound = apperrors.NotFound("api_key_not_found", "api key not found")
+)
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository) services.APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+	}
+}
+
+// CreateAPIKey implements services.APIKeyService.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, principal *entities.Principal, req *dto.CreateAPIKeyRequest) (*dto.CreatedAPIKeyResponse, error) {
+	// A key can't be granted more than the credential creating it
+	scopes, err := entities.NarrowScopes(principal.Scopes, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := password.GenerateAPIKey(apiKeyPrefix, apiKeyLength)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKey := &entities.APIKey{
+		UserID:  principal.UserID,
+		Name:    req.Name,
+		Prefix:  key[:apiKeyDisplayLength],
+		KeyHash: password.HashToken(key),
+		Scopes:  scopes,
+		// The key acts in the organization the caller is currently working in
+		OrganizationID: entities.OrganizationRef(principal.OrganizationID),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, apiKey); err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	return &dto.CreatedAPIKeyResponse{
+		APIKeyResponse: *dto.NewAPIKeyResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+// GetAllAPIKeys implements services.APIKeyService.
+func (s *apiKeyService) GetAllAPIKeys(ctx context.Context, principal *entities.Principal) ([]dto.APIKeyResponse, error) {
+	apiKeys, err := s.apiKeyRepo.GetAllByUserID(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.APIKeyResponse, 0, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		result = append(result, *dto.NewAPIKeyResponse(&apiKey))
+	}
+
+	return result, nil
+}
+
+// RevokeAPIKey implements services.APIKeyService.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, principal *entities.Principal, id string) error {
+	if err := s.apiKeyRepo.Delete(ctx, id, principal.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errAPIKeyNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Authenticate implements services.APIKeyService.
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*entities.APIKey, error) {
+	apiKey, err := s.apiKeyRepo.GetByHash(ctx, password.HashToken(key))
+	if err != nil {
+		return nil, err
+	}
+
+	if apiKey == nil || apiKey.IsExpired() || !apiKey.User.IsActive {
+		return nil, errInvalidAPIKey
+	}
+
+	// Only write the usage timestamp once in a while instead of on every request
+	now := time.Now()
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) > lastUsedUpdateEvery {
+		if err := s.apiKeyRepo.UpdateLastUsed(ctx, apiKey.ID, now); err != nil {
+			return nil, err
+		}
+		apiKey.LastUsedAt = &now
+	}
+
+	return apiKey, nil
+}
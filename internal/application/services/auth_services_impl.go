@@ -1,66 +1,112 @@
 package services
 
 import (
-	"errors"
-	"log"
-	"strings"
+	"context"
+	"fmt"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"tasius.my.id/todolistapi/internal/application/dto"
 	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
 	"tasius.my.id/todolistapi/internal/domain/entities"
 	"tasius.my.id/todolistapi/internal/domain/repositories"
 	"tasius.my.id/todolistapi/internal/domain/services"
-	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
 	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lifecycle"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
 	"tasius.my.id/todolistapi/internal/utils/password"
 )
 
+var (
+	errInvalidCredentials   = apperrors.Unauthenticated("invalid_credentials", "invalid email or password")
+	errRefreshTokenRequired = apperrors.Validation("refresh_token_required", "Refresh token is required")
+)
+
 type authService struct {
-	userRepo    repositories.UserRepository
-	redisClient *redis.Client
-	jwtConfig   *config.JWTConfig
-	jwtManager  *jwt.TokenManager
+	userRepo     repositories.UserRepository
+	redisClient  *redis.Client
+	jwtConfig    *config.JWTConfig
+	jwtManager   *jwt.TokenManager
+	mailer       services.Mailer
+	resetConfig  *config.PasswordResetConfig
+	loginTracker *lockout.AttemptTracker
+	tasks        *lifecycle.Tasks
 }
 
-func NewAuthService(userRepo repositories.UserRepository, redisClient *redis.Client, jwtConfig *config.JWTConfig, jwtManager *jwt.TokenManager) services.AuthService {
+func NewAuthService(userRepo repositories.UserRepository, redisClient *redis.Client, jwtConfig *config.JWTConfig, jwtManager *jwt.TokenManager, mailer services.Mailer, resetConfig *config.PasswordResetConfig, loginTracker *lockout.AttemptTracker, tasks *lifecycle.Tasks) services.AuthService {
 	return &authService{
-		userRepo:    userRepo,
-		redisClient: redisClient,
-		jwtConfig:   jwtConfig,
-		jwtManager:  jwtManager,
+		userRepo:     userRepo,
+		redisClient:  redisClient,
+		jwtConfig:    jwtConfig,
+		jwtManager:   jwtManager,
+		mailer:       mailer,
+		resetConfig:  resetConfig,
+		loginTracker: loginTracker,
+		tasks:        tasks,
 	}
 }
 
 // Login implements services.AuthService.
-func (s *authService) Login(ctx *fiber.Ctx, req *dto.LoginRequest) (*dto.AuthResponse, error) {
-	user, err := s.userRepo.GetByEmail(ctx.Context(), req.Email)
+func (s *authService) Login(ctx context.Context, req *dto.LoginRequest, clientIP string) (*dto.AuthResponse, error) {
+	if err := s.loginTracker.Check(ctx, req.Email, clientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Println("password", req.Password)
-	log.Println("user password", user.Password)
+	// Unknown emails, inactive accounts and wrong passwords all fail the same way
+	if user == nil || !user.IsActive {
+		password.CheckDummyPassword(req.Password)
+		return nil, s.loginFailed(ctx, req.Email, clientIP)
+	}
 
 	if err := password.CheckPassword(req.Password, user.Password); err != nil {
-		return nil, errors.New("invalid password")
+		return nil, s.loginFailed(ctx, req.Email, clientIP)
+	}
+
+	// Clients may ask for fewer scopes than the user has, e.g. for read-only integrations
+	scopes, err := entities.NarrowScopes(user.AllowedScopes(), req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	// With two-factor authentication the tokens are only issued once the code is verified. Failures
+	// are only reset then, else knowing the password would allow guessing codes without a limit.
+	if user.TwoFactorEnabled {
+		mfaToken, err := s.jwtManager.GenerateMFAToken(user, scopes, "")
+		if err != nil {
+			return nil, err
+		}
+		return &dto.AuthResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	if err := s.loginTracker.Reset(ctx, req.Email); err != nil {
+		return nil, err
 	}
 
-	return s.generateAuthResponse(user)
+	return s.generateAuthResponse(user, scopes)
 }
 
-// RefreshToken implements services.AuthService.
-func (s *authService) RefreshToken(ctx *fiber.Ctx, refreshToken string) (*dto.AuthResponse, error) {
-	token := ctx.Get("Authorization")
-	if token == "" {
-		return nil, errors.New("Refresh token is required")
+func (s *authService) loginFailed(ctx context.Context, email, clientIP string) error {
+	if err := s.loginTracker.RegisterFailure(ctx, email, clientIP); err != nil {
+		return err
 	}
+	return errInvalidCredentials
+}
 
-	// Remove "Bearer " prefix if present
-	token = strings.TrimPrefix(token, "Bearer ")
+// RefreshToken implements services.AuthService.
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*dto.AuthResponse, error) {
+	if refreshToken == "" {
+		return nil, errRefreshTokenRequired
+	}
 
-	tokens, err := s.jwtManager.RefreshToken(token)
+	tokens, err := s.jwtManager.RefreshToken(refreshToken)
 	if err != nil {
 		return nil, err
 	}
@@ -74,13 +120,13 @@ func (s *authService) RefreshToken(ctx *fiber.Ctx, refreshToken string) (*dto.Au
 }
 
 // Register implements services.AuthService.
-func (s *authService) Register(ctx *fiber.Ctx, req *dto.RegisterRequest) (*dto.AuthResponse, error) {
-	exsist, err := s.userRepo.ExistsByEmail(ctx.Context(), req.Email)
+func (s *authService) Register(ctx context.Context, req *dto.RegisterRequest) (*dto.AuthResponse, error) {
+	exsist, err := s.userRepo.ExistsByEmail(ctx, req.Email)
 	if err != nil {
 		return nil, err
 	}
 	if exsist {
-		return nil, errors.New("email already exists")
+		return nil, errEmailAlreadyExists
 	}
 
 	hashPassword, err := password.HashPassword(req.Password)
@@ -95,21 +141,21 @@ func (s *authService) Register(ctx *fiber.Ctx, req *dto.RegisterRequest) (*dto.A
 		IsActive: true,
 	}
 
-	if err := s.userRepo.Create(ctx.Context(), user); err != nil {
-		return nil, errors.New("failed to create user")
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	return s.generateAuthResponse(user)
+	return s.generateAuthResponse(user, user.AllowedScopes())
 }
 
 // ValidateToken implements services.AuthService.
-func (s *authService) ValidateToken(ctx *fiber.Ctx, token string) (*dto.UserResponse, error) {
+func (s *authService) ValidateToken(ctx context.Context, token string) (*dto.UserResponse, error) {
 	claims, err := s.jwtManager.ValidateToken(token, jwt.AccessToken)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.userRepo.GetByID(ctx.Context(), claims.UserID)
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -117,14 +163,9 @@ func (s *authService) ValidateToken(ctx *fiber.Ctx, token string) (*dto.UserResp
 	return dto.NewUserResponse(user), nil
 }
 
-func (s *authService) Logout(ctx *fiber.Ctx) error {
-	userID, err := middleware.GetUserIDFromContext(ctx)
-	if err != nil {
-		return err
-	}
-
+func (s *authService) Logout(ctx context.Context, principal *entities.Principal) error {
 	// Invalidate all tokens for this user
-	err = s.jwtManager.Logout(userID)
+	err := s.jwtManager.Logout(principal.UserID)
 	if err != nil {
 		return err
 	}
@@ -132,18 +173,25 @@ func (s *authService) Logout(ctx *fiber.Ctx) error {
 	return nil
 }
 
-func (s *authService) generateAuthResponse(user *entities.User) (*dto.AuthResponse, error) {
+// generateAuthResponse issues tokens for the user's personal space, organizations are entered by switching afterwards
+func (s *authService) generateAuthResponse(user *entities.User, scopes []string) (*dto.AuthResponse, error) {
+	return buildAuthResponse(s.jwtManager, s.jwtConfig, user, scopes, "")
+}
+
+func buildAuthResponse(jwtManager *jwt.TokenManager, jwtConfig *config.JWTConfig, user *entities.User, scopes []string, organizationID string) (*dto.AuthResponse, error) {
 
-	tokens, err := s.jwtManager.GenerateTokenPair(user)
+	tokens, err := jwtManager.GenerateTokenPair(user, scopes, organizationID)
 	if err != nil {
 		return nil, err
 	}
 
 	return &dto.AuthResponse{
-		User:         dto.NewUserResponse(user),	
-		AccessToken:  tokens[jwt.AccessToken],
-		RefreshToken: tokens[jwt.RefreshToken],
-		TokenType:    "Bearer",
-		ExpiresIn:    s.jwtConfig.Expiration.Milliseconds(),
+		User:           dto.NewUserResponse(user),
+		AccessToken:    tokens[jwt.AccessToken],
+		RefreshToken:   tokens[jwt.RefreshToken],
+		TokenType:      "Bearer",
+		ExpiresIn:      jwtConfig.Expiration.Milliseconds(),
+		Scopes:         scopes,
+		OrganizationID: organizationID,
 	}, nil
 }
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
+	"tasius.my.id/todolistapi/internal/utils/password"
+)
+
+const (
+	testPassword    = "correct horse battery staple"
+	testClientIP    = "192.0.2.1"
+	testMaxAttempts = 3
+
+	testMaxResetRequests = 2
+)
+
+// testPasswordHash is hashed once, bcrypt makes every hash take a noticeable time
+var testPasswordHash = sync.OnceValues(func() (string, error) {
+	return password.HashPassword(testPassword)
+})
+
+type authFixture struct {
+	service    *authService
+	jwtManager *jwt.TokenManager
+	mailer     *fakeMailer
+}
+
+func newAuthFixture(t *testing.T, users ...*entities.User) *authFixture {
+	t.Helper()
+
+	hash, err := testPasswordHash()
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	for _, user := range users {
+		user.Password = hash
+	}
+
+	redisClient, _ := newRedis(t)
+	jwtConfig, jwtManager := newJWT(t, redisClient)
+	// Failures lock the email right away once there are too many, without delays in between
+	loginTracker := lockout.NewAttemptTracker(&config.LockoutConfig{
+		MaxAttempts: testMaxAttempts,
+		Window:      time.Minute,
+		Duration:    time.Minute,
+	}, redisClient)
+	resetConfig := &config.PasswordResetConfig{
+		TokenExpiration: 30 * time.Minute,
+		URL:             "http://localhost:3000/reset-password",
+		MaxRequests:     testMaxResetRequests,
+		IPMaxRequests:   testMaxResetRequests + 1,
+		RequestWindow:   time.Hour,
+	}
+	mailer := &fakeMailer{}
+	service := NewAuthService(newFakeUserRepository(users...), redisClient, jwtConfig, jwtManager, mailer, resetConfig, loginTracker, newTasks(t))
+
+	return &authFixture{service: service.(*authService), jwtManager: jwtManager, mailer: mailer}
+}
+
+func TestLogin(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     *entities.User
+		email    string
+		password string
+		// failures are the failed logins before this one
+		failures int
+		wantErr  error
+		wantMFA  bool
+	}{
+		{name: "valid credentials", user: &entities.User{Email: "alice@example.com", IsActive: true}, email: "alice@example.com", password: testPassword},
+		{name: "email in another case", user: &entities.User{Email: "alice@example.com", IsActive: true}, email: "Alice@Example.com", password: testPassword},
+		{name: "wrong password", user: &entities.User{Email: "alice@example.com", IsActive: true}, email: "alice@example.com", password: "wrong", wantErr: errInvalidCredentials},
+		{name: "unknown email", email: "nobody@example.com", password: testPassword, wantErr: errInvalidCredentials},
+		{name: "inactive user", user: &entities.User{Email: "alice@example.com"}, email: "alice@example.com", password: testPassword, wantErr: errInvalidCredentials},
+		{name: "below the lockout", user: &entities.User{Email: "alice@example.com", IsActive: true}, email: "alice@example.com", password: testPassword, failures: testMaxAttempts - 1},
+		{name: "locked out", user: &entities.User{Email: "alice@example.com", IsActive: true}, email: "alice@example.com", password: testPassword, failures: testMaxAttempts, wantErr: lockout.ErrLocked},
+		{name: "two-factor authentication", user: &entities.User{Email: "alice@example.com", IsActive: true, TwoFactorEnabled: true}, email: "alice@example.com", password: testPassword, wantMFA: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var users []*entities.User
+			if tt.user != nil {
+				users = append(users, tt.user)
+			}
+			f := newAuthFixture(t, users...)
+			ctx := context.Background()
+
+			for range tt.failures {
+				req := &dto.LoginRequest{Email: tt.email, Password: "wrong"}
+				if _, err := f.service.Login(ctx, req, testClientIP); !errors.Is(err, errInvalidCredentials) {
+					t.Fatalf("failed Login() error = %v, want %v", err, errInvalidCredentials)
+				}
+			}
+
+			got, err := f.service.Login(ctx, &dto.LoginRequest{Email: tt.email, Password: tt.password}, testClientIP)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Login() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if tt.wantMFA {
+				if !got.MFARequired || got.AccessToken != "" || got.RefreshToken != "" {
+					t.Fatalf("Login() = %+v, want only an MFA challenge", got)
+				}
+				claims, err := f.jwtManager.ValidateToken(got.MFAToken, jwt.MFAToken)
+				if err != nil || claims.UserID != tt.user.ID {
+					t.Errorf("MFA token claims = %+v, %v, want user %s", claims, err, tt.user.ID)
+				}
+				return
+			}
+
+			if got.MFARequired {
+				t.Fatalf("Login() = %+v, want tokens", got)
+			}
+			claims, err := f.jwtManager.ValidateToken(got.AccessToken, jwt.AccessToken)
+			if err != nil || claims.UserID != tt.user.ID {
+				t.Errorf("access token claims = %+v, %v, want user %s", claims, err, tt.user.ID)
+			}
+		})
+	}
+}
+
+func TestLoginResetsFailures(t *testing.T) {
+	f := newAuthFixture(t, &entities.User{Email: "alice@example.com", IsActive: true})
+	ctx := context.Background()
+
+	login := func(pass string) error {
+		_, err := f.service.Login(ctx, &dto.LoginRequest{Email: "alice@example.com", Password: pass}, testClientIP)
+		return err
+	}
+
+	// A successful login clears the failures before it, so they don't add up to a lockout
+	for range 2 {
+		for range testMaxAttempts - 1 {
+			if err := login("wrong"); !errors.Is(err, errInvalidCredentials) {
+				t.Fatalf("Login() error = %v, want %v", err, errInvalidCredentials)
+			}
+		}
+		if err := login(testPassword); err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+	}
+}
+
+func TestTwoFactorLoginKeepsFailures(t *testing.T) {
+	f := newAuthFixture(t, &entities.User{Email: "alice@example.com", IsActive: true, TwoFactorEnabled: true})
+	ctx := context.Background()
+
+	login := func(pass string) (*dto.AuthResponse, error) {
+		return f.service.Login(ctx, &dto.LoginRequest{Email: "alice@example.com", Password: pass}, testClientIP)
+	}
+
+	for range testMaxAttempts - 1 {
+		if _, err := login("wrong"); !errors.Is(err, errInvalidCredentials) {
+			t.Fatalf("Login() error = %v, want %v", err, errInvalidCredentials)
+		}
+	}
+	// The password alone doesn't complete the login, so it doesn't clear the failures either
+	if got, err := login(testPassword); err != nil || !got.MFARequired {
+		t.Fatalf("Login() = %+v, %v, want an MFA challenge", got, err)
+	}
+	if _, err := login("wrong"); !errors.Is(err, errInvalidCredentials) {
+		t.Fatalf("Login() error = %v, want %v", err, errInvalidCredentials)
+	}
+	if _, err := login(testPassword); !errors.Is(err, lockout.ErrLocked) {
+		t.Errorf("Login() error = %v, want %v", err, lockout.ErrLocked)
+	}
+}
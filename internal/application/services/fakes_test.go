@@ -0,0 +1,413 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lifecycle"
+)
+
+// newRedis returns a client of an in-memory Redis, closed when the test ends
+func newRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+func newJWT(t *testing.T, redisClient *redis.Client) (*config.JWTConfig, *jwt.TokenManager) {
+	t.Helper()
+
+	cfg := &config.JWTConfig{
+		PrivateKey:        "test-secret",
+		Expiration:        15 * time.Minute,
+		RefreshExpiration: 24 * time.Hour,
+		MFAExpiration:     5 * time.Minute,
+	}
+	manager, err := jwt.NewTokenManager(cfg, redisClient)
+	if err != nil {
+		t.Fatalf("NewTokenManager() error = %v", err)
+	}
+	return cfg, manager
+}
+
+// newTasks returns a stopped task tracker, which runs background tasks synchronously so tests see their effect
+func newTasks(t *testing.T) *lifecycle.Tasks {
+	t.Helper()
+
+	tasks := lifecycle.NewTasks(time.Second)
+	if err := tasks.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return tasks
+}
+
+// fakeMailer records the mails it is asked to send
+type fakeMailer struct {
+	mu    sync.Mutex
+	mails []services.Mail
+}
+
+var _ services.Mailer = (*fakeMailer)(nil)
+
+func (m *fakeMailer) Send(_ context.Context, mail *services.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mails = append(m.mails, *mail)
+	return nil
+}
+
+// fakeUserRepository keeps users in memory, lookups return copies like a database would
+type fakeUserRepository struct {
+	mu    sync.Mutex
+	users map[string]entities.User
+	// members are the IDs of the members of each organization
+	members map[string][]string
+}
+
+var _ repositories.UserRepository = (*fakeUserRepository)(nil)
+
+func newFakeUserRepository(users ...*entities.User) *fakeUserRepository {
+	repo := &fakeUserRepository{users: make(map[string]entities.User), members: make(map[string][]string)}
+	for _, user := range users {
+		repo.put(user)
+	}
+	return repo
+}
+
+func (r *fakeUserRepository) put(user *entities.User) {
+	if user.ID == "" {
+		user.ID = uuid.NewString()
+	}
+	if user.Role == "" {
+		user.Role = entities.RoleUser
+	}
+	r.users[user.ID] = *user
+}
+
+func (r *fakeUserRepository) Create(_ context.Context, user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.put(user)
+	return nil
+}
+
+func (r *fakeUserRepository) GetByEmail(_ context.Context, email string) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepository) GetMemberByEmail(ctx context.Context, organizationID string, email string) (*entities.User, error) {
+	user, err := r.GetByEmail(ctx, email)
+	if err != nil || user == nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !slices.Contains(r.members[organizationID], user.ID) {
+		return nil, nil
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetByID(_ context.Context, id string) (*entities.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, nil
+	}
+	return &user, nil
+}
+
+func (r *fakeUserRepository) Update(_ context.Context, user *entities.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.users[user.ID] = *user
+	return nil
+}
+
+func (r *fakeUserRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.users, id)
+	return nil
+}
+
+func (r *fakeUserRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	user, err := r.GetByEmail(ctx, email)
+	return user != nil, err
+}
+
+func (r *fakeUserRepository) List(_ context.Context, _ repositories.UserFilter) ([]entities.User, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]entities.User, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	return users, int64(len(users)), nil
+}
+
+// fakeIdentityRepository keeps external identities in memory, creating users in the given repository
+type fakeIdentityRepository struct {
+	mu         sync.Mutex
+	users      *fakeUserRepository
+	identities map[string]entities.UserIdentity
+}
+
+var _ repositories.UserIdentityRepository = (*fakeIdentityRepository)(nil)
+
+func newFakeIdentityRepository(users *fakeUserRepository) *fakeIdentityRepository {
+	return &fakeIdentityRepository{users: users, identities: make(map[string]entities.UserIdentity)}
+}
+
+func (r *fakeIdentityRepository) Create(_ context.Context, identity *entities.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if identity.ID == "" {
+		identity.ID = uuid.NewString()
+	}
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+func (r *fakeIdentityRepository) CreateWithUser(ctx context.Context, user *entities.User, identity *entities.UserIdentity) error {
+	if err := r.users.Create(ctx, user); err != nil {
+		return err
+	}
+	identity.UserID = user.ID
+	return r.Create(ctx, identity)
+}
+
+func (r *fakeIdentityRepository) GetByProviderSubject(_ context.Context, provider string, subject string) (*entities.UserIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeIdentityRepository) Delete(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.identities, id)
+	return nil
+}
+
+// fakeRecoveryCodeRepository keeps the hashes of recovery codes in memory
+type fakeRecoveryCodeRepository struct {
+	mu     sync.Mutex
+	hashes map[string][]string
+}
+
+var _ repositories.RecoveryCodeRepository = (*fakeRecoveryCodeRepository)(nil)
+
+func (r *fakeRecoveryCodeRepository) Replace(_ context.Context, userID string, codeHashes []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hashes == nil {
+		r.hashes = make(map[string][]string)
+	}
+	r.hashes[userID] = slices.Clone(codeHashes)
+	return nil
+}
+
+func (r *fakeRecoveryCodeRepository) Consume(_ context.Context, userID string, codeHash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.Index(r.hashes[userID], codeHash)
+	if i < 0 {
+		return false, nil
+	}
+	r.hashes[userID] = slices.Delete(r.hashes[userID], i, i+1)
+	return true, nil
+}
+
+func (r *fakeRecoveryCodeRepository) DeleteByUserID(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.hashes, userID)
+	return nil
+}
+
+// fakeTodoRepository keeps todos in memory, scoped to organizations like the database
+type fakeTodoRepository struct {
+	mu    sync.Mutex
+	todos map[string]entities.Todo
+	// reads counts the lookups that reached the repository
+	reads int
+}
+
+var _ repositories.TodoRepository = (*fakeTodoRepository)(nil)
+
+func newFakeTodoRepository(todos ...*entities.Todo) *fakeTodoRepository {
+	repo := &fakeTodoRepository{todos: make(map[string]entities.Todo)}
+	for _, todo := range todos {
+		_ = repo.Create(context.Background(), todo)
+	}
+	return repo
+}
+
+func inOrganization(todo entities.Todo, organizationID string) bool {
+	return organizationIDOf(&todo) == organizationID
+}
+
+func (r *fakeTodoRepository) Create(_ context.Context, todo *entities.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if todo.ID == "" {
+		todo.ID = uuid.NewString()
+	}
+	r.todos[todo.ID] = *todo
+	return nil
+}
+
+func (r *fakeTodoRepository) GetAll(_ context.Context, userID string, organizationID string) ([]entities.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	var todos []entities.Todo
+	for _, todo := range r.todos {
+		if todo.UserID == userID && inOrganization(todo, organizationID) {
+			todos = append(todos, todo)
+		}
+	}
+	return todos, nil
+}
+
+func (r *fakeTodoRepository) GetByID(_ context.Context, id string, organizationID string) (*entities.Todo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	todo, ok := r.todos[id]
+	if !ok || !inOrganization(todo, organizationID) {
+		return nil, nil
+	}
+	return &todo, nil
+}
+
+func (r *fakeTodoRepository) Update(_ context.Context, id string, organizationID string, todo *entities.Todo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.todos[id]
+	if !ok || !inOrganization(existing, organizationID) {
+		return gorm.ErrRecordNotFound
+	}
+	r.todos[id] = *todo
+	return nil
+}
+
+func (r *fakeTodoRepository) Delete(_ context.Context, id string, organizationID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.todos[id]
+	if !ok || !inOrganization(existing, organizationID) {
+		return gorm.ErrRecordNotFound
+	}
+	delete(r.todos, id)
+	return nil
+}
+
+func (r *fakeTodoRepository) DeleteByUserID(_ context.Context, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, todo := range r.todos {
+		if todo.UserID == userID {
+			delete(r.todos, id)
+		}
+	}
+	return nil
+}
+
+func (r *fakeTodoRepository) CountByUserID(_ context.Context, userID string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, todo := range r.todos {
+		if todo.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// fakeDataKeyRepository keeps the wrapped data keys of field encryption in memory
+type fakeDataKeyRepository struct {
+	mu   sync.Mutex
+	keys map[string]entities.DataKey
+}
+
+var _ repositories.DataKeyRepository = (*fakeDataKeyRepository)(nil)
+
+func (r *fakeDataKeyRepository) GetByUserID(_ context.Context, userID string) (*entities.DataKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &key, nil
+}
+
+func (r *fakeDataKeyRepository) Create(_ context.Context, key *entities.DataKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.keys == nil {
+		r.keys = make(map[string]entities.DataKey)
+	}
+	if _, ok := r.keys[key.UserID]; !ok {
+		key.ID = uuid.NewString()
+		r.keys[key.UserID] = *key
+	}
+	return nil
+}
+
+func (r *fakeDataKeyRepository) ListNotWrappedWith(_ context.Context, masterKeyID string, limit int) ([]entities.DataKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []entities.DataKey
+	for _, key := range r.keys {
+		if key.MasterKeyID != masterKeyID && len(keys) < limit {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeDataKeyRepository) UpdateWrappedKey(_ context.Context, id string, masterKeyID string, wrappedKey []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for userID, key := range r.keys {
+		if key.ID == id {
+			key.MasterKeyID, key.WrappedKey = masterKeyID, wrappedKey
+			r.keys[userID] = key
+		}
+	}
+	return nil
+}
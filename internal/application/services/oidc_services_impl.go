@@ -0,0 +1,251 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/oidc"
+	"tasius.my.id/todolistapi/internal/utils/password"
+)
+
+const (
+	oidcStateKey = "oidc_state:%s" // state hash -> pending authorization request
+)
+
+var (
+	errUnknownProvider     = apperrors.NotFound("unknown_identity_provider", "unknown identity provider")
+	errInvalidOIDCState    = apperrors.Unauthenticated("invalid_oidc_state", "invalid or expired login request")
+	errOIDCLoginFailed     = apperrors.Unauthenticated("oidc_login_failed", "login with identity provider failed")
+	errOIDCEmailRequired   = apperrors.Unauthenticated("oidc_email_required", "identity provider did not share an email address")
+	errProviderUnavailable = apperrors.Upstream("identity_provider_unavailable", "identity provider is unavailable")
+	errOIDCEmailUnverified = apperrors.Conflict("oidc_email_unverified", "an account with this email already exists, log in with your password to link it")
+)
+
+type pendingOIDCLogin struct {
+	Provider     string `json:"provider"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+}
+
+type oidcService struct {
+	userRepo     repositories.UserRepository
+	identityRepo repositories.UserIdentityRepository
+	redisClient  *redis.Client
+	jwtManager   *jwt.TokenManager
+	jwtConfig    *config.JWTConfig
+	oidcConfig   *config.OIDCConfig
+	providers    map[string]*oidc.Provider
+}
+
+func NewOIDCService(userRepo repositories.UserRepository, identityRepo repositories.UserIdentityRepository, redisClient *redis.Client, jwtManager *jwt.TokenManager, jwtConfig *config.JWTConfig, oidcConfig *config.OIDCConfig, providers map[string]*oidc.Provider) services.OIDCService {
+	return &oidcService{
+		userRepo:     userRepo,
+		identityRepo: identityRepo,
+		redisClient:  redisClient,
+		jwtManager:   jwtManager,
+		jwtConfig:    jwtConfig,
+		oidcConfig:   oidcConfig,
+		providers:    providers,
+	}
+}
+
+// Login implements services.OIDCService.
+func (s *oidcService) Login(ctx context.Context, providerName string) (*dto.OIDCLoginResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, errUnknownProvider
+	}
+
+	state, err := oidc.GenerateState()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := oidc.GenerateState()
+	if err != nil {
+		return nil, err
+	}
+	codeVerifier, err := oidc.GenerateCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, errProviderUnavailable.Wrap(err)
+	}
+
+	payload, err := json.Marshal(pendingOIDCLogin{Provider: providerName, Nonce: nonce, CodeVerifier: codeVerifier})
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf(oidcStateKey, password.HashToken(state))
+	if err := s.redisClient.Set(ctx, key, payload, s.oidcConfig.StateExpiration).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store login request: %w", err)
+	}
+
+	return &dto.OIDCLoginResponse{AuthorizationURL: authURL}, nil
+}
+
+// Callback implements services.OIDCService.
+func (s *oidcService) Callback(ctx context.Context, providerName string, req *dto.OIDCCallbackRequest) (*dto.AuthResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, errUnknownProvider
+	}
+
+	// The state is single-use, whatever happens next the user has to start over
+	pending, err := s.consumeState(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if pending.Provider != providerName {
+		return nil, errInvalidOIDCState
+	}
+
+	claims, err := provider.Exchange(ctx, req.Code, pending.CodeVerifier, pending.Nonce)
+	if err != nil {
+		return nil, errOIDCLoginFailed
+	}
+
+	user, err := s.resolveUser(ctx, providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := user.AllowedScopes()
+
+	// The identity provider replaces the password, not our own second factor
+	if user.TwoFactorEnabled {
+		mfaToken, err := s.jwtManager.GenerateMFAToken(user, scopes, "")
+		if err != nil {
+			return nil, err
+		}
+		return &dto.AuthResponse{
+			MFARequired: true,
+			MFAToken:    mfaToken,
+		}, nil
+	}
+
+	return buildAuthResponse(s.jwtManager, s.jwtConfig, user, scopes, "")
+}
+
+func (s *oidcService) consumeState(ctx context.Context, state string) (*pendingOIDCLogin, error) {
+	payload, err := s.redisClient.GetDel(ctx, fmt.Sprintf(oidcStateKey, password.HashToken(state))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var pending pendingOIDCLogin
+	if err := json.Unmarshal([]byte(payload), &pending); err != nil {
+		return nil, errInvalidOIDCState
+	}
+
+	return &pending, nil
+}
+
+// resolveUser finds the user linked to the external identity. Unknown identities are linked to
+// the account with the same verified email, or get a new account if there is none.
+func (s *oidcService) resolveUser(ctx context.Context, providerName string, claims *oidc.Claims) (*entities.User, error) {
+	identity, err := s.identityRepo.GetByProviderSubject(ctx, providerName, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	if identity != nil {
+		user, err := s.userRepo.GetByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user != nil {
+			if !user.IsActive {
+				return nil, errOIDCLoginFailed
+			}
+			return user, nil
+		}
+
+		// The linked account was deleted, the identity may sign up again
+		if err := s.identityRepo.Delete(ctx, identity.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		return nil, errOIDCEmailRequired
+	}
+
+	identity = &entities.UserIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		Email:    email,
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	if user != nil {
+		// Linking on an unverified email would let anyone take over the account
+		if !bool(claims.EmailVerified) {
+			return nil, errOIDCEmailUnverified
+		}
+		if !user.IsActive {
+			return nil, errOIDCLoginFailed
+		}
+
+		identity.UserID = user.ID
+		if err := s.identityRepo.Create(ctx, identity); err != nil {
+			return nil, fmt.Errorf("failed to link identity: %w", err)
+		}
+		return user, nil
+	}
+
+	return s.createUser(ctx, claims, email, identity)
+}
+
+// createUser signs up a user coming from an identity provider. The account gets a random
+// password nobody knows, it can be replaced through the password reset flow.
+func (s *oidcService) createUser(ctx context.Context, claims *oidc.Claims, email string, identity *entities.UserIdentity) (*entities.User, error) {
+	randomPassword, err := password.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+	hashPassword, err := password.HashPassword(randomPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	name := strings.TrimSpace(claims.Name)
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+
+	user := &entities.User{
+		Email:    email,
+		Password: hashPassword,
+		Name:     name,
+		IsActive: true,
+	}
+
+	if err := s.identityRepo.CreateWithUser(ctx, user, identity); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return user, nil
+}
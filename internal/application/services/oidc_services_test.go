@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/utils/oidc"
+	"tasius.my.id/todolistapi/internal/utils/oidc/oidctest"
+)
+
+type oidcFixture struct {
+	service    *oidcService
+	issuer     *oidctest.Issuer
+	users      *fakeUserRepository
+	identities *fakeIdentityRepository
+}
+
+func newOIDCFixture(t *testing.T, users ...*entities.User) *oidcFixture {
+	t.Helper()
+
+	redisClient, _ := newRedis(t)
+	jwtConfig, jwtManager := newJWT(t, redisClient)
+	issuer := oidctest.NewIssuer(t)
+	userRepo := newFakeUserRepository(users...)
+	identityRepo := newFakeIdentityRepository(userRepo)
+
+	oidcConfig := &config.OIDCConfig{
+		Providers:       map[string]config.OIDCProviderConfig{"test": *issuer.Config("test")},
+		StateExpiration: 10 * time.Minute,
+	}
+	service := NewOIDCService(userRepo, identityRepo, redisClient, jwtManager, jwtConfig, oidcConfig, oidc.NewProviders(oidcConfig, nil))
+
+	return &oidcFixture{
+		service:    service.(*oidcService),
+		issuer:     issuer,
+		users:      userRepo,
+		identities: identityRepo,
+	}
+}
+
+// login runs the flow up to the redirect back from the issuer and returns the callback request
+func (f *oidcFixture) login(t *testing.T, identity oidctest.Identity) *dto.OIDCCallbackRequest {
+	t.Helper()
+
+	response, err := f.service.Login(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	code, state := f.issuer.Authorize(t, response.AuthorizationURL, identity)
+	return &dto.OIDCCallbackRequest{Code: code, State: state}
+}
+
+var bob = oidctest.Identity{Subject: "bob-sub", Email: "Bob@Example.com", EmailVerified: true, Name: "Bob"}
+
+func TestOIDCCallbackSignsUpNewUser(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	response, err := f.service.Callback(context.Background(), "test", f.login(t, bob))
+	if err != nil {
+		t.Fatalf("Callback() error = %v", err)
+	}
+	if response.AccessToken == "" || response.RefreshToken == "" {
+		t.Errorf("Callback() returned no tokens: %+v", response)
+	}
+	if response.User.Email != "bob@example.com" || response.User.Name != "Bob" {
+		t.Errorf("Callback() user = %+v, want bob@example.com named Bob", response.User)
+	}
+
+	identity, _ := f.identities.GetByProviderSubject(context.Background(), "test", bob.Subject)
+	if identity == nil || identity.UserID != response.User.ID {
+		t.Errorf("identity = %+v, want it linked to user %s", identity, response.User.ID)
+	}
+
+	// Logging in again finds the linked user instead of signing up twice
+	again, err := f.service.Callback(context.Background(), "test", f.login(t, bob))
+	if err != nil {
+		t.Fatalf("second Callback() error = %v", err)
+	}
+	if again.User.ID != response.User.ID {
+		t.Errorf("second login user = %s, want %s", again.User.ID, response.User.ID)
+	}
+	if _, total, _ := f.users.List(context.Background(), repositories.UserFilter{}); total != 1 {
+		t.Errorf("users = %d, want 1", total)
+	}
+}
+
+func TestOIDCCallbackStateIsSingleUse(t *testing.T) {
+	f := newOIDCFixture(t)
+	req := f.login(t, bob)
+
+	if _, err := f.service.Callback(context.Background(), "test", req); err != nil {
+		t.Fatalf("Callback() error = %v", err)
+	}
+	if _, err := f.service.Callback(context.Background(), "test", req); !errors.Is(err, errInvalidOIDCState) {
+		t.Errorf("replayed Callback() error = %v, want %v", err, errInvalidOIDCState)
+	}
+}
+
+func TestOIDCCallbackRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(t *testing.T, f *oidcFixture, req *dto.OIDCCallbackRequest)
+		wantErr error
+	}{
+		{
+			name: "unknown state",
+			tamper: func(t *testing.T, f *oidcFixture, req *dto.OIDCCallbackRequest) {
+				req.State = "forged-state"
+			},
+			wantErr: errInvalidOIDCState,
+		},
+		{
+			name: "nonce mismatch",
+			tamper: func(t *testing.T, f *oidcFixture, req *dto.OIDCCallbackRequest) {
+				f.issuer.Claims = func(claims jwt.MapClaims) { claims["nonce"] = "replayed-nonce" }
+			},
+			wantErr: errOIDCLoginFailed,
+		},
+		{
+			name: "bad ID token signature",
+			tamper: func(t *testing.T, f *oidcFixture, req *dto.OIDCCallbackRequest) {
+				f.issuer.SigningKey = oidctest.GenerateKey(t)
+			},
+			wantErr: errOIDCLoginFailed,
+		},
+		{
+			name: "code of another login",
+			tamper: func(t *testing.T, f *oidcFixture, req *dto.OIDCCallbackRequest) {
+				// The code was issued for the PKCE challenge of another login
+				req.Code = f.login(t, bob).Code
+			},
+			wantErr: errOIDCLoginFailed,
+		},
+		{
+			name: "no email",
+			tamper: func(t *testing.T, f *oidcFixture, req *dto.OIDCCallbackRequest) {
+				f.issuer.Claims = func(claims jwt.MapClaims) { claims["email"] = "" }
+			},
+			wantErr: errOIDCEmailRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t)
+			req := f.login(t, bob)
+			tt.tamper(t, f, req)
+
+			if _, err := f.service.Callback(context.Background(), "test", req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Callback() error = %v, want %v", err, tt.wantErr)
+			}
+			if identity, _ := f.identities.GetByProviderSubject(context.Background(), "test", bob.Subject); identity != nil {
+				t.Errorf("rejected login linked identity %+v", identity)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackUnknownProvider(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	if _, err := f.service.Login(context.Background(), "other"); !errors.Is(err, errUnknownProvider) {
+		t.Errorf("Login() error = %v, want %v", err, errUnknownProvider)
+	}
+	if _, err := f.service.Callback(context.Background(), "other", f.login(t, bob)); !errors.Is(err, errUnknownProvider) {
+		t.Errorf("Callback() error = %v, want %v", err, errUnknownProvider)
+	}
+}
+
+func TestOIDCCallbackLinksExistingAccount(t *testing.T) {
+	tests := []struct {
+		name          string
+		emailVerified bool
+		isActive      bool
+		twoFactor     bool
+		wantErr       error
+		wantMFA       bool
+	}{
+		{name: "verified email", emailVerified: true, isActive: true},
+		{name: "unverified email", emailVerified: false, isActive: true, wantErr: errOIDCEmailUnverified},
+		{name: "inactive account", emailVerified: true, isActive: false, wantErr: errOIDCLoginFailed},
+		{name: "two-factor account", emailVerified: true, isActive: true, twoFactor: true, wantMFA: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := &entities.User{Email: "bob@example.com", Name: "Robert", IsActive: tt.isActive, TwoFactorEnabled: tt.twoFactor}
+			f := newOIDCFixture(t, existing)
+
+			identity := bob
+			identity.EmailVerified = tt.emailVerified
+			response, err := f.service.Callback(context.Background(), "test", f.login(t, identity))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Callback() error = %v, want %v", err, tt.wantErr)
+			}
+
+			linked, _ := f.identities.GetByProviderSubject(context.Background(), "test", bob.Subject)
+			if tt.wantErr != nil {
+				if linked != nil {
+					t.Errorf("rejected login linked identity %+v", linked)
+				}
+				return
+			}
+
+			if linked == nil || linked.UserID != existing.ID {
+				t.Errorf("identity = %+v, want it linked to user %s", linked, existing.ID)
+			}
+			if tt.wantMFA {
+				if !response.MFARequired || response.MFAToken == "" || response.AccessToken != "" {
+					t.Errorf("Callback() = %+v, want an MFA challenge without tokens", response)
+				}
+				return
+			}
+			if response.User.ID != existing.ID || response.AccessToken == "" {
+				t.Errorf("Callback() = %+v, want tokens of user %s", response, existing.ID)
+			}
+		})
+	}
+}
+
+func TestOIDCCallbackRelinksDeletedAccount(t *testing.T) {
+	f := newOIDCFixture(t)
+
+	first, err := f.service.Callback(context.Background(), "test", f.login(t, bob))
+	if err != nil {
+		t.Fatalf("Callback() error = %v", err)
+	}
+	if err := f.users.Delete(context.Background(), first.User.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := f.service.Callback(context.Background(), "test", f.login(t, bob))
+	if err != nil {
+		t.Fatalf("Callback() after deleting the account error = %v", err)
+	}
+	if second.User.ID == first.User.ID {
+		t.Error("Callback() returned the deleted user")
+	}
+}
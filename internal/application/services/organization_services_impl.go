@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/password"
+)
+
+var (
+	errOrganizationNotFound = apperrors.NotFound("organization_not_found", "organization not found")
+	errNotOrgManager        = apperrors.Forbidden("not_organization_manager", "only organization owners and admins can do this")
+	errLastOwner            = apperrors.Conflict("last_owner", "the last owner can't leave the organization")
+	errInvalidInvite        = apperrors.Validation("invalid_invite", "invalid or expired invite")
+	errInviteEmailMismatch  = apperrors.Forbidden("invite_email_mismatch", "this invite was sent to a different email address")
+	errAlreadyMember        = apperrors.Conflict("already_member", "already a member of the organization")
+	errMemberNotFound       = apperrors.NotFound("member_not_found", "member not found")
+	errRemoveOwner          = apperrors.Forbidden("remove_owner", "only owners can remove other owners")
+	errRemoveSelf           = apperrors.Validation("remove_self", "use leave to remove yourself from the organization")
+)
+
+type organizationService struct {
+	orgRepo    repositories.OrganizationRepository
+	inviteRepo repositories.OrganizationInviteRepository
+	userRepo   repositories.UserRepository
+	jwtManager *jwt.TokenManager
+	jwtConfig  *config.JWTConfig
+	mailer     services.Mailer
+	orgConfig  *config.OrganizationConfig
+}
+
+func NewOrganizationService(orgRepo repositories.OrganizationRepository, inviteRepo repositories.OrganizationInviteRepository, userRepo repositories.UserRepository, jwtManager *jwt.TokenManager, jwtConfig *config.JWTConfig, mailer services.Mailer, orgConfig *config.OrganizationConfig) services.OrganizationService {
+	return &organizationService{
+		orgRepo:    orgRepo,
+		inviteRepo: inviteRepo,
+		userRepo:   userRepo,
+		jwtManager: jwtManager,
+		jwtConfig:  jwtConfig,
+		mailer:     mailer,
+		orgConfig:  orgConfig,
+	}
+}
+
+// CreateOrganization implements services.OrganizationService.
+func (s *organizationService) CreateOrganization(ctx context.Context, principal *entities.Principal, req *dto.CreateOrganizationRequest) (*dto.OrganizationResponse, error) {
+	organization := &entities.Organization{Name: strings.TrimSpace(req.Name)}
+	if err := s.orgRepo.CreateWithOwner(ctx, organization, principal.UserID); err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	return dto.NewOrganizationResponse(organization, entities.OrgRoleOwner), nil
+}
+
+// GetMyOrganizations implements services.OrganizationService.
+func (s *organizationService) GetMyOrganizations(ctx context.Context, principal *entities.Principal) ([]dto.OrganizationResponse, error) {
+	memberships, err := s.orgRepo.GetMembershipsByUserID(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		result = append(result, *dto.NewOrganizationResponse(&membership.Organization, membership.Role))
+	}
+
+	return result, nil
+}
+
+// GetOrganization implements services.OrganizationService.
+func (s *organizationService) GetOrganization(ctx context.Context, principal *entities.Principal, id string) (*dto.OrganizationResponse, error) {
+	member, err := s.currentMember(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewOrganizationResponse(&member.Organization, member.Role), nil
+}
+
+// GetMembers implements services.OrganizationService.
+func (s *organizationService) GetMembers(ctx context.Context, principal *entities.Principal, id string) ([]dto.OrganizationMemberResponse, error) {
+	if _, err := s.currentMember(ctx, principal, id); err != nil {
+		return nil, err
+	}
+
+	members, err := s.orgRepo.GetMembers(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]dto.OrganizationMemberResponse, 0, len(members))
+	for _, member := range members {
+		result = append(result, *dto.NewOrganizationMemberResponse(&member))
+	}
+
+	return result, nil
+}
+
+// InviteMember implements services.OrganizationService.
+func (s *organizationService) InviteMember(ctx context.Context, principal *entities.Principal, id string, req *dto.InviteMemberRequest) (*dto.InviteResponse, error) {
+	member, err := s.currentMember(ctx, principal, id)
+	if err != nil {
+		return nil, err
+	}
+	if !member.CanManage() {
+		return nil, errNotOrgManager
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	existing, err := s.userRepo.GetMemberByEmail(ctx, id, email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errAlreadyMember
+	}
+
+	token, err := password.GenerateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invite := &entities.OrganizationInvite{
+		OrganizationID: id,
+		Email:          email,
+		Role:           req.Role,
+		TokenHash:      password.HashToken(token),
+		InvitedByID:    member.UserID,
+		ExpiresAt:      time.Now().Add(s.orgConfig.InviteExpiration),
+	}
+	if err := s.inviteRepo.Create(ctx, invite); err != nil {
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	err = s.mailer.Send(ctx, &services.Mail{
+		To:      email,
+		Subject: fmt.Sprintf("You have been invited to join %s", member.Organization.Name),
+		Body: fmt.Sprintf(
+			"Hi,\n\nYou have been invited to join %s as %s. Accept the invite with the link below, it expires in %s.\n\n%s?token=%s\n\nIf you don't have an account yet, register with this email address first.\n",
+			member.Organization.Name, req.Role, s.orgConfig.InviteExpiration, s.orgConfig.InviteURL, token,
+		),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.InviteResponse{
+		ID:        invite.ID,
+		Email:     invite.Email,
+		Role:      invite.Role,
+		ExpiresAt: invite.ExpiresAt,
+	}, nil
+}
+
+// AcceptInvite implements services.OrganizationService.
+func (s *organizationService) AcceptInvite(ctx context.Context, principal *entities.Principal, req *dto.AcceptInviteRequest) (*dto.OrganizationResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	invite, err := s.inviteRepo.GetByTokenHash(ctx, password.HashToken(req.Token))
+	if err != nil {
+		return nil, err
+	}
+	if invite == nil || invite.AcceptedAt != nil || invite.IsExpired() {
+		return nil, errInvalidInvite
+	}
+
+	// Invites can't be forwarded to someone else
+	if !strings.EqualFold(invite.Email, user.Email) {
+		return nil, errInviteEmailMismatch
+	}
+
+	existing, err := s.orgRepo.GetMember(ctx, invite.OrganizationID, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, errAlreadyMember
+	}
+
+	if err := s.inviteRepo.Accept(ctx, invite, user.ID); err != nil {
+		return nil, errInvalidInvite
+	}
+
+	return dto.NewOrganizationResponse(&invite.Organization, invite.Role), nil
+}
+
+// LeaveOrganization implements services.OrganizationService.
+func (s *organizationService) LeaveOrganization(ctx context.Context, principal *entities.Principal, id string) error {
+	member, err := s.currentMember(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+
+	if member.Role == entities.OrgRoleOwner {
+		owners, err := s.orgRepo.CountMembersByRole(ctx, id, entities.OrgRoleOwner)
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			return errLastOwner
+		}
+	}
+
+	return s.orgRepo.RemoveMember(ctx, id, member.UserID)
+}
+
+// RemoveMember implements services.OrganizationService.
+func (s *organizationService) RemoveMember(ctx context.Context, principal *entities.Principal, id string, userID string) error {
+	member, err := s.currentMember(ctx, principal, id)
+	if err != nil {
+		return err
+	}
+	if !member.CanManage() {
+		return errNotOrgManager
+	}
+	if member.UserID == userID {
+		return errRemoveSelf
+	}
+	if _, err := uuid.Parse(userID); err != nil {
+		return errMemberNotFound
+	}
+
+	target, err := s.orgRepo.GetMember(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return errMemberNotFound
+	}
+	if target.Role == entities.OrgRoleOwner && member.Role != entities.OrgRoleOwner {
+		return errRemoveOwner
+	}
+
+	// Tokens acting in the organization stop working on the next request
+	if err := s.orgRepo.RemoveMember(ctx, id, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errMemberNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// SwitchOrganization implements services.OrganizationService.
+func (s *organizationService) SwitchOrganization(ctx context.Context, principal *entities.Principal, req *dto.SwitchOrganizationRequest) (*dto.AuthResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.OrganizationID != "" {
+		if _, err := s.currentMember(ctx, principal, req.OrganizationID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The new tokens keep the scopes of the current session
+	return buildAuthResponse(s.jwtManager, s.jwtConfig, user, principal.Scopes, req.OrganizationID)
+}
+
+// currentMember loads the caller's membership, organizations the caller doesn't belong to are reported as not found
+func (s *organizationService) currentMember(ctx context.Context, principal *entities.Principal, organizationID string) (*entities.OrganizationMember, error) {
+	if _, err := uuid.Parse(organizationID); err != nil {
+		return nil, errOrganizationNotFound
+	}
+
+	member, err := s.orgRepo.GetMember(ctx, organizationID, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if member == nil || member.Organization.ID == "" {
+		return nil, errOrganizationNotFound
+	}
+
+	return member, nil
+}
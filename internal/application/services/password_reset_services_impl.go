@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/password"
+)
+
+const (
+	// Redis keys for password reset tokens, the token itself is never stored
+	passwordResetTokenKey    = "password_reset:%s"          // token hash -> user ID
+	passwordResetUserKey     = "password_reset_user:%s"     // user ID -> token hash
+	passwordResetRequestsKey = "password_reset_requests:%s" // "email:<email>" or "ip:<ip>" -> requests in the window
+)
+
+var (
+	errInvalidResetToken    = apperrors.Validation("invalid_reset_token", "invalid or expired reset token")
+	errTooManyResetRequests = apperrors.RateLimited("too_many_reset_requests", "too many password reset requests")
+)
+
+// ForgotPassword implements services.AuthService.
+func (s *authService) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest, clientIP string) error {
+	// Limited whether the email is registered or not, so the limit doesn't reveal it either
+	if err := s.limitResetRequests(ctx, "email:"+strings.ToLower(strings.TrimSpace(req.Email)), s.resetConfig.MaxRequests); err != nil {
+		return err
+	}
+	if err := s.limitResetRequests(ctx, "ip:"+clientIP, s.resetConfig.IPMaxRequests); err != nil {
+		return err
+	}
+
+	return s.SendResetLink(ctx, req.Email)
+}
+
+// SendResetLink implements services.AuthService.
+func (s *authService) SendResetLink(ctx context.Context, email string) error {
+	user, err := s.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	// Don't reveal whether the email is registered
+	if user == nil || !user.IsActive {
+		return nil
+	}
+
+	// Issuing the token and sending the mail happen after the response, so their time doesn't reveal
+	// that the email is registered either
+	s.tasks.Go("send password reset email", func(bgCtx context.Context) {
+		if err := s.sendResetLink(bgCtx, user); err != nil {
+			slog.ErrorContext(bgCtx, "Failed to send password reset email", "user_id", user.ID, "error", err)
+		}
+	})
+	return nil
+}
+
+func (s *authService) sendResetLink(ctx context.Context, user *entities.User) error {
+	token, err := s.issueResetToken(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(ctx, &services.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to reset your password. It expires in %s and can only be used once.\n\n%s?token=%s\n\nIf you did not request a password reset, you can ignore this email.\n",
+			user.Name, s.resetConfig.TokenExpiration, s.resetConfig.URL, token,
+		),
+	})
+}
+
+// limitResetRequests counts a reset request of subject, failing once it made more than max in the window
+func (s *authService) limitResetRequests(ctx context.Context, subject string, max int) error {
+	key := fmt.Sprintf(passwordResetRequestsKey, subject)
+
+	requests, err := s.redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return fmt.Errorf("failed to count password reset requests: %w", err)
+	}
+	if requests == 1 {
+		s.redisClient.Expire(ctx, key, s.resetConfig.RequestWindow)
+	}
+	if requests <= int64(max) {
+		return nil
+	}
+
+	if ttl, err := s.redisClient.PTTL(ctx, key).Result(); err == nil && ttl > 0 {
+		return errTooManyResetRequests.WithRetryAfter(ttl)
+	}
+	return errTooManyResetRequests
+}
+
+// ResetPassword implements services.AuthService.
+func (s *authService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	user, err := s.consumeResetToken(ctx, req.Token)
+	if err != nil {
+		return err
+	}
+
+	hashPassword, err := password.HashPassword(req.Password)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	// Sign the user out everywhere, the old password may have been compromised
+	return s.jwtManager.RevokeAllSessions(user.ID)
+}
+
+// issueResetToken stores the hash of a new reset token and revokes the previous one of the user
+func (s *authService) issueResetToken(ctx context.Context, userID string) (string, error) {
+	token, err := password.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+	tokenHash := password.HashToken(token)
+	userKey := fmt.Sprintf(passwordResetUserKey, userID)
+
+	if previous, err := s.redisClient.Get(ctx, userKey).Result(); err == nil {
+		s.redisClient.Del(ctx, fmt.Sprintf(passwordResetTokenKey, previous))
+	}
+
+	pipe := s.redisClient.TxPipeline()
+	pipe.Set(ctx, fmt.Sprintf(passwordResetTokenKey, tokenHash), userID, s.resetConfig.TokenExpiration)
+	pipe.Set(ctx, userKey, tokenHash, s.resetConfig.TokenExpiration)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	return token, nil
+}
+
+// consumeResetToken atomically deletes a reset token and returns the user it was issued for
+func (s *authService) consumeResetToken(ctx context.Context, token string) (*entities.User, error) {
+	userID, err := s.redisClient.GetDel(ctx, fmt.Sprintf(passwordResetTokenKey, password.HashToken(token))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errInvalidResetToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf(passwordResetUserKey, userID))
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, errInvalidResetToken
+	}
+
+	return user, nil
+}
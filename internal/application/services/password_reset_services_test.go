@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+func TestForgotPassword(t *testing.T) {
+	f := newAuthFixture(t, &entities.User{Email: "alice@example.com", Name: "Alice", IsActive: true})
+
+	if err := f.service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "alice@example.com"}, testClientIP); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	if err := f.service.ForgotPassword(context.Background(), &dto.ForgotPasswordRequest{Email: "nobody@example.com"}, testClientIP); err != nil {
+		t.Fatalf("ForgotPassword() of an unknown email error = %v", err)
+	}
+
+	if len(f.mailer.mails) != 1 {
+		t.Fatalf("sent %d mails, want 1", len(f.mailer.mails))
+	}
+	mail := f.mailer.mails[0]
+	if mail.To != "alice@example.com" || !strings.Contains(mail.Body, "http://localhost:3000/reset-password?token=") {
+		t.Errorf("sent mail = %+v, want a reset link to alice@example.com", mail)
+	}
+}
+
+func TestForgotPasswordIsRateLimited(t *testing.T) {
+	tests := []struct {
+		name string
+		// emails are requested in order from the same IP, the last one is expected to be limited
+		emails []string
+	}{
+		{name: "per email", emails: []string{"alice@example.com", "alice@example.com", "Alice@Example.com"}},
+		{name: "per unknown email", emails: []string{"nobody@example.com", "nobody@example.com", "nobody@example.com"}},
+		{name: "per IP", emails: []string{"a@example.com", "b@example.com", "c@example.com", "d@example.com"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAuthFixture(t, &entities.User{Email: "alice@example.com", IsActive: true})
+			ctx := context.Background()
+
+			last := len(tt.emails) - 1
+			for i, email := range tt.emails {
+				err := f.service.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: email}, testClientIP)
+				if i < last {
+					if err != nil {
+						t.Fatalf("ForgotPassword() #%d error = %v", i+1, err)
+					}
+					continue
+				}
+
+				var appErr *apperrors.Error
+				if !errors.Is(err, errTooManyResetRequests) || !errors.As(err, &appErr) || appErr.RetryAfter <= 0 {
+					t.Fatalf("ForgotPassword() #%d error = %v, want %v with a retry after", i+1, err, errTooManyResetRequests)
+				}
+			}
+
+			// Another client isn't affected by the IP limit
+			if tt.name == "per IP" {
+				if err := f.service.ForgotPassword(ctx, &dto.ForgotPasswordRequest{Email: "e@example.com"}, "192.0.2.2"); err != nil {
+					t.Errorf("ForgotPassword() from another IP error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSendResetLinkIsNotRateLimited(t *testing.T) {
+	f := newAuthFixture(t, &entities.User{Email: "alice@example.com", IsActive: true})
+
+	for range testMaxResetRequests + 1 {
+		if err := f.service.SendResetLink(context.Background(), "alice@example.com"); err != nil {
+			t.Fatalf("SendResetLink() error = %v", err)
+		}
+	}
+	if len(f.mailer.mails) != testMaxResetRequests+1 {
+		t.Errorf("sent %d mails, want %d", len(f.mailer.mails), testMaxResetRequests+1)
+	}
+}
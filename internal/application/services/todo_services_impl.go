@@ -3,106 +3,111 @@ package services
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
-	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
 	"tasius.my.id/todolistapi/internal/domain/entities"
 	"tasius.my.id/todolistapi/internal/domain/repositories"
 	"tasius.my.id/todolistapi/internal/domain/services"
-	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils/lifecycle"
+	"tasius.my.id/todolistapi/internal/utils/metrics"
 )
 
 const (
 	todoCacheKey     = "todo:%s"
-	todoListCacheKey = "todos"
+	todoListCacheKey = "todos:%s" // user ID -> hash of cached lists by organization
+	personalSpace    = "personal"
 	cacheExpiration  = 5 * time.Minute
-	errTodoNotFound   = "Not found todo with id: %s"
-	errUnauthorized   = "Unauthorized"
+)
+
+var (
+	errTodoNotFound  = apperrors.NotFound("todo_not_found", "todo not found")
+	errTodoForbidden = apperrors.Forbidden("todo_forbidden", "todo belongs to another user")
 )
 
 type todoService struct {
 	todoRepo    repositories.TodoRepository
 	redisClient *redis.Client
+	// encryptor encrypts cached todos like the database does, nil when field encryption is off
+	encryptor services.FieldEncryptor
+	tasks     *lifecycle.Tasks
 }
 
 // CreateTodo implements services.TodoService.
-func (t *todoService) CreateTodo(ctx *fiber.Ctx, todo dto.TodoDTO) (*dto.TodoResponse, error) {
+func (t *todoService) CreateTodo(ctx context.Context, principal *entities.Principal, todo dto.TodoDTO) (*dto.TodoResponse, error) {
 	todoEntity := &entities.Todo{
-		Title:       todo.Title,
-		Description: todo.Description,
-		UserID:      todo.UserID,
+		Title:          todo.Title,
+		Description:    todo.Description,
+		UserID:         principal.UserID,
+		OrganizationID: entities.OrganizationRef(principal.OrganizationID),
 	}
 
-	err := t.todoRepo.Create(ctx.Context(), todoEntity)
+	err := t.todoRepo.Create(ctx, todoEntity)
 	if err != nil {
 		return nil, err
 	}
 
 	// Invalidate the todo list cache in background since we added a new todo
-	go func() {
-		t.invalidateListCache(context.Background())
-	}()
+	t.tasks.Go("invalidate todo cache", func(bgCtx context.Context) {
+		t.invalidateListCache(bgCtx, todoEntity.UserID)
+	})
 
 	return t.generateTodoResponse(todoEntity), nil
 }
 
 // DeleteTodo implements services.TodoService.
-func (t *todoService) DeleteTodo(ctx *fiber.Ctx, id string) error {
+func (t *todoService) DeleteTodo(ctx context.Context, principal *entities.Principal, id string) error {
+	organizationID := principal.OrganizationID
 
-	existingTodo, err := t.todoRepo.GetByID(ctx.Context(), id)
+	existingTodo, err := t.todoRepo.GetByID(ctx, id, organizationID)
 	if err != nil {
 		return err
 	}
 
 	if existingTodo == nil {
-		return fmt.Errorf(errTodoNotFound, id)
-	}
-
-	userId, err := middleware.GetUserIDFromContext(ctx)
-	if err != nil {
-		return err
+		return errTodoNotFound.WithMessage(fmt.Sprintf("Not found todo with id: %s", id))
 	}
 
+	userId := principal.UserID
 	if existingTodo.UserID != userId {
-		return errors.New(errUnauthorized)
+		return errTodoForbidden
 	}
 
 	// Invalidate both the specific todo and the list cache in background
-	go func() {
-		bgCtx := context.Background()
+	t.tasks.Go("invalidate todo cache", func(bgCtx context.Context) {
 		t.invalidateCache(bgCtx, id)
-		t.invalidateListCache(bgCtx)
-	}()
+		t.invalidateListCache(bgCtx, userId)
+	})
 
-	return t.todoRepo.Delete(ctx.Context(), id)
+	return t.todoRepo.Delete(ctx, id, organizationID)
 }
 
 // GetAllTodos implements services.TodoService.
-func (t *todoService) GetAllTodos(ctx *fiber.Ctx) ([]dto.TodoResponse, error) {
-
-	userId, err := middleware.GetUserIDFromContext(ctx)
-	if err != nil {
-		return nil, err
+func (t *todoService) GetAllTodos(ctx context.Context, principal *entities.Principal) ([]dto.TodoResponse, error) {
+	userId := principal.UserID
+	organizationID := principal.OrganizationID
+	listKey := fmt.Sprintf(todoListCacheKey, userId)
+	listField := organizationID
+	if listField == "" {
+		listField = personalSpace
 	}
 
 	// Try to get from cache first
-	cached, err := t.redisClient.Get(ctx.Context(), todoListCacheKey).Result()
+	cached, err := t.redisClient.HGet(ctx, listKey, listField).Result()
+	metrics.CacheLookup(metrics.TodoListCache, err)
 	if err == nil {
 		var cachedTodos []dto.TodoResponse
-		if err := json.Unmarshal([]byte(cached), &cachedTodos); err == nil {
-			if cachedTodos[0].UserID != userId {
-				return nil, errors.New(errUnauthorized)
-			}
+		if t.decodeCached(ctx, userId, listKey+"\n"+listField, cached, &cachedTodos) {
 			return cachedTodos, nil
 		}
 	}
 
 	// If not in cache, get from database
-	todos, err := t.todoRepo.GetAll(ctx.Context(), userId)
+	todos, err := t.todoRepo.GetAll(ctx, userId, organizationID)
 	if err != nil {
 		return nil, err
 	}
@@ -113,103 +118,144 @@ func (t *todoService) GetAllTodos(ctx *fiber.Ctx) ([]dto.TodoResponse, error) {
 	}
 
 	// Cache the result
-	if jsonData, err := json.Marshal(result); err == nil {
-		go t.redisClient.Set(ctx.Context(), todoListCacheKey, jsonData, cacheExpiration)
-	}
+	t.tasks.Go("cache todo list", func(bgCtx context.Context) {
+		if cached, ok := t.encodeCached(bgCtx, userId, listKey+"\n"+listField, result); ok {
+			t.redisClient.HSet(bgCtx, listKey, listField, cached)
+			t.redisClient.Expire(bgCtx, listKey, cacheExpiration)
+		}
+	})
 
 	return result, nil
 }
 
 // GetTodoByID implements services.TodoService.
-func (t *todoService) GetTodoByID(ctx *fiber.Ctx, id string) (*dto.TodoResponse, error) {
+func (t *todoService) GetTodoByID(ctx context.Context, principal *entities.Principal, id string) (*dto.TodoResponse, error) {
 	cacheKey := fmt.Sprintf(todoCacheKey, id)
-
-	userId, err := middleware.GetUserIDFromContext(ctx)
-	if err != nil {
-		return nil, err
-	}
+	organizationID := principal.OrganizationID
+	userId := principal.UserID
 
 	// Try to get from cache first
-	cached, err := t.redisClient.Get(ctx.Context(), cacheKey).Result()
+	cached, err := t.redisClient.Get(ctx, cacheKey).Result()
+	metrics.CacheLookup(metrics.TodoCache, err)
 	if err == nil {
 		var cachedTodo dto.TodoResponse
-		if err := json.Unmarshal([]byte(cached), &cachedTodo); err == nil {
+		// Todos of other users are encrypted with their key, they don't decode and are looked up below
+		if t.decodeCached(ctx, userId, cacheKey, cached, &cachedTodo) {
+			// Todos outside the active organization don't exist for the caller, like in the database
+			if cachedTodo.OrganizationID != organizationID {
+				return nil, errTodoNotFound.WithMessage(fmt.Sprintf("Not found todo with id: %s", id))
+			}
 			// Check if the cached todo belongs to the current user
-			if cachedTodo.UserID == userId {
-				return &cachedTodo, nil
+			if cachedTodo.UserID != userId {
+				return nil, errTodoForbidden
 			}
-			return nil, errors.New(errUnauthorized)
+			return &cachedTodo, nil
 		}
 	}
 
 	// If not in cache or invalid cache, get from database
-	todo, err := t.todoRepo.GetByID(ctx.Context(), id)
+	todo, err := t.todoRepo.GetByID(ctx, id, organizationID)
 	if err != nil {
 		return nil, err
 	}
 
 	if todo == nil {
-		return nil, fmt.Errorf(errTodoNotFound, id)
+		return nil, errTodoNotFound.WithMessage(fmt.Sprintf("Not found todo with id: %s", id))
 	}
 
 	// Check if the todo belongs to the current user
 	if todo.UserID != userId {
-		return nil, errors.New(errUnauthorized)
+		return nil, errTodoForbidden
 	}
 
 	response := t.generateTodoResponse(todo)
 
-	// Cache the result
-	if jsonData, err := json.Marshal(response); err == nil {
-		go t.redisClient.Set(ctx.Context(), cacheKey, jsonData, cacheExpiration)
-	}
+	// Cache the result. The request context is recycled once the handler returns, the task gets its own.
+	t.tasks.Go("cache todo", func(bgCtx context.Context) {
+		if cached, ok := t.encodeCached(bgCtx, userId, cacheKey, response); ok {
+			t.redisClient.Set(bgCtx, cacheKey, cached, cacheExpiration)
+		}
+	})
 
 	return response, nil
 }
 
 // UpdateTodo implements services.TodoService.
-func (t *todoService) UpdateTodo(ctx *fiber.Ctx, id string, todo dto.UpdateTodoRequest) (*dto.TodoResponse, error) {
-	existingTodo, err := t.todoRepo.GetByID(ctx.Context(), id)
+func (t *todoService) UpdateTodo(ctx context.Context, principal *entities.Principal, id string, todo dto.UpdateTodoRequest) (*dto.TodoResponse, error) {
+	organizationID := principal.OrganizationID
+
+	existingTodo, err := t.todoRepo.GetByID(ctx, id, organizationID)
 	if err != nil {
 		return nil, err
 	}
 
 	if existingTodo == nil {
-		return nil, fmt.Errorf(errTodoNotFound, id)
-	}
-
-	userId, err := middleware.GetUserIDFromContext(ctx)
-	if err != nil {
-		return nil, err
+		return nil, errTodoNotFound.WithMessage(fmt.Sprintf("Not found todo with id: %s", id))
 	}
 
+	userId := principal.UserID
 	if existingTodo.UserID != userId {
-		return nil, errors.New(errUnauthorized)
+		return nil, errTodoForbidden
 	}
 
 	existingTodo.Title = todo.Title
 	existingTodo.Description = todo.Description
 
-	err = t.todoRepo.Update(ctx.Context(), id, existingTodo)
+	err = t.todoRepo.Update(ctx, id, organizationID, existingTodo)
 	if err != nil {
 		return nil, err
 	}
 
 	// Invalidate both the specific todo and the list cache in background
-	go func() {
-		bgCtx := context.Background()
+	t.tasks.Go("invalidate todo cache", func(bgCtx context.Context) {
 		t.invalidateCache(bgCtx, id)
-		t.invalidateListCache(bgCtx)
-	}()
+		t.invalidateListCache(bgCtx, userId)
+	})
 
 	return t.generateTodoResponse(existingTodo), nil
 }
 
-func NewTodoService(todoRepo repositories.TodoRepository, redisClient *redis.Client) services.TodoService {
+// NewTodoService creates the todo service. Cached todos are encrypted with encryptor, which is nil
+// when field encryption is off.
+func NewTodoService(todoRepo repositories.TodoRepository, redisClient *redis.Client, encryptor services.FieldEncryptor, tasks *lifecycle.Tasks) services.TodoService {
 	return &todoService{
 		todoRepo:    todoRepo,
 		redisClient: redisClient,
+		encryptor:   encryptor,
+		tasks:       tasks,
+	}
+}
+
+// encodeCached encodes a value to cache under key, encrypted with the data key of the user when
+// field encryption is on, so todos aren't kept in plaintext in Redis
+func (t *todoService) encodeCached(ctx context.Context, userID, key string, value any) (string, bool) {
+	jsonData, err := json.Marshal(value)
+	if err != nil {
+		return "", false
+	}
+	if t.encryptor == nil {
+		return string(jsonData), true
+	}
+
+	encrypted, err := t.encryptor.Encrypt(ctx, userID, key, string(jsonData))
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to encrypt cached todos", "user_id", userID, "error", err)
+		return "", false
+	}
+	return encrypted, true
+}
+
+// decodeCached decodes a value cached under key into value, reporting whether it is usable
+func (t *todoService) decodeCached(ctx context.Context, userID, key, cached string, value any) bool {
+	if t.encryptor != nil {
+		// Values written before field encryption was enabled expire like any other
+		decrypted, err := t.encryptor.Decrypt(ctx, userID, key, cached)
+		if err != nil {
+			return false
+		}
+		cached = decrypted
 	}
+	return json.Unmarshal([]byte(cached), value) == nil
 }
 
 func (t *todoService) invalidateCache(ctx context.Context, id string) {
@@ -217,15 +263,24 @@ func (t *todoService) invalidateCache(ctx context.Context, id string) {
 	t.redisClient.Del(ctx, cacheKey)
 }
 
-func (t *todoService) invalidateListCache(ctx context.Context) {
-	t.redisClient.Del(ctx, todoListCacheKey)
+// invalidateListCache drops the cached lists of a user in all organizations
+func (t *todoService) invalidateListCache(ctx context.Context, userID string) {
+	t.redisClient.Del(ctx, fmt.Sprintf(todoListCacheKey, userID))
 }
 
 func (t *todoService) generateTodoResponse(todo *entities.Todo) *dto.TodoResponse {
 	return &dto.TodoResponse{
-		ID:          todo.ID,
-		Title:       todo.Title,
-		Description: todo.Description,
-		UserID:      todo.UserID,
+		ID:             todo.ID,
+		Title:          todo.Title,
+		Description:    todo.Description,
+		UserID:         todo.UserID,
+		OrganizationID: organizationIDOf(todo),
 	}
-}
\ No newline at end of file
+}
+
+func organizationIDOf(todo *entities.Todo) string {
+	if todo.OrganizationID == nil {
+		return ""
+	}
+	return *todo.OrganizationID
+}
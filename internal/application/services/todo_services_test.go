@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/infrastructure/fieldcrypto"
+)
+
+func newFieldEncryptor(t *testing.T) *fieldcrypto.Encryptor {
+	t.Helper()
+
+	encryptor, err := fieldcrypto.NewEncryptor(&config.FieldEncryptionConfig{
+		MasterKeyID: "test",
+		MasterKeys:  map[string]string{"test": base64.StdEncoding.EncodeToString(make([]byte, 32))},
+	}, &fakeDataKeyRepository{})
+	if err != nil {
+		t.Fatalf("NewEncryptor() error = %v", err)
+	}
+	return encryptor
+}
+
+func TestTodoCacheIsEncrypted(t *testing.T) {
+	redisClient, redisServer := newRedis(t)
+	todo := &entities.Todo{Title: "Buy milk", Description: "Two litres, oat", UserID: uuidOf("alice")}
+	todoRepo := newFakeTodoRepository(todo)
+	service := NewTodoService(todoRepo, redisClient, newFieldEncryptor(t), newTasks(t))
+
+	alice := &entities.Principal{UserID: todo.UserID}
+	if _, err := service.GetTodoByID(context.Background(), alice, todo.ID); err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+	if _, err := service.GetAllTodos(context.Background(), alice); err != nil {
+		t.Fatalf("GetAllTodos() error = %v", err)
+	}
+
+	cached := []string{redisServer.HGet(fmt.Sprintf(todoListCacheKey, todo.UserID), personalSpace)}
+	cachedTodo, err := redisServer.Get(fmt.Sprintf(todoCacheKey, todo.ID))
+	if err != nil {
+		t.Fatalf("todo wasn't cached: %v", err)
+	}
+	cached = append(cached, cachedTodo)
+	for _, value := range cached {
+		if !fieldcrypto.IsEncrypted(value) || strings.Contains(value, todo.Title) || strings.Contains(value, todo.Description) {
+			t.Errorf("cached value %q isn't encrypted", value)
+		}
+	}
+
+	// Both are served from the cache now
+	reads := todoRepo.reads
+	got, err := service.GetTodoByID(context.Background(), alice, todo.ID)
+	if err != nil || got.Title != todo.Title {
+		t.Fatalf("cached GetTodoByID() = %+v, %v, want %q", got, err, todo.Title)
+	}
+	list, err := service.GetAllTodos(context.Background(), alice)
+	if err != nil || len(list) != 1 || list[0].Description != todo.Description {
+		t.Fatalf("cached GetAllTodos() = %+v, %v, want the todo", list, err)
+	}
+	if todoRepo.reads != reads {
+		t.Errorf("cached lookups read the repository %d times", todoRepo.reads-reads)
+	}
+}
+
+// uuidOf returns a stable UUID for a name, so tests can refer to users by name
+func uuidOf(name string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name)).String()
+}
+
+func TestTodoAccess(t *testing.T) {
+	organizationID := uuidOf("acme")
+	personal := &entities.Todo{ID: uuidOf("personal"), Title: "Personal", Description: "In alice's space", UserID: uuidOf("alice")}
+	shared := &entities.Todo{ID: uuidOf("shared"), Title: "Shared", Description: "In acme", UserID: uuidOf("alice"), OrganizationID: &organizationID}
+
+	tests := []struct {
+		name      string
+		principal *entities.Principal
+		id        string
+		wantErr   error
+	}{
+		{name: "owner", principal: &entities.Principal{UserID: uuidOf("alice")}, id: personal.ID},
+		{name: "owner in organization", principal: &entities.Principal{UserID: uuidOf("alice"), OrganizationID: organizationID}, id: shared.ID},
+		{name: "other user", principal: &entities.Principal{UserID: uuidOf("bob")}, id: personal.ID, wantErr: errTodoForbidden},
+		{name: "other member of organization", principal: &entities.Principal{UserID: uuidOf("bob"), OrganizationID: organizationID}, id: shared.ID, wantErr: errTodoForbidden},
+		{name: "missing", principal: &entities.Principal{UserID: uuidOf("alice")}, id: uuidOf("missing"), wantErr: errTodoNotFound},
+		{name: "organization todo from personal space", principal: &entities.Principal{UserID: uuidOf("alice")}, id: shared.ID, wantErr: errTodoNotFound},
+		{name: "personal todo from organization", principal: &entities.Principal{UserID: uuidOf("alice"), OrganizationID: organizationID}, id: personal.ID, wantErr: errTodoNotFound},
+		{name: "other organization", principal: &entities.Principal{UserID: uuidOf("alice"), OrganizationID: uuidOf("globex")}, id: shared.ID, wantErr: errTodoNotFound},
+	}
+
+	// Every subtest gets its own todos and cache, so a delete or cached lookup doesn't leak into the next
+	newService := func(t *testing.T) (*todoService, *fakeTodoRepository) {
+		p, s := *personal, *shared
+		todoRepo := newFakeTodoRepository(&p, &s)
+		redisClient, _ := newRedis(t)
+		return NewTodoService(todoRepo, redisClient, nil, newTasks(t)).(*todoService), todoRepo
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			t.Run("get", func(t *testing.T) {
+				service, _ := newService(t)
+				got, err := service.GetTodoByID(ctx, tt.principal, tt.id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetTodoByID() error = %v, want %v", err, tt.wantErr)
+				}
+				if err == nil && got.ID != tt.id {
+					t.Errorf("GetTodoByID() = %+v, want todo %s", got, tt.id)
+				}
+			})
+
+			t.Run("update", func(t *testing.T) {
+				service, todoRepo := newService(t)
+				got, err := service.UpdateTodo(ctx, tt.principal, tt.id, dto.UpdateTodoRequest{Title: "Updated", Description: "Updated description"})
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("UpdateTodo() error = %v, want %v", err, tt.wantErr)
+				}
+				stored, ok := todoRepo.todos[tt.id]
+				if updated := ok && stored.Title == "Updated"; updated != (err == nil) {
+					t.Errorf("UpdateTodo() error = %v, but stored todo = %+v", err, stored)
+				}
+				if err == nil && got.Title != "Updated" {
+					t.Errorf("UpdateTodo() = %+v, want the updated todo", got)
+				}
+			})
+
+			t.Run("delete", func(t *testing.T) {
+				service, todoRepo := newService(t)
+				err := service.DeleteTodo(ctx, tt.principal, tt.id)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DeleteTodo() error = %v, want %v", err, tt.wantErr)
+				}
+				existed := tt.id == personal.ID || tt.id == shared.ID
+				if _, exists := todoRepo.todos[tt.id]; exists != (existed && err != nil) {
+					t.Errorf("DeleteTodo() error = %v, but todo exists = %v", err, exists)
+				}
+			})
+		})
+	}
+}
+
+func TestCachedTodoAccess(t *testing.T) {
+	todo := &entities.Todo{Title: "Buy milk", Description: "Two litres, oat", UserID: uuidOf("alice")}
+	redisClient, _ := newRedis(t)
+	todoRepo := newFakeTodoRepository(todo)
+	service := NewTodoService(todoRepo, redisClient, nil, newTasks(t))
+
+	if _, err := service.GetTodoByID(context.Background(), &entities.Principal{UserID: todo.UserID}, todo.ID); err != nil {
+		t.Fatalf("GetTodoByID() error = %v", err)
+	}
+
+	// The todo is cached now, the answers are the same as from the database
+	tests := []struct {
+		name      string
+		principal *entities.Principal
+		wantErr   error
+	}{
+		{name: "other user", principal: &entities.Principal{UserID: uuidOf("bob")}, wantErr: errTodoForbidden},
+		{name: "owner in an organization", principal: &entities.Principal{UserID: todo.UserID, OrganizationID: uuidOf("acme")}, wantErr: errTodoNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reads := todoRepo.reads
+			if got, err := service.GetTodoByID(context.Background(), tt.principal, todo.ID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("cached GetTodoByID() = %+v, %v, want %v", got, err, tt.wantErr)
+			}
+			if todoRepo.reads != reads {
+				t.Error("GetTodoByID() read the repository, want the cached todo")
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/tracing"
+)
+
+const todoIDAttribute = attribute.Key("todo.id")
+
+// traced runs fn in a span named after the service method. fn gets the context of the span, so
+// repository and cache calls become its children.
+func traced(ctx context.Context, name string, fn func(ctx context.Context) error, attributes ...attribute.KeyValue) error {
+	spanCtx, span := tracing.Start(ctx, name, attributes...)
+	err := fn(spanCtx)
+	tracing.End(span, err)
+	return err
+}
+
+type tracedTodoService struct {
+	next services.TodoService
+}
+
+// NewTracedTodoService wraps every method of a todo service in a span
+func NewTracedTodoService(next services.TodoService) services.TodoService {
+	return &tracedTodoService{next: next}
+}
+
+// CreateTodo implements services.TodoService.
+func (t *tracedTodoService) CreateTodo(ctx context.Context, principal *entities.Principal, todo dto.TodoDTO) (response *dto.TodoResponse, err error) {
+	err = traced(ctx, "TodoService.CreateTodo", func(ctx context.Context) error {
+		response, err = t.next.CreateTodo(ctx, principal, todo)
+		return err
+	})
+	return response, err
+}
+
+// GetAllTodos implements services.TodoService.
+func (t *tracedTodoService) GetAllTodos(ctx context.Context, principal *entities.Principal) (todos []dto.TodoResponse, err error) {
+	err = traced(ctx, "TodoService.GetAllTodos", func(ctx context.Context) error {
+		todos, err = t.next.GetAllTodos(ctx, principal)
+		return err
+	})
+	return todos, err
+}
+
+// GetTodoByID implements services.TodoService.
+func (t *tracedTodoService) GetTodoByID(ctx context.Context, principal *entities.Principal, id string) (response *dto.TodoResponse, err error) {
+	err = traced(ctx, "TodoService.GetTodoByID", func(ctx context.Context) error {
+		response, err = t.next.GetTodoByID(ctx, principal, id)
+		return err
+	}, todoIDAttribute.String(id))
+	return response, err
+}
+
+// UpdateTodo implements services.TodoService.
+func (t *tracedTodoService) UpdateTodo(ctx context.Context, principal *entities.Principal, id string, todo dto.UpdateTodoRequest) (response *dto.TodoResponse, err error) {
+	err = traced(ctx, "TodoService.UpdateTodo", func(ctx context.Context) error {
+		response, err = t.next.UpdateTodo(ctx, principal, id, todo)
+		return err
+	}, todoIDAttribute.String(id))
+	return response, err
+}
+
+// DeleteTodo implements services.TodoService.
+func (t *tracedTodoService) DeleteTodo(ctx context.Context, principal *entities.Principal, id string) error {
+	return traced(ctx, "TodoService.DeleteTodo", func(ctx context.Context) error {
+		return t.next.DeleteTodo(ctx, principal, id)
+	}, todoIDAttribute.String(id))
+}
+
+type tracedAuthService struct {
+	next services.AuthService
+}
+
+// NewTracedAuthService wraps every method of an auth service in a span. Credentials and tokens are
+// never recorded.
+func NewTracedAuthService(next services.AuthService) services.AuthService {
+	return &tracedAuthService{next: next}
+}
+
+// Register implements services.AuthService.
+func (s *tracedAuthService) Register(ctx context.Context, req *dto.RegisterRequest) (response *dto.AuthResponse, err error) {
+	err = traced(ctx, "AuthService.Register", func(ctx context.Context) error {
+		response, err = s.next.Register(ctx, req)
+		return err
+	})
+	return response, err
+}
+
+// Login implements services.AuthService.
+func (s *tracedAuthService) Login(ctx context.Context, req *dto.LoginRequest, clientIP string) (response *dto.AuthResponse, err error) {
+	err = traced(ctx, "AuthService.Login", func(ctx context.Context) error {
+		response, err = s.next.Login(ctx, req, clientIP)
+		return err
+	})
+	return response, err
+}
+
+// Logout implements services.AuthService.
+func (s *tracedAuthService) Logout(ctx context.Context, principal *entities.Principal) error {
+	return traced(ctx, "AuthService.Logout", func(ctx context.Context) error {
+		return s.next.Logout(ctx, principal)
+	})
+}
+
+// RefreshToken implements services.AuthService.
+func (s *tracedAuthService) RefreshToken(ctx context.Context, refreshToken string) (response *dto.AuthResponse, err error) {
+	err = traced(ctx, "AuthService.RefreshToken", func(ctx context.Context) error {
+		response, err = s.next.RefreshToken(ctx, refreshToken)
+		return err
+	})
+	return response, err
+}
+
+// ValidateToken implements services.AuthService.
+func (s *tracedAuthService) ValidateToken(ctx context.Context, token string) (user *dto.UserResponse, err error) {
+	err = traced(ctx, "AuthService.ValidateToken", func(ctx context.Context) error {
+		user, err = s.next.ValidateToken(ctx, token)
+		return err
+	})
+	return user, err
+}
+
+// ForgotPassword implements services.AuthService.
+func (s *tracedAuthService) ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest, clientIP string) error {
+	return traced(ctx, "AuthService.ForgotPassword", func(ctx context.Context) error {
+		return s.next.ForgotPassword(ctx, req, clientIP)
+	})
+}
+
+// SendResetLink implements services.AuthService.
+func (s *tracedAuthService) SendResetLink(ctx context.Context, email string) error {
+	return traced(ctx, "AuthService.SendResetLink", func(ctx context.Context) error {
+		return s.next.SendResetLink(ctx, email)
+	})
+}
+
+// ResetPassword implements services.AuthService.
+func (s *tracedAuthService) ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error {
+	return traced(ctx, "AuthService.ResetPassword", func(ctx context.Context) error {
+		return s.next.ResetPassword(ctx, req)
+	})
+}
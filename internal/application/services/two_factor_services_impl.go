@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
+	"tasius.my.id/todolistapi/internal/utils/password"
+	"tasius.my.id/todolistapi/internal/utils/totp"
+)
+
+const (
+	totpSetupKey       = "totp_setup:%s"   // user ID -> pending secret
+	totpUsedKey        = "totp_used:%s:%d" // user ID, counter -> code already used
+	totpSetupTTL       = 10 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var (
+	errInvalidMFACode        = apperrors.Validation("invalid_mfa_code", "invalid two-factor code")
+	errTwoFactorEnabled      = apperrors.Conflict("two_factor_already_enabled", "two-factor authentication is already enabled")
+	errTwoFactorDisabled     = apperrors.Conflict("two_factor_not_enabled", "two-factor authentication is not enabled")
+	errTwoFactorSetupExpired = apperrors.Validation("two_factor_setup_expired", "two-factor setup has expired, please start again")
+)
+
+type twoFactorService struct {
+	userRepo         repositories.UserRepository
+	recoveryCodeRepo repositories.RecoveryCodeRepository
+	redisClient      *redis.Client
+	jwtManager       *jwt.TokenManager
+	jwtConfig        *config.JWTConfig
+	twoFactorConfig  *config.TwoFactorConfig
+	loginTracker     *lockout.AttemptTracker
+}
+
+func NewTwoFactorService(userRepo repositories.UserRepository, recoveryCodeRepo repositories.RecoveryCodeRepository, redisClient *redis.Client, jwtManager *jwt.TokenManager, jwtConfig *config.JWTConfig, twoFactorConfig *config.TwoFactorConfig, loginTracker *lockout.AttemptTracker) services.TwoFactorService {
+	return &twoFactorService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		redisClient:      redisClient,
+		jwtManager:       jwtManager,
+		jwtConfig:        jwtConfig,
+		twoFactorConfig:  twoFactorConfig,
+		loginTracker:     loginTracker,
+	}
+}
+
+// Setup implements services.TwoFactorService.
+func (s *twoFactorService) Setup(ctx context.Context, principal *entities.Principal) (*dto.TwoFactorSetupResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled {
+		return nil, errTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// The secret only becomes active once the user proves they can generate codes with it
+	if err := s.redisClient.Set(ctx, fmt.Sprintf(totpSetupKey, user.ID), secret, totpSetupTTL).Err(); err != nil {
+		return nil, fmt.Errorf("failed to store two-factor secret: %w", err)
+	}
+
+	return &dto.TwoFactorSetupResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.URI(s.twoFactorConfig.Issuer, user.Email, secret),
+	}, nil
+}
+
+// Enable implements services.TwoFactorService.
+func (s *twoFactorService) Enable(ctx context.Context, principal *entities.Principal, req *dto.TwoFactorCodeRequest, clientIP string) (*dto.TwoFactorEnableResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.redisClient.Get(ctx, fmt.Sprintf(totpSetupKey, user.ID)).Result()
+	if err != nil {
+		return nil, errTwoFactorSetupExpired
+	}
+
+	err = s.limitAttempts(ctx, user, clientIP, func() error {
+		if _, ok := totp.Validate(secret, req.Code, time.Now()); !ok {
+			return errInvalidMFACode
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.generateRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = secret
+	user.TwoFactorEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	s.redisClient.Del(ctx, fmt.Sprintf(totpSetupKey, user.ID))
+
+	return &dto.TwoFactorEnableResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// Disable implements services.TwoFactorService.
+func (s *twoFactorService) Disable(ctx context.Context, principal *entities.Principal, req *dto.TwoFactorCodeRequest, clientIP string) error {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return err
+	}
+
+	if !user.TwoFactorEnabled {
+		return errTwoFactorDisabled
+	}
+
+	if err := s.limitAttempts(ctx, user, clientIP, func() error { return s.verifyCode(ctx, user, req.Code) }); err != nil {
+		return err
+	}
+
+	user.TOTPSecret = ""
+	user.TwoFactorEnabled = false
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+	}
+
+	return s.recoveryCodeRepo.DeleteByUserID(ctx, user.ID)
+}
+
+// Verify implements services.TwoFactorService.
+func (s *twoFactorService) Verify(ctx context.Context, req *dto.TwoFactorVerifyRequest, clientIP string) (*dto.AuthResponse, error) {
+	claims, err := s.jwtManager.ValidateToken(req.MFAToken, jwt.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive || !user.TwoFactorEnabled {
+		return nil, errUserNotFound
+	}
+
+	if err := s.limitAttempts(ctx, user, clientIP, func() error { return s.verifyCode(ctx, user, req.Code) }); err != nil {
+		return nil, err
+	}
+
+	// The challenge token is single-use
+	if err := s.jwtManager.InvalidateToken(req.MFAToken, jwt.MFAToken, s.jwtConfig.MFAExpiration); err != nil {
+		return nil, err
+	}
+
+	// The login is complete now, its failures are forgotten
+	if err := s.loginTracker.Reset(ctx, user.Email); err != nil {
+		return nil, err
+	}
+
+	return buildAuthResponse(s.jwtManager, s.jwtConfig, user, claims.Scopes, claims.OrganizationID)
+}
+
+// verifyCode accepts either a current TOTP code, which can't be replayed, or an unused recovery code
+func (s *twoFactorService) verifyCode(ctx context.Context, user *entities.User, code string) error {
+	if counter, ok := totp.Validate(user.TOTPSecret, code, time.Now()); ok {
+		firstUse, err := s.redisClient.SetNX(ctx, fmt.Sprintf(totpUsedKey, user.ID, counter), "1", 2*(totp.Skew+1)*totp.Period*time.Second).Result()
+		if err != nil {
+			return err
+		}
+		if !firstUse {
+			return errInvalidMFACode
+		}
+		return nil
+	}
+
+	consumed, err := s.recoveryCodeRepo.Consume(ctx, user.ID, password.HashToken(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return errInvalidMFACode
+	}
+
+	return nil
+}
+
+// limitAttempts runs verify, counting wrong codes like failed logins of the user's email and the
+// client's IP. The count outlives MFA tokens, so logging in again doesn't allow more guesses.
+func (s *twoFactorService) limitAttempts(ctx context.Context, user *entities.User, clientIP string, verify func() error) error {
+	if err := s.loginTracker.Check(ctx, user.Email, clientIP); err != nil {
+		return err
+	}
+
+	err := verify()
+	if errors.Is(err, errInvalidMFACode) {
+		if err := s.loginTracker.RegisterFailure(ctx, user.Email, clientIP); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// generateRecoveryCodes replaces the user's recovery codes, returning the plaintext codes exactly once
+func (s *twoFactorService) generateRecoveryCodes(ctx context.Context, userID string) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := password.GenerateRandomString(recoveryCodeLength)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, password.HashToken(code))
+	}
+
+	if err := s.recoveryCodeRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
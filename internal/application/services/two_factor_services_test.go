@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
+	"tasius.my.id/todolistapi/internal/utils/totp"
+)
+
+type twoFactorFixture struct {
+	service      *twoFactorService
+	jwtManager   *jwt.TokenManager
+	loginTracker *lockout.AttemptTracker
+	user         *entities.User
+	principal    *entities.Principal
+}
+
+// newTwoFactorFixture returns the service with a user who has two-factor authentication enabled
+func newTwoFactorFixture(t *testing.T) *twoFactorFixture {
+	t.Helper()
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	user := &entities.User{Email: "alice@example.com", IsActive: true, TwoFactorEnabled: true, TOTPSecret: secret}
+	userRepo := newFakeUserRepository(user)
+
+	redisClient, _ := newRedis(t)
+	jwtConfig, jwtManager := newJWT(t, redisClient)
+	loginTracker := lockout.NewAttemptTracker(&config.LockoutConfig{
+		MaxAttempts:   testMaxAttempts,
+		IPMaxAttempts: 100,
+		Window:        time.Minute,
+		Duration:      time.Minute,
+	}, redisClient)
+	service := NewTwoFactorService(userRepo, &fakeRecoveryCodeRepository{}, redisClient, jwtManager, jwtConfig, &config.TwoFactorConfig{Issuer: "Test"}, loginTracker)
+
+	return &twoFactorFixture{
+		service:      service.(*twoFactorService),
+		jwtManager:   jwtManager,
+		loginTracker: loginTracker,
+		user:         user,
+		principal:    &entities.Principal{UserID: user.ID, Email: user.Email},
+	}
+}
+
+func (f *twoFactorFixture) mfaToken(t *testing.T) string {
+	t.Helper()
+
+	token, err := f.jwtManager.GenerateMFAToken(f.user, entities.UserScopes, "")
+	if err != nil {
+		t.Fatalf("GenerateMFAToken() error = %v", err)
+	}
+	return token
+}
+
+func totpCode(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("GenerateCode() error = %v", err)
+	}
+	return code
+}
+
+func TestVerifyTwoFactor(t *testing.T) {
+	f := newTwoFactorFixture(t)
+
+	got, err := f.service.Verify(context.Background(), &dto.TwoFactorVerifyRequest{MFAToken: f.mfaToken(t), Code: totpCode(t, f.user.TOTPSecret)}, testClientIP)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if got.AccessToken == "" || got.RefreshToken == "" {
+		t.Errorf("Verify() = %+v, want tokens", got)
+	}
+}
+
+func TestVerifyTwoFactorResetsFailures(t *testing.T) {
+	f := newTwoFactorFixture(t)
+	ctx := context.Background()
+
+	registerFailures := func() {
+		for range testMaxAttempts - 1 {
+			if err := f.loginTracker.RegisterFailure(ctx, f.user.Email, testClientIP); err != nil {
+				t.Fatalf("RegisterFailure() error = %v", err)
+			}
+		}
+	}
+
+	// Failed passwords before the completed login don't add up with those after it
+	registerFailures()
+	if _, err := f.service.Verify(ctx, &dto.TwoFactorVerifyRequest{MFAToken: f.mfaToken(t), Code: totpCode(t, f.user.TOTPSecret)}, testClientIP); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	registerFailures()
+	if err := f.loginTracker.Check(ctx, f.user.Email, testClientIP); err != nil {
+		t.Errorf("Check() error = %v, want the failures before Verify() to be reset", err)
+	}
+}
+
+// TestTwoFactorAttemptsAreLimited guesses codes until the account is locked, after which even the
+// right code is rejected
+func TestTwoFactorAttemptsAreLimited(t *testing.T) {
+	tests := []struct {
+		name string
+		// setup prepares the fixture, e.g. a pending secret for enable
+		setup func(t *testing.T, f *twoFactorFixture) (secret string)
+		try   func(t *testing.T, f *twoFactorFixture, code string) error
+	}{
+		{
+			name:  "verify, with a new MFA token for every code",
+			setup: func(t *testing.T, f *twoFactorFixture) string { return f.user.TOTPSecret },
+			try: func(t *testing.T, f *twoFactorFixture, code string) error {
+				_, err := f.service.Verify(context.Background(), &dto.TwoFactorVerifyRequest{MFAToken: f.mfaToken(t), Code: code}, testClientIP)
+				return err
+			},
+		},
+		{
+			name: "enable",
+			setup: func(t *testing.T, f *twoFactorFixture) string {
+				f.user.TwoFactorEnabled, f.user.TOTPSecret = false, ""
+				_ = f.service.userRepo.Update(context.Background(), f.user)
+				setup, err := f.service.Setup(context.Background(), f.principal)
+				if err != nil {
+					t.Fatalf("Setup() error = %v", err)
+				}
+				return setup.Secret
+			},
+			try: func(t *testing.T, f *twoFactorFixture, code string) error {
+				_, err := f.service.Enable(context.Background(), f.principal, &dto.TwoFactorCodeRequest{Code: code}, testClientIP)
+				return err
+			},
+		},
+		{
+			name:  "disable",
+			setup: func(t *testing.T, f *twoFactorFixture) string { return f.user.TOTPSecret },
+			try: func(t *testing.T, f *twoFactorFixture, code string) error {
+				return f.service.Disable(context.Background(), f.principal, &dto.TwoFactorCodeRequest{Code: code}, testClientIP)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTwoFactorFixture(t)
+			secret := tt.setup(t, f)
+
+			for range testMaxAttempts {
+				if err := tt.try(t, f, "000000x"); !errors.Is(err, errInvalidMFACode) {
+					t.Fatalf("wrong code error = %v, want %v", err, errInvalidMFACode)
+				}
+			}
+			if err := tt.try(t, f, totpCode(t, secret)); !errors.Is(err, lockout.ErrLocked) {
+				t.Errorf("right code after too many wrong ones error = %v, want %v", err, lockout.ErrLocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/password"
+)
+
+const (
+	emailChangeKey = "email_change:%s" // token hash -> pending email change
+)
+
+var (
+	errUserNotFound           = apperrors.NotFound("user_not_found", "user not found")
+	errEmailAlreadyExists     = apperrors.Conflict("email_already_exists", "email already exists")
+	errInvalidCurrentPassword = apperrors.Validation("invalid_current_password", "current password is incorrect")
+	errInvalidEmailToken      = apperrors.Validation("invalid_email_token", "invalid or expired verification token")
+)
+
+type pendingEmailChange struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+type userService struct {
+	userRepo           repositories.UserRepository
+	todoRepo           repositories.TodoRepository
+	redisClient        *redis.Client
+	jwtManager         *jwt.TokenManager
+	mailer             services.Mailer
+	verificationConfig *config.EmailVerificationConfig
+}
+
+func NewUserService(userRepo repositories.UserRepository, todoRepo repositories.TodoRepository, redisClient *redis.Client, jwtManager *jwt.TokenManager, mailer services.Mailer, verificationConfig *config.EmailVerificationConfig) services.UserService {
+	return &userService{
+		userRepo:           userRepo,
+		todoRepo:           todoRepo,
+		redisClient:        redisClient,
+		jwtManager:         jwtManager,
+		mailer:             mailer,
+		verificationConfig: verificationConfig,
+	}
+}
+
+// GetProfile implements services.UserService.
+func (s *userService) GetProfile(ctx context.Context, principal *entities.Principal) (*dto.UserResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	return dto.NewUserResponse(user), nil
+}
+
+// UpdateProfile implements services.UserService.
+func (s *userService) UpdateProfile(ctx context.Context, principal *entities.Principal, req *dto.UpdateProfileRequest) (*dto.ProfileUpdateResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != "" && req.Name != user.Name {
+		user.Name = req.Name
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, fmt.Errorf("failed to update profile: %w", err)
+		}
+	}
+
+	response := &dto.ProfileUpdateResponse{User: dto.NewUserResponse(user)}
+
+	// The new email only replaces the current one once it has been verified
+	if req.Email != "" && req.Email != user.Email {
+		if err := s.requestEmailChange(ctx, user, req.Email); err != nil {
+			return nil, err
+		}
+		response.PendingEmail = req.Email
+	}
+
+	return response, nil
+}
+
+// VerifyEmailChange implements services.UserService.
+func (s *userService) VerifyEmailChange(ctx context.Context, principal *entities.Principal, req *dto.VerifyEmailChangeRequest) (*dto.UserResponse, error) {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := s.consumeEmailChange(ctx, req.Token)
+	if err != nil {
+		return nil, err
+	}
+	if change.UserID != user.ID {
+		return nil, errInvalidEmailToken
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, change.Email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, errEmailAlreadyExists
+	}
+
+	user.Email = change.Email
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+
+	return dto.NewUserResponse(user), nil
+}
+
+// ChangePassword implements services.UserService.
+func (s *userService) ChangePassword(ctx context.Context, principal *entities.Principal, req *dto.ChangePasswordRequest) error {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return err
+	}
+
+	if err := password.CheckPassword(req.CurrentPassword, user.Password); err != nil {
+		return errInvalidCurrentPassword
+	}
+
+	hashPassword, err := password.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	user.Password = hashPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	return s.jwtManager.RevokeAllSessions(user.ID)
+}
+
+// DeleteAccount implements services.UserService.
+func (s *userService) DeleteAccount(ctx context.Context, principal *entities.Principal, req *dto.DeleteAccountRequest) error {
+	user, err := currentUser(ctx, principal, s.userRepo)
+	if err != nil {
+		return err
+	}
+
+	if err := password.CheckPassword(req.Password, user.Password); err != nil {
+		return errInvalidCurrentPassword
+	}
+
+	if err := s.deleteTodos(ctx, user.ID); err != nil {
+		return err
+	}
+
+	// Deactivate and anonymize the account so the email can be registered again
+	user.IsActive = false
+	user.Name = "Deleted User"
+	user.Email = fmt.Sprintf("deleted-%s@deleted.invalid", user.ID)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return fmt.Errorf("failed to deactivate account: %w", err)
+	}
+
+	if err := s.userRepo.Delete(ctx, user.ID); err != nil {
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	return s.jwtManager.RevokeAllSessions(user.ID)
+}
+
+// currentUser loads the active user the principal acts for
+func currentUser(ctx context.Context, principal *entities.Principal, userRepo repositories.UserRepository) (*entities.User, error) {
+	user, err := userRepo.GetByID(ctx, principal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		return nil, errUserNotFound
+	}
+
+	return user, nil
+}
+
+// requestEmailChange stores the pending email change and sends a verification token to the new address
+func (s *userService) requestEmailChange(ctx context.Context, user *entities.User, email string) error {
+	exists, err := s.userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return errEmailAlreadyExists
+	}
+
+	token, err := password.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(pendingEmailChange{UserID: user.ID, Email: email})
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf(emailChangeKey, password.HashToken(token))
+	if err := s.redisClient.Set(ctx, key, payload, s.verificationConfig.TokenExpiration).Err(); err != nil {
+		return fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	return s.mailer.Send(ctx, &services.Mail{
+		To:      email,
+		Subject: "Verify your new email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your new email address with the link below. It expires in %s.\n\n%s?token=%s\n\nIf you did not request this change, you can ignore this email.\n",
+			user.Name, s.verificationConfig.TokenExpiration, s.verificationConfig.URL, token,
+		),
+	})
+}
+
+func (s *userService) consumeEmailChange(ctx context.Context, token string) (*pendingEmailChange, error) {
+	payload, err := s.redisClient.GetDel(ctx, fmt.Sprintf(emailChangeKey, password.HashToken(token))).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, errInvalidEmailToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var change pendingEmailChange
+	if err := json.Unmarshal([]byte(payload), &change); err != nil {
+		return nil, errInvalidEmailToken
+	}
+
+	return &change, nil
+}
+
+// deleteTodos removes the todos of a user in all organizations together with their cached lists.
+// Cached single todos are only served to their owner and expire on their own.
+func (s *userService) deleteTodos(ctx context.Context, userID string) error {
+	if err := s.todoRepo.DeleteByUserID(ctx, userID); err != nil {
+		return err
+	}
+
+	s.redisClient.Del(ctx, fmt.Sprintf(todoListCacheKey, userID))
+
+	return nil
+}
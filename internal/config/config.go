@@ -1,24 +1,40 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	HybridEncryption HybridEncryptionConfig
-	AppEnv    string
-	AppPort   string
+	Database          DatabaseConfig
+	Redis             RedisConfig
+	JWT               JWTConfig
+	HybridEncryption  HybridEncryptionConfig
+	FieldEncryption   FieldEncryptionConfig
+	Mail              MailConfig
+	PasswordReset     PasswordResetConfig
+	EmailVerification EmailVerificationConfig
+	TwoFactor         TwoFactorConfig
+	Lockout           LockoutConfig
+	OIDC              OIDCConfig
+	Organization      OrganizationConfig
+	Health            HealthConfig
+	Metrics           MetricsConfig
+	Tracing           TracingConfig
+	Log               LogConfig
+	AppEnv            string
+	AppPort           string
+	// ShutdownTimeout is how long in-flight requests and background tasks may take to finish on shutdown
+	ShutdownTimeout time.Duration
 }
 
 type JWTConfig struct {
-	PrivateKey string
-	Expiration time.Duration
+	PrivateKey        string
+	Expiration        time.Duration
 	RefreshExpiration time.Duration
+	MFAExpiration     time.Duration
 }
 
 type DatabaseConfig struct {
@@ -40,48 +56,289 @@ type RedisConfig struct {
 type HybridEncryptionConfig struct {
 	PrivateKeyPath string
 	PublicKeyPath  string
+	// PrivateKeyPassphrase decrypts encrypted private key files
+	PrivateKeyPassphrase string
+	// PreviousPrivateKeyPaths are keys still accepted for decryption while clients move to a rotated key
+	PreviousPrivateKeyPaths []string
+	ReloadInterval          time.Duration
+	// MaxClockSkew is how far the timestamp of an envelope may be off, nonces are remembered twice as long
+	MaxClockSkew time.Duration
+	// AllowLegacyFormat accepts the unversioned <encrypted_key_hex>:<encrypted_data_hex> format,
+	// which has no replay protection
+	AllowLegacyFormat bool
 }
 
-func Load() *Config {
-	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
-	redisPort, _ := strconv.Atoi(getEnv("REDIS_PORT", "6379"))
-	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+// FieldEncryptionConfig configures encryption of todo contents at rest. Every user has a data key,
+// stored wrapped by one of the master keys.
+type FieldEncryptionConfig struct {
+	// MasterKeyID selects the master key new data keys are wrapped with, encryption is off when empty
+	MasterKeyID string
+	// MasterKeys are base64 encoded 32 byte keys by ID, previous keys are kept until every data key is rewrapped
+	MasterKeys map[string]string
+}
+
+type MailConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+type PasswordResetConfig struct {
+	TokenExpiration time.Duration
+	URL             string
+	// MaxRequests and IPMaxRequests limit the reset links requested per email and per IP in RequestWindow
+	MaxRequests   int
+	IPMaxRequests int
+	RequestWindow time.Duration
+}
+
+type EmailVerificationConfig struct {
+	TokenExpiration time.Duration
+	URL             string
+}
+
+type TwoFactorConfig struct {
+	Issuer string
+}
+
+type LockoutConfig struct {
+	MaxAttempts   int
+	IPMaxAttempts int
+	Window        time.Duration
+	Duration      time.Duration
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+}
+
+type OIDCConfig struct {
+	Providers       map[string]OIDCProviderConfig
+	StateExpiration time.Duration
+}
+
+// OIDCProviderConfig describes an external OpenID Connect identity provider users can log in with
+type OIDCProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type OrganizationConfig struct {
+	InviteExpiration time.Duration
+	InviteURL        string
+}
+
+// HealthConfig configures the liveness and readiness probes
+type HealthConfig struct {
+	// CheckTimeout bounds each dependency ping of the readiness probe
+	CheckTimeout time.Duration
+	// DrainDelay is how long readiness fails on shutdown before the server stops accepting requests,
+	// giving load balancers time to take the instance out of rotation
+	DrainDelay time.Duration
+	// MaintenanceMode keeps the instance out of rotation, regardless of the maintenance flag in Redis
+	MaintenanceMode bool
+}
 
-	expiration, _ := time.ParseDuration(getEnv("JWT_EXPIRATION", "15m"))
-	refreshExpiration, _ := time.ParseDuration(getEnv("JWT_REFRESH_EXPIRATION", "720h"))
+type MetricsConfig struct {
+	// Token is required as a bearer token to scrape /metrics, which is open when empty
+	Token string
+}
 
-	return &Config{
+// Trace exporters TRACING_EXPORTER may be set to
+const (
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOTLP   = "otlp"
+)
+
+type TracingConfig struct {
+	// Exporter is none, stdout for local runs, or otlp
+	Exporter    string
+	ServiceName string
+	// SampleRatio is the share of traces started here that are recorded, incoming sampling decisions are kept
+	SampleRatio float64
+	// OTLPEndpoint is the host:port of the OTLP/HTTP collector
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// OTLPHeaders are sent with every export, e.g. an API key of a hosted collector
+	OTLPHeaders map[string]string
+}
+
+type LogConfig struct {
+	// Level is debug, info, warn or error
+	Level string
+	// Format is json or text
+	Format string
+}
+
+// Load resolves the configuration from defaults, the configuration file, environment variables and
+// flags, later sources overriding earlier ones. flags may be nil. All invalid settings are reported
+// together in the returned error.
+func Load(flags *Flags) (*Config, error) {
+	cfg, _, err := load(flags)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Inspect returns every setting with its source, along with the error Load would return
+func Inspect(flags *Flags) ([]Setting, error) {
+	_, settings, err := load(flags)
+	return settings, err
+}
+
+func load(flags *Flags) (*Config, []Setting, error) {
+	l, err := newLoader(flags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cfg := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "postgres"),
-			Port:     dbPort,
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:     l.string("DB_HOST", "localhost"),
+			User:     l.string("DB_USER", "postgres"),
+			Password: l.secret("DB_PASSWORD", defaultDBPassword),
+			DBName:   l.string("DB_NAME", "postgres"),
+			Port:     l.int("DB_PORT", 5432),
+			SSLMode:  l.string("DB_SSLMODE", "disable"),
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     redisPort,
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       redisDB,
+			Host:     l.string("REDIS_HOST", "localhost"),
+			Port:     l.int("REDIS_PORT", 6379),
+			Password: l.secret("REDIS_PASSWORD", ""),
+			DB:       l.int("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			PrivateKey:        getEnv("JWT_PRIVATE_KEY", "your-private-key"),
-			Expiration:        expiration,
-			RefreshExpiration: refreshExpiration,
+			PrivateKey:        l.secret("JWT_PRIVATE_KEY", defaultJWTPrivateKey),
+			Expiration:        l.duration("JWT_EXPIRATION", "15m"),
+			RefreshExpiration: l.duration("JWT_REFRESH_EXPIRATION", "720h"),
+			MFAExpiration:     l.duration("JWT_MFA_EXPIRATION", "5m"),
 		},
 		HybridEncryption: HybridEncryptionConfig{
-			PrivateKeyPath: getEnv("HYBRID_ENCRYPTION_PRIVATE_KEY_PATH", "keys/private.pem"),
-			PublicKeyPath:  getEnv("HYBRID_ENCRYPTION_PUBLIC_KEY_PATH", "keys/public.pem"),
+			PrivateKeyPath:          l.string("HYBRID_ENCRYPTION_PRIVATE_KEY_PATH", "keys/private.pem"),
+			PublicKeyPath:           l.string("HYBRID_ENCRYPTION_PUBLIC_KEY_PATH", "keys/public.pem"),
+			PrivateKeyPassphrase:    l.secret("HYBRID_ENCRYPTION_PRIVATE_KEY_PASSPHRASE", ""),
+			PreviousPrivateKeyPaths: l.list("HYBRID_ENCRYPTION_PREVIOUS_KEY_PATHS", ""),
+			ReloadInterval:          l.duration("HYBRID_ENCRYPTION_RELOAD_INTERVAL", "30s"),
+			MaxClockSkew:            l.duration("HYBRID_ENCRYPTION_MAX_CLOCK_SKEW", "5m"),
+			AllowLegacyFormat:       l.bool("HYBRID_ENCRYPTION_ALLOW_LEGACY_FORMAT", false),
+		},
+		FieldEncryption: FieldEncryptionConfig{
+			MasterKeyID: l.string("FIELD_ENCRYPTION_MASTER_KEY_ID", ""),
+			MasterKeys:  loadMasterKeys(l),
+		},
+		Mail: MailConfig{
+			Host:     l.string("SMTP_HOST", ""),
+			Port:     l.int("SMTP_PORT", 587),
+			Username: l.string("SMTP_USERNAME", ""),
+			Password: l.secret("SMTP_PASSWORD", ""),
+			From:     l.string("MAIL_FROM", "no-reply@todolistapi.local"),
+		},
+		PasswordReset: PasswordResetConfig{
+			TokenExpiration: l.duration("PASSWORD_RESET_EXPIRATION", "30m"),
+			URL:             l.string("PASSWORD_RESET_URL", "http://localhost:3000/reset-password"),
+			MaxRequests:     l.int("PASSWORD_RESET_MAX_REQUESTS", 3),
+			IPMaxRequests:   l.int("PASSWORD_RESET_IP_MAX_REQUESTS", 10),
+			RequestWindow:   l.duration("PASSWORD_RESET_REQUEST_WINDOW", "1h"),
+		},
+		EmailVerification: EmailVerificationConfig{
+			TokenExpiration: l.duration("EMAIL_VERIFICATION_EXPIRATION", "24h"),
+			URL:             l.string("EMAIL_VERIFICATION_URL", "http://localhost:3000/verify-email"),
+		},
+		TwoFactor: TwoFactorConfig{
+			Issuer: l.string("TOTP_ISSUER", "TodoListAPI"),
+		},
+		Lockout: LockoutConfig{
+			MaxAttempts:   l.int("LOGIN_MAX_ATTEMPTS", 5),
+			IPMaxAttempts: l.int("LOGIN_IP_MAX_ATTEMPTS", 20),
+			Window:        l.duration("LOGIN_ATTEMPT_WINDOW", "15m"),
+			Duration:      l.duration("LOGIN_LOCKOUT_DURATION", "15m"),
+			BaseDelay:     l.duration("LOGIN_BASE_DELAY", "1s"),
+			MaxDelay:      l.duration("LOGIN_MAX_DELAY", "30s"),
+		},
+		OIDC: OIDCConfig{
+			Providers:       loadOIDCProviders(l),
+			StateExpiration: l.duration("OIDC_STATE_EXPIRATION", "10m"),
+		},
+		Organization: OrganizationConfig{
+			InviteExpiration: l.duration("ORG_INVITE_EXPIRATION", "168h"),
+			InviteURL:        l.string("ORG_INVITE_URL", "http://localhost:3000/accept-invite"),
+		},
+		Health: HealthConfig{
+			CheckTimeout:    l.duration("HEALTH_CHECK_TIMEOUT", "2s"),
+			DrainDelay:      l.duration("SHUTDOWN_DRAIN_DELAY", "5s"),
+			MaintenanceMode: l.bool("MAINTENANCE_MODE", false),
 		},
-		AppEnv:  getEnv("APP_ENV", "development"),
-		AppPort: getEnv("APP_PORT", "3000"),
+		Metrics: MetricsConfig{
+			Token: l.secret("METRICS_TOKEN", ""),
+		},
+		Tracing: TracingConfig{
+			Exporter:     l.string("TRACING_EXPORTER", TracingExporterNone),
+			ServiceName:  l.string("TRACING_SERVICE_NAME", "todolistapi"),
+			SampleRatio:  l.float("TRACING_SAMPLE_RATIO", 1),
+			OTLPEndpoint: l.string("TRACING_OTLP_ENDPOINT", "localhost:4318"),
+			OTLPInsecure: l.bool("TRACING_OTLP_INSECURE", false),
+			OTLPHeaders:  loadOTLPHeaders(l),
+		},
+		Log: LogConfig{
+			Level:  strings.ToLower(l.string("LOG_LEVEL", "info")),
+			Format: strings.ToLower(l.string("LOG_FORMAT", "json")),
+		},
+		AppEnv:          l.string("APP_ENV", EnvDevelopment),
+		AppPort:         l.string("APP_PORT", "3000"),
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", "30s"),
+	}
+
+	l.validate(cfg)
+	l.checkUnknownFileKeys()
+	if len(l.errs) > 0 {
+		return nil, l.settings, fmt.Errorf("invalid configuration:\n%w", errors.Join(l.errs...))
+	}
+
+	return cfg, l.settings, nil
+}
+
+// loadOIDCProviders reads the providers listed in OIDC_PROVIDERS, each configured
+// through OIDC_<NAME>_* variables, e.g. OIDC_GOOGLE_ISSUER_URL for "google"
+func loadOIDCProviders(l *loader) map[string]OIDCProviderConfig {
+	providers := make(map[string]OIDCProviderConfig)
+	for _, name := range l.list("OIDC_PROVIDERS", "") {
+		name = strings.ToLower(name)
+		prefix := fmt.Sprintf("OIDC_%s_", strings.ToUpper(strings.ReplaceAll(name, "-", "_")))
+
+		providers[name] = OIDCProviderConfig{
+			Name:         name,
+			IssuerURL:    strings.TrimSuffix(l.string(prefix+"ISSUER_URL", ""), "/"),
+			ClientID:     l.string(prefix+"CLIENT_ID", ""),
+			ClientSecret: l.secret(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  l.string(prefix+"REDIRECT_URL", ""),
+			Scopes:       l.list(prefix+"SCOPES", "openid,email,profile"),
+		}
 	}
+	return providers
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// loadMasterKeys reads FIELD_ENCRYPTION_MASTER_KEYS, a comma separated list of <id>:<base64 key>
+func loadMasterKeys(l *loader) map[string]string {
+	keys := make(map[string]string)
+	for _, entry := range splitList(l.secret("FIELD_ENCRYPTION_MASTER_KEYS", "")) {
+		id, key, _ := strings.Cut(entry, ":")
+		keys[strings.TrimSpace(id)] = strings.TrimSpace(key)
 	}
-	return defaultValue
-}
\ No newline at end of file
+	return keys
+}
+
+// loadOTLPHeaders reads TRACING_OTLP_HEADERS, a comma separated list of <name>=<value>
+func loadOTLPHeaders(l *loader) map[string]string {
+	headers := make(map[string]string)
+	for _, entry := range splitList(l.secret("TRACING_OTLP_HEADERS", "")) {
+		name, value, _ := strings.Cut(entry, "=")
+		headers[strings.TrimSpace(name)] = strings.TrimSpace(value)
+	}
+	return headers
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+var fileKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// readFile reads a TOML configuration file into settings keyed like their environment variables.
+// Tables are prefixes, so "port" in [db] is DB_PORT and "client_id" in [oidc.google] is
+// OIDC_GOOGLE_CLIENT_ID. Arrays become comma separated lists.
+//
+// Settings are flat, so values are strings, numbers, booleans or arrays of those.
+func readFile(path string) (map[string]string, error) {
+	var document map[string]any
+	if _, err := toml.DecodeFile(path, &document); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", document); err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten adds the settings of a table to values, prefixing keys with the names of the tables it is in
+func flatten(values map[string]string, prefix string, table map[string]any) error {
+	for key, value := range table {
+		if !fileKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid key %q", key)
+		}
+		name := prefix + envKey(key)
+
+		if table, ok := value.(map[string]any); ok {
+			if err := flatten(values, name+"_", table); err != nil {
+				return err
+			}
+			continue
+		}
+
+		formatted, err := formatValue(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		values[name] = formatted
+	}
+	return nil
+}
+
+func envKey(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
+
+// formatValue formats a value like it would be set in the environment
+func formatValue(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	case []any:
+		items := make([]string, 0, len(value))
+		for _, item := range value {
+			if _, ok := item.([]any); ok {
+				return "", fmt.Errorf("nested arrays are not supported")
+			}
+			formatted, err := formatValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, formatted)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T, use a string, number, boolean or array", value)
+	}
+}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "top-level keys and tables",
+			content: `
+app_port = 3000 # comments are ignored
+[db]
+host = "localhost"
+ssl-mode = 'disable'
+`,
+			want: map[string]string{"APP_PORT": "3000", "DB_HOST": "localhost", "DB_SSL_MODE": "disable"},
+		},
+		{
+			name: "nested tables",
+			content: `
+[oidc.google]
+client_id = "google-client"
+`,
+			want: map[string]string{"OIDC_GOOGLE_CLIENT_ID": "google-client"},
+		},
+		{
+			name:    "numbers and booleans",
+			content: "max = 1_000\nratio = 0.25\nexp = 1e3\nnegative = -5\nenabled = true\noff = false",
+			want: map[string]string{
+				"MAX": "1000", "RATIO": "0.25", "EXP": "1000", "NEGATIVE": "-5", "ENABLED": "true", "OFF": "false",
+			},
+		},
+		{
+			name:    "escapes and hashes in strings",
+			content: `value = "a \"quoted\" # not a comment\tend"` + "\nliteral = 'C:\\keys\\private.pem'",
+			want:    map[string]string{"VALUE": "a \"quoted\" # not a comment\tend", "LITERAL": `C:\keys\private.pem`},
+		},
+		{
+			name: "arrays",
+			content: `
+scopes = ["openid", "email",
+  "profile", # trailing comma and comments
+]
+empty = []
+ports = [80, 443]
+`,
+			want: map[string]string{"SCOPES": "openid,email,profile", "EMPTY": "", "PORTS": "80,443"},
+		},
+		{
+			name:    "inline tables",
+			content: `oidc = { providers = ["google"] }`,
+			want:    map[string]string{"OIDC_PROVIDERS": "google"},
+		},
+		{
+			name:    "multi-line strings",
+			content: "headers = \"\"\"\nauthorization=token\"\"\"",
+			want:    map[string]string{"HEADERS": "authorization=token"},
+		},
+		{
+			name:    "unquoted string",
+			content: "level = info",
+			wantErr: "line 1",
+		},
+		{
+			name:    "unterminated array",
+			content: "scopes = [\"openid\",\n",
+			wantErr: "line",
+		},
+		{
+			name:    "duplicate key",
+			content: "port = 1\nport = 2",
+			wantErr: "port",
+		},
+		{
+			name:    "quoted key with dots",
+			content: `"db.host" = "localhost"`,
+			wantErr: `invalid key "db.host"`,
+		},
+		{
+			name:    "nested array",
+			content: `values = [[1, 2], [3]]`,
+			wantErr: "VALUES: nested arrays are not supported",
+		},
+		{
+			name:    "array of tables",
+			content: "[[providers]]\nname = \"google\"",
+			wantErr: "PROVIDERS: unsupported value",
+		},
+		{
+			name:    "date",
+			content: "since = 2024-01-01",
+			wantErr: "SINCE: unsupported value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readFile(writeConfigFile(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("readFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readFile() error = %v", err)
+			}
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("readFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	if _, err := readFile(filepath.Join(t.TempDir(), "missing.toml")); err == nil {
+		t.Error("readFile() of a missing file succeeded")
+	}
+}
+
+func TestReadExampleFile(t *testing.T) {
+	values, err := readFile(filepath.Join("..", "..", "config.example.toml"))
+	if err != nil {
+		t.Fatalf("readFile() error = %v", err)
+	}
+	if values["DB_PORT"] != "5432" || values["HYBRID_ENCRYPTION_RELOAD_INTERVAL"] != "30s" {
+		t.Errorf("readFile() = %v, want DB_PORT 5432 and HYBRID_ENCRYPTION_RELOAD_INTERVAL 30s", values)
+	}
+}
+
+func TestLookupPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		flag       *string
+		env        *string
+		file       *string
+		wantValue  string
+		wantSource string
+	}{
+		{name: "default", wantValue: "default", wantSource: SourceDefault},
+		{name: "file", file: ptr("file"), wantValue: "file", wantSource: SourceFile},
+		{name: "env over file", env: ptr("env"), file: ptr("file"), wantValue: "env", wantSource: SourceEnv},
+		{name: "empty env over file", env: ptr(""), file: ptr("file"), wantValue: "", wantSource: SourceEnv},
+		{name: "empty env over default", env: ptr(""), wantValue: "", wantSource: SourceEnv},
+		{name: "flag over env", flag: ptr("flag"), env: ptr("env"), file: ptr("file"), wantValue: "flag", wantSource: SourceFlag},
+	}
+
+	const key = "TODOLISTAPI_TEST_SETTING"
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &loader{flags: map[string]string{}, file: map[string]string{}}
+			if tt.flag != nil {
+				l.flags[key] = *tt.flag
+			}
+			if tt.file != nil {
+				l.file[key] = *tt.file
+			}
+			if tt.env != nil {
+				t.Setenv(key, *tt.env)
+			}
+
+			if got := l.string(key, "default"); got != tt.wantValue {
+				t.Errorf("lookup() = %q, want %q", got, tt.wantValue)
+			}
+			if got := l.settings[0].Source; got != tt.wantSource {
+				t.Errorf("lookup() source = %q, want %q", got, tt.wantSource)
+			}
+		})
+	}
+}
+
+func ptr(s string) *string {
+	return &s
+}
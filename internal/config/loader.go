@@ -0,0 +1,190 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of a setting, from lowest to highest precedence
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// Setting is a resolved configuration value and where it came from
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+	Secret bool
+}
+
+// Redacted returns the value with secrets masked
+func (s Setting) Redacted() string {
+	if s.Secret && s.Value != "" {
+		return "********"
+	}
+	return s.Value
+}
+
+// Flags are the command line options of the configuration, see RegisterFlags
+type Flags struct {
+	File      string
+	Overrides map[string]string
+}
+
+// RegisterFlags adds -config FILE and repeatable -set KEY=VALUE options to fs. Pass the result to Load
+// after parsing the command line.
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	flags := &Flags{Overrides: make(map[string]string)}
+	fs.StringVar(&flags.File, "config", "", "Configuration file (TOML), defaults to CONFIG_FILE")
+	fs.Func("set", "Override a setting, e.g. -set APP_PORT=8080 (repeatable)", func(value string) error {
+		key, val, ok := strings.Cut(value, "=")
+		key = strings.ToUpper(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return fmt.Errorf("expected KEY=VALUE, got %q", value)
+		}
+		flags.Overrides[key] = val
+		return nil
+	})
+	return flags
+}
+
+// loader resolves settings from flags, environment variables, the configuration file and defaults,
+// in that order, collecting every invalid value instead of stopping at the first one
+type loader struct {
+	flags    map[string]string
+	file     map[string]string
+	settings []Setting
+	errs     []error
+}
+
+func newLoader(flags *Flags) (*loader, error) {
+	l := &loader{flags: map[string]string{}, file: map[string]string{}}
+
+	path := os.Getenv("CONFIG_FILE")
+	if flags != nil {
+		l.flags = flags.Overrides
+		if flags.File != "" {
+			path = flags.File
+		}
+	}
+
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		l.file = values
+	}
+
+	return l, nil
+}
+
+func (l *loader) lookup(key, defaultValue string, secret bool) string {
+	setting := Setting{Key: key, Value: defaultValue, Source: SourceDefault, Secret: secret}
+	if value, ok := l.flags[key]; ok {
+		setting.Value, setting.Source = value, SourceFlag
+	} else if value, ok := os.LookupEnv(key); ok {
+		setting.Value, setting.Source = value, SourceEnv
+	} else if value, ok := l.file[key]; ok {
+		setting.Value, setting.Source = value, SourceFile
+	}
+
+	l.settings = append(l.settings, setting)
+	return setting.Value
+}
+
+// checkUnknownFileKeys reports file settings that were never read, which are usually typos
+func (l *loader) checkUnknownFileKeys() {
+	known := make(map[string]bool, len(l.settings))
+	for _, setting := range l.settings {
+		known[setting.Key] = true
+	}
+
+	keys := make([]string, 0, len(l.file))
+	for key := range l.file {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		l.errorf(key, "unknown setting in the configuration file")
+	}
+}
+
+func (l *loader) errorf(key, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (l *loader) string(key, defaultValue string) string {
+	return l.lookup(key, defaultValue, false)
+}
+
+// secret is a string that is redacted when the configuration is printed
+func (l *loader) secret(key, defaultValue string) string {
+	return l.lookup(key, defaultValue, true)
+}
+
+func (l *loader) int(key string, defaultValue int) int {
+	raw := l.lookup(key, strconv.Itoa(defaultValue), false)
+	value, err := strconv.Atoi(strings.TrimSpace(raw))
+	if err != nil {
+		l.errorf(key, "%q is not an integer", raw)
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) float(key string, defaultValue float64) float64 {
+	raw := l.lookup(key, strconv.FormatFloat(defaultValue, 'f', -1, 64), false)
+	value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+	if err != nil {
+		l.errorf(key, "%q is not a number", raw)
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) bool(key string, defaultValue bool) bool {
+	raw := l.lookup(key, strconv.FormatBool(defaultValue), false)
+	value, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		l.errorf(key, "%q is not a boolean", raw)
+		return defaultValue
+	}
+	return value
+}
+
+func (l *loader) duration(key, defaultValue string) time.Duration {
+	raw := l.lookup(key, defaultValue, false)
+	value, err := time.ParseDuration(strings.TrimSpace(raw))
+	if err != nil {
+		l.errorf(key, "%q is not a duration, use e.g. 30s, 15m or 24h", raw)
+		value, _ = time.ParseDuration(defaultValue)
+	}
+	return value
+}
+
+func (l *loader) list(key, defaultValue string) []string {
+	return splitList(l.lookup(key, defaultValue, false))
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,180 @@
+package config
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Environments APP_ENV may be set to
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+// Defaults that only make sense for local development, production refuses to start with them
+const (
+	defaultJWTPrivateKey = "your-private-key"
+	defaultDBPassword    = "postgres"
+	// minJWTKeyLength is the shortest HMAC secret accepted in production
+	minJWTKeyLength = 32
+)
+
+// publishedJWTPrivateKeys are keys that are public because they were committed with the examples
+var publishedJWTPrivateKeys = []string{
+	defaultJWTPrivateKey,
+	"hmRkbgqWqgWrlYgDZmdslzQeKPoFQsirseqwXk5_EQ4",
+}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// validate records every setting that is out of range or unsafe for the environment
+func (l *loader) validate(cfg *Config) {
+	if !slices.Contains([]string{EnvDevelopment, EnvTest, EnvStaging, EnvProduction}, cfg.AppEnv) {
+		l.errorf("APP_ENV", "%q is not one of development, test, staging or production", cfg.AppEnv)
+	}
+
+	appPort, err := strconv.Atoi(cfg.AppPort)
+	if err != nil {
+		appPort = 0
+	}
+	l.port("APP_PORT", appPort)
+	l.positive("SHUTDOWN_TIMEOUT", cfg.ShutdownTimeout)
+	l.positive("HEALTH_CHECK_TIMEOUT", cfg.Health.CheckTimeout)
+	if cfg.Health.DrainDelay < 0 {
+		l.errorf("SHUTDOWN_DRAIN_DELAY", "must not be negative")
+	}
+	if cfg.Health.DrainDelay >= cfg.ShutdownTimeout {
+		l.errorf("SHUTDOWN_DRAIN_DELAY", "must be shorter than SHUTDOWN_TIMEOUT")
+	}
+	if !slices.Contains([]string{TracingExporterNone, TracingExporterStdout, TracingExporterOTLP}, cfg.Tracing.Exporter) {
+		l.errorf("TRACING_EXPORTER", "%q is not one of none, stdout or otlp", cfg.Tracing.Exporter)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		l.errorf("TRACING_SAMPLE_RATIO", "must be between 0 and 1")
+	}
+	if cfg.Tracing.Exporter == TracingExporterOTLP && cfg.Tracing.OTLPEndpoint == "" {
+		l.errorf("TRACING_OTLP_ENDPOINT", "must be set for the otlp exporter")
+	}
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, cfg.Log.Level) {
+		l.errorf("LOG_LEVEL", "%q is not one of debug, info, warn or error", cfg.Log.Level)
+	}
+	if !slices.Contains([]string{"json", "text"}, cfg.Log.Format) {
+		l.errorf("LOG_FORMAT", "%q is not one of json or text", cfg.Log.Format)
+	}
+	l.port("DB_PORT", cfg.Database.Port)
+	l.port("REDIS_PORT", cfg.Redis.Port)
+	l.port("SMTP_PORT", cfg.Mail.Port)
+
+	if !slices.Contains(sslModes, cfg.Database.SSLMode) {
+		l.errorf("DB_SSLMODE", "%q is not a valid sslmode", cfg.Database.SSLMode)
+	}
+	if cfg.Redis.DB < 0 {
+		l.errorf("REDIS_DB", "must not be negative")
+	}
+
+	if cfg.JWT.PrivateKey == "" {
+		l.errorf("JWT_PRIVATE_KEY", "must be set")
+	}
+	l.positive("JWT_EXPIRATION", cfg.JWT.Expiration)
+	l.positive("JWT_REFRESH_EXPIRATION", cfg.JWT.RefreshExpiration)
+	l.positive("JWT_MFA_EXPIRATION", cfg.JWT.MFAExpiration)
+
+	l.positive("HYBRID_ENCRYPTION_RELOAD_INTERVAL", cfg.HybridEncryption.ReloadInterval)
+	l.positive("HYBRID_ENCRYPTION_MAX_CLOCK_SKEW", cfg.HybridEncryption.MaxClockSkew)
+
+	if id := cfg.FieldEncryption.MasterKeyID; id != "" {
+		if _, ok := cfg.FieldEncryption.MasterKeys[id]; !ok {
+			l.errorf("FIELD_ENCRYPTION_MASTER_KEY_ID", "master key %q is not listed in FIELD_ENCRYPTION_MASTER_KEYS", id)
+		}
+	}
+
+	l.positive("PASSWORD_RESET_EXPIRATION", cfg.PasswordReset.TokenExpiration)
+	l.absoluteURL("PASSWORD_RESET_URL", cfg.PasswordReset.URL)
+	if cfg.PasswordReset.MaxRequests < 1 {
+		l.errorf("PASSWORD_RESET_MAX_REQUESTS", "must be at least 1")
+	}
+	if cfg.PasswordReset.IPMaxRequests < 1 {
+		l.errorf("PASSWORD_RESET_IP_MAX_REQUESTS", "must be at least 1")
+	}
+	l.positive("PASSWORD_RESET_REQUEST_WINDOW", cfg.PasswordReset.RequestWindow)
+	l.positive("EMAIL_VERIFICATION_EXPIRATION", cfg.EmailVerification.TokenExpiration)
+	l.absoluteURL("EMAIL_VERIFICATION_URL", cfg.EmailVerification.URL)
+	l.positive("ORG_INVITE_EXPIRATION", cfg.Organization.InviteExpiration)
+	l.absoluteURL("ORG_INVITE_URL", cfg.Organization.InviteURL)
+
+	if cfg.Lockout.MaxAttempts < 1 {
+		l.errorf("LOGIN_MAX_ATTEMPTS", "must be at least 1")
+	}
+	if cfg.Lockout.IPMaxAttempts < 1 {
+		l.errorf("LOGIN_IP_MAX_ATTEMPTS", "must be at least 1")
+	}
+	l.positive("LOGIN_ATTEMPT_WINDOW", cfg.Lockout.Window)
+	l.positive("LOGIN_LOCKOUT_DURATION", cfg.Lockout.Duration)
+	if cfg.Lockout.BaseDelay < 0 {
+		l.errorf("LOGIN_BASE_DELAY", "must not be negative")
+	}
+	if cfg.Lockout.MaxDelay < cfg.Lockout.BaseDelay {
+		l.errorf("LOGIN_MAX_DELAY", "must not be shorter than LOGIN_BASE_DELAY")
+	}
+
+	l.positive("OIDC_STATE_EXPIRATION", cfg.OIDC.StateExpiration)
+	for _, provider := range cfg.OIDC.Providers {
+		prefix := "OIDC_" + envKey(provider.Name) + "_"
+		l.absoluteURL(prefix+"ISSUER_URL", provider.IssuerURL)
+		l.absoluteURL(prefix+"REDIRECT_URL", provider.RedirectURL)
+		if provider.ClientID == "" {
+			l.errorf(prefix+"CLIENT_ID", "must be set")
+		}
+	}
+
+	if cfg.AppEnv == EnvProduction {
+		l.validateProduction(cfg)
+	}
+}
+
+// validateProduction refuses the development defaults of secrets, the development mailer and open metrics
+func (l *loader) validateProduction(cfg *Config) {
+	switch {
+	case slices.Contains(publishedJWTPrivateKeys, cfg.JWT.PrivateKey):
+		l.errorf("JWT_PRIVATE_KEY", "must be changed from the default or example value in production, generate one with cmd/generate_jwt_key")
+	case len(cfg.JWT.PrivateKey) < minJWTKeyLength:
+		l.errorf("JWT_PRIVATE_KEY", "must be at least %d characters in production", minJWTKeyLength)
+	}
+
+	if cfg.Database.Password == defaultDBPassword {
+		l.errorf("DB_PASSWORD", "must be changed from the default in production")
+	}
+
+	// Without a host mails are only logged, users would never get their reset and invite links
+	if cfg.Mail.Host == "" {
+		l.errorf("SMTP_HOST", "is required in production")
+	}
+
+	// Without a token anyone who can reach the server can read the metrics
+	if cfg.Metrics.Token == "" {
+		l.errorf("METRICS_TOKEN", "is required in production")
+	}
+}
+
+func (l *loader) port(key string, port int) {
+	if port < 1 || port > 65535 {
+		l.errorf(key, "%d is not a valid port", port)
+	}
+}
+
+func (l *loader) positive(key string, duration time.Duration) {
+	if duration <= 0 {
+		l.errorf(key, "must be positive")
+	}
+}
+
+func (l *loader) absoluteURL(key, value string) {
+	parsed, err := url.Parse(value)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		l.errorf(key, "%q is not an absolute http(s) URL", value)
+	}
+}
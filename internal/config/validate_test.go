@@ -0,0 +1,54 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateProduction(t *testing.T) {
+	production := map[string]string{
+		"APP_ENV":         EnvProduction,
+		"JWT_PRIVATE_KEY": strings.Repeat("k", minJWTKeyLength),
+		"DB_PASSWORD":     "a-real-password",
+		"SMTP_HOST":       "smtp.example.com",
+		"METRICS_TOKEN":   "a-metrics-token",
+	}
+
+	tests := []struct {
+		name    string
+		change  map[string]string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "default JWT key", change: map[string]string{"JWT_PRIVATE_KEY": defaultJWTPrivateKey}, wantErr: "JWT_PRIVATE_KEY: must be changed"},
+		{name: "JWT key of the example file", change: map[string]string{"JWT_PRIVATE_KEY": "hmRkbgqWqgWrlYgDZmdslzQeKPoFQsirseqwXk5_EQ4"}, wantErr: "JWT_PRIVATE_KEY: must be changed"},
+		{name: "short JWT key", change: map[string]string{"JWT_PRIVATE_KEY": "short"}, wantErr: "JWT_PRIVATE_KEY: must be at least"},
+		{name: "default database password", change: map[string]string{"DB_PASSWORD": defaultDBPassword}, wantErr: "DB_PASSWORD: must be changed"},
+		{name: "no SMTP host", change: map[string]string{"SMTP_HOST": ""}, wantErr: "SMTP_HOST: is required in production"},
+		{name: "no metrics token", change: map[string]string{"METRICS_TOKEN": ""}, wantErr: "METRICS_TOKEN: is required in production"},
+		{name: "no SMTP host in development", change: map[string]string{"APP_ENV": EnvDevelopment, "SMTP_HOST": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := &Flags{Overrides: make(map[string]string)}
+			for key, value := range production {
+				flags.Overrides[key] = value
+			}
+			for key, value := range tt.change {
+				flags.Overrides[key] = value
+			}
+
+			_, err := Load(flags)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Load() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+// Package apperrors defines the errors services return for failures the caller can do something
+// about. Each error has a kind, which interfaces map to their own status, e.g. an HTTP status code,
+// and a stable code clients can match on instead of the message.
+package apperrors
+
+import (
+	"errors"
+	"time"
+)
+
+// Kind classifies what went wrong from the caller's point of view
+type Kind string
+
+const (
+	KindNotFound        Kind = "not_found"
+	KindForbidden       Kind = "forbidden"
+	KindConflict        Kind = "conflict"
+	KindValidation      Kind = "validation"
+	KindUnauthenticated Kind = "unauthenticated"
+	KindRateLimited     Kind = "rate_limited"
+	KindUpstream        Kind = "upstream"
+)
+
+// CodeValidationFailed is the code of requests rejected by a validator, see ValidationFailed
+const CodeValidationFailed = "validation_failed"
+
+// Error is an error with a kind and a stable code. Errors are values: the With and Wrap methods
+// return copies, so package level errors can be shared.
+type Error struct {
+	Kind Kind
+	// Code identifies the error, e.g. "todo_not_found". It never changes once released.
+	Code    string
+	Message string
+	// Errors maps the fields of a request that failed validation to their problem
+	Errors map[string]string
+	// RetryAfter tells rate limited callers when they may try again, zero if unknown
+	RetryAfter time.Duration
+
+	cause error
+}
+
+// New creates an error of the given kind
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NotFound creates an error for a resource that doesn't exist or isn't visible to the caller
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+// Forbidden creates an error for an action the caller isn't allowed to take
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// Conflict creates an error for an action that conflicts with the current state, e.g. a duplicate
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+// Validation creates an error for invalid input
+func Validation(code, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+// Unauthenticated creates an error for missing, invalid or expired credentials
+func Unauthenticated(code, message string) *Error {
+	return New(KindUnauthenticated, code, message)
+}
+
+// RateLimited creates an error for a caller that has to slow down
+func RateLimited(code, message string) *Error {
+	return New(KindRateLimited, code, message)
+}
+
+// Upstream creates an error for a failure of an external service the action depends on, e.g. an
+// identity provider
+func Upstream(code, message string) *Error {
+	return New(KindUpstream, code, message)
+}
+
+// ValidationFailed creates the error of a request that failed validation, with the problem of each
+// invalid field
+func ValidationFailed(fields map[string]string) *Error {
+	return Validation(CodeValidationFailed, "Validation failed").WithErrors(fields)
+}
+
+// Error returns the message followed by the cause, clients are only shown the message
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+// Unwrap returns the error that caused e, if any
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same kind and code, so errors.Is matches copies
+// made with the With and Wrap methods
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// Wrap returns a copy of e caused by err, e.g. the failure of an external service behind it
+func (e *Error) Wrap(err error) *Error {
+	wrapped := *e
+	wrapped.cause = err
+	return &wrapped
+}
+
+// WithMessage returns a copy of e with another message, e.g. one naming the resource
+func (e *Error) WithMessage(message string) *Error {
+	copied := *e
+	copied.Message = message
+	return &copied
+}
+
+// WithErrors returns a copy of e with the problem of each invalid field of the request
+func (e *Error) WithErrors(fields map[string]string) *Error {
+	copied := *e
+	copied.Errors = fields
+	return &copied
+}
+
+// WithRetryAfter returns a copy of e telling the caller when to try again
+func (e *Error) WithRetryAfter(retryAfter time.Duration) *Error {
+	copied := *e
+	copied.RetryAfter = retryAfter
+	return &copied
+}
+
+// As returns the *Error in the chain of err
+func As(err error) (*Error, bool) {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr, true
+	}
+	return nil, false
+}
+
+// IsKind reports whether err is an *Error of the given kind
+func IsKind(err error, kind Kind) bool {
+	appErr, ok := As(err)
+	return ok && appErr.Kind == kind
+}
@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKey is a personal access key for scripts and CI, only its hash is stored
+type APIKey struct {
+	ID     string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID string `gorm:"not null;type:uuid;index"`
+	User   User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// OrganizationID is the organization the key acts in, nil for the personal space
+	OrganizationID *string  `gorm:"type:uuid;index"`
+	Name           string   `gorm:"not null"`
+	Prefix         string   `gorm:"not null"`
+	KeyHash        string   `gorm:"not null;uniqueIndex"`
+	Scopes         []string `gorm:"serializer:json;not null"`
+	ExpiresAt      *time.Time
+	LastUsedAt     *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsExpired reports whether the key is past its expiry date
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
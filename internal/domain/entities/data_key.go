@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// DataKey is the key a user's todo contents are encrypted with at rest, stored wrapped by a master key
+type DataKey struct {
+	ID     string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID string `gorm:"not null;type:uuid;uniqueIndex"`
+	User   User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	// MasterKeyID is the master key WrappedKey is encrypted with
+	MasterKeyID string `gorm:"not null;index"`
+	WrappedKey  []byte `gorm:"not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (DataKey) TableName() string {
+	return "user_data_keys"
+}
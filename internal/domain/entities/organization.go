@@ -0,0 +1,66 @@
+package entities
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	OrgRoleOwner  = "owner"
+	OrgRoleAdmin  = "admin"
+	OrgRoleMember = "member"
+)
+
+// Organization is a team workspace, todos created inside it are kept apart from other organizations
+type Organization struct {
+	ID        string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	Name      string `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// OrganizationRef maps an organization ID to its column value, the personal space ("") is stored as NULL
+func OrganizationRef(organizationID string) *string {
+	if organizationID == "" {
+		return nil
+	}
+	return &organizationID
+}
+
+// OrganizationMember is the membership of a user in an organization
+type OrganizationMember struct {
+	ID             string       `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string       `gorm:"not null;type:uuid;uniqueIndex:idx_organization_members_org_user"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID         string       `gorm:"not null;type:uuid;uniqueIndex:idx_organization_members_org_user;index"`
+	User           User         `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Role           string       `gorm:"not null;default:'member'"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// CanManage reports whether the member may invite and remove other members
+func (m *OrganizationMember) CanManage() bool {
+	return m.Role == OrgRoleOwner || m.Role == OrgRoleAdmin
+}
+
+// OrganizationInvite is a pending invitation to join an organization, only its token hash is stored
+type OrganizationInvite struct {
+	ID             string       `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	OrganizationID string       `gorm:"not null;type:uuid;index"`
+	Organization   Organization `gorm:"foreignKey:OrganizationID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Email          string       `gorm:"not null"`
+	Role           string       `gorm:"not null"`
+	TokenHash      string       `gorm:"not null;uniqueIndex"`
+	InvitedByID    string       `gorm:"not null;type:uuid"`
+	ExpiresAt      time.Time    `gorm:"not null"`
+	AcceptedAt     *time.Time
+	CreatedAt      time.Time
+}
+
+// IsExpired reports whether the invite can no longer be accepted
+func (i *OrganizationInvite) IsExpired() bool {
+	return time.Now().After(i.ExpiresAt)
+}
@@ -0,0 +1,14 @@
+package entities
+
+// Principal is the authenticated caller a service acts for, whether the request came in over HTTP,
+// from a command line tool or from a background job
+type Principal struct {
+	UserID string
+	Email  string
+	Role   string
+	// Scopes are the scopes granted to the caller's credential
+	Scopes []string
+	// OrganizationID is the organization the caller acts in, empty for the personal space
+	OrganizationID   string
+	OrganizationRole string
+}
@@ -0,0 +1,13 @@
+package entities
+
+import "time"
+
+// RecoveryCode is a single-use two-factor backup code, only its hash is stored
+type RecoveryCode struct {
+	ID        string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID    string `gorm:"not null;type:uuid;index"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	CodeHash  string `gorm:"not null;uniqueIndex"`
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -0,0 +1,44 @@
+package entities
+
+import (
+	"fmt"
+	"slices"
+
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+)
+
+// Scopes limit what a credential is allowed to do
+const (
+	ScopeTodosRead    = "todos:read"
+	ScopeTodosWrite   = "todos:write"
+	ScopeProfileRead  = "profile:read"
+	ScopeProfileWrite = "profile:write"
+	ScopeAdmin        = "admin"
+)
+
+// UserScopes are the scopes a user can grant to their own credentials
+var UserScopes = []string{
+	ScopeTodosRead,
+	ScopeTodosWrite,
+	ScopeProfileRead,
+	ScopeProfileWrite,
+}
+
+// ErrScopeNotAllowed is returned for scopes beyond those of the credential asking for them
+var ErrScopeNotAllowed = apperrors.Forbidden("scope_not_allowed", "scope not allowed")
+
+// NarrowScopes returns the requested scopes if all of them are allowed,
+// or every allowed scope when none are requested
+func NarrowScopes(allowed, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return allowed, nil
+	}
+
+	for _, scope := range requested {
+		if !slices.Contains(allowed, scope) {
+			return nil, ErrScopeNotAllowed.WithMessage(fmt.Sprintf("scope not allowed: %s", scope))
+		}
+	}
+
+	return requested, nil
+}
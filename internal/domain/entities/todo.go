@@ -12,7 +12,9 @@ type Todo struct {
 	Description string `gorm:"not null"`
 	UserID      string `gorm:"not null;type:uuid;index"`
 	User        User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
-	DeletedAt   gorm.DeletedAt `gorm:"index"`
-}
\ No newline at end of file
+	// OrganizationID is nil for todos in the user's personal space
+	OrganizationID *string `gorm:"type:uuid;index"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	DeletedAt      gorm.DeletedAt `gorm:"index"`
+}
@@ -1,34 +1,59 @@
 package entities
 
 import (
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	ID        string         `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
-	Email     string         `json:"email" gorm:"unique;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	Name      string         `json:"name" gorm:"not null"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID               string         `json:"id" gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Email            string         `json:"email" gorm:"unique;not null"`
+	Password         string         `json:"-" gorm:"not null"`
+	Name             string         `json:"name" gorm:"not null"`
+	Role             string         `json:"role" gorm:"not null;default:'user';index"`
+	IsActive         bool           `json:"is_active" gorm:"default:true"`
+	TOTPSecret       string         `json:"-"`
+	TwoFactorEnabled bool           `json:"two_factor_enabled" gorm:"default:false"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
 func (User) TableName() string {
 	return "users"
 }
 
+// AllowedScopes returns the scopes the user may request for their tokens
+func (u *User) AllowedScopes() []string {
+	if u.IsAdmin() {
+		return append(slices.Clone(UserScopes), ScopeAdmin)
+	}
+	return UserScopes
+}
+
+// IsAdmin reports whether the user has the admin role
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
 // BeforeCreate hook to set default values
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == "" {
 		u.ID = uuid.NewString()
 	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
 	if !u.IsActive {
 		u.IsActive = true
 	}
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,19 @@
+package entities
+
+import "time"
+
+// UserIdentity links a user to their account at an external OpenID Connect provider
+type UserIdentity struct {
+	ID        string `gorm:"primaryKey;type:uuid;default:uuid_generate_v4()"`
+	UserID    string `gorm:"not null;type:uuid;index"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	Provider  string `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject   string `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
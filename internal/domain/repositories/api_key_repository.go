@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, apiKey *entities.APIKey) error
+	GetByHash(ctx context.Context, keyHash string) (*entities.APIKey, error)
+	GetAllByUserID(ctx context.Context, userID string) ([]entities.APIKey, error)
+	Delete(ctx context.Context, id string, userID string) error
+	UpdateLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+}
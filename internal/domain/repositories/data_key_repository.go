@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type DataKeyRepository interface {
+	// GetByUserID returns nil when the user has no data key yet
+	GetByUserID(ctx context.Context, userID string) (*entities.DataKey, error)
+	// Create stores the key unless the user already has one, callers read the stored key back
+	Create(ctx context.Context, key *entities.DataKey) error
+	// ListNotWrappedWith returns keys wrapped with any master key other than masterKeyID
+	ListNotWrappedWith(ctx context.Context, masterKeyID string, limit int) ([]entities.DataKey, error)
+	UpdateWrappedKey(ctx context.Context, id string, masterKeyID string, wrappedKey []byte) error
+}
@@ -0,0 +1,13 @@
+package repositories
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type OrganizationInviteRepository interface {
+	Create(ctx context.Context, invite *entities.OrganizationInvite) error
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entities.OrganizationInvite, error)
+	Accept(ctx context.Context, invite *entities.OrganizationInvite, userID string) error
+}
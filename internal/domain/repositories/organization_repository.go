@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type OrganizationRepository interface {
+	CreateWithOwner(ctx context.Context, organization *entities.Organization, ownerID string) error
+	GetByID(ctx context.Context, id string) (*entities.Organization, error)
+	GetMember(ctx context.Context, organizationID string, userID string) (*entities.OrganizationMember, error)
+	GetMembers(ctx context.Context, organizationID string) ([]entities.OrganizationMember, error)
+	GetMembershipsByUserID(ctx context.Context, userID string) ([]entities.OrganizationMember, error)
+	RemoveMember(ctx context.Context, organizationID string, userID string) error
+	CountMembersByRole(ctx context.Context, organizationID string, role string) (int64, error)
+}
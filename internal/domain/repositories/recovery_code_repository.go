@@ -0,0 +1,9 @@
+package repositories
+
+import "context"
+
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID string, codeHashes []string) error
+	Consume(ctx context.Context, userID string, codeHash string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
@@ -6,10 +6,14 @@ import (
 	"tasius.my.id/todolistapi/internal/domain/entities"
 )
 
+// TodoRepository queries are scoped to an organization, an empty organizationID is the
+// user's personal space. DeleteByUserID and CountByUserID span all organizations of a user.
 type TodoRepository interface {
 	Create(ctx context.Context, todo *entities.Todo) error
-	GetAll(ctx context.Context, userID string) ([]entities.Todo, error)
-	GetByID(ctx context.Context, id string) (*entities.Todo, error)
-	Update(ctx context.Context, id string, todo *entities.Todo) error
-	Delete(ctx context.Context, id string) error
+	GetAll(ctx context.Context, userID string, organizationID string) ([]entities.Todo, error)
+	GetByID(ctx context.Context, id string, organizationID string) (*entities.Todo, error)
+	Update(ctx context.Context, id string, organizationID string, todo *entities.Todo) error
+	Delete(ctx context.Context, id string, organizationID string) error
+	DeleteByUserID(ctx context.Context, userID string) error
+	CountByUserID(ctx context.Context, userID string) (int64, error)
 }
\ No newline at end of file
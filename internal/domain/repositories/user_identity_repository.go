@@ -0,0 +1,14 @@
+package repositories
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type UserIdentityRepository interface {
+	Create(ctx context.Context, identity *entities.UserIdentity) error
+	CreateWithUser(ctx context.Context, user *entities.User, identity *entities.UserIdentity) error
+	GetByProviderSubject(ctx context.Context, provider string, subject string) (*entities.UserIdentity, error)
+	Delete(ctx context.Context, id string) error
+}
@@ -6,11 +6,29 @@ import (
 	"tasius.my.id/todolistapi/internal/domain/entities"
 )
 
+// UserFilter narrows down a user listing, zero values are ignored
+type UserFilter struct {
+	Search   string
+	Role     string
+	IsActive *bool
+	Offset   int
+	Limit    int
+	// OrganizationID limits the listing to members of an organization
+	OrganizationID string
+}
+
+// UserRepository stores the accounts of users. Accounts are global on purpose: a person signs in
+// once and may be a member of several organizations, so authentication and profile lookups by ID or
+// email see every user. Lookups on behalf of an organization use GetMemberByEmail or List with an
+// OrganizationID, which only see its members.
 type UserRepository interface {
 	Create(ctx context.Context, user *entities.User) error
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	// GetMemberByEmail returns the user with the email if they are a member of the organization
+	GetMemberByEmail(ctx context.Context, organizationID string, email string) (*entities.User, error)
 	GetByID(ctx context.Context, id string) (*entities.User, error)
 	Update(ctx context.Context, user *entities.User) error
 	Delete(ctx context.Context, id string) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	List(ctx context.Context, filter UserFilter) ([]entities.User, int64, error)
 }
\ No newline at end of file
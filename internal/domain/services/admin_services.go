@@ -0,0 +1,19 @@
+package services
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type AdminService interface {
+	ListUsers(ctx context.Context, req *dto.ListUsersRequest) (*dto.UserListResponse, error)
+	GetUser(ctx context.Context, id string) (*dto.AdminUserResponse, error)
+	SetUserActive(ctx context.Context, principal *entities.Principal, id string, active bool) (*dto.AdminUserResponse, error)
+	UpdateUserRole(ctx context.Context, principal *entities.Principal, id string, req *dto.UpdateRoleRequest) (*dto.AdminUserResponse, error)
+	ForceLogout(ctx context.Context, id string) error
+	ResetUserPassword(ctx context.Context, principal *entities.Principal, id string) error
+	UnlockUser(ctx context.Context, id string) error
+	GetUserStats(ctx context.Context, id string) (*dto.UserStatsResponse, error)
+}
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, principal *entities.Principal, req *dto.CreateAPIKeyRequest) (*dto.CreatedAPIKeyResponse, error)
+	GetAllAPIKeys(ctx context.Context, principal *entities.Principal) ([]dto.APIKeyResponse, error)
+	RevokeAPIKey(ctx context.Context, principal *entities.Principal, id string) error
+	Authenticate(ctx context.Context, key string) (*entities.APIKey, error)
+}
@@ -1,14 +1,20 @@
 package services
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"context"
+
 	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
 )
 
 type AuthService interface {
-	Register(ctx *fiber.Ctx, req *dto.RegisterRequest) (*dto.AuthResponse, error)
-	Login(ctx *fiber.Ctx, req *dto.LoginRequest) (*dto.AuthResponse, error)
-	Logout(ctx *fiber.Ctx) error
-	RefreshToken(ctx *fiber.Ctx, refreshToken string) (*dto.AuthResponse, error)
-	ValidateToken(ctx *fiber.Ctx, token string) (*dto.UserResponse, error)
+	Register(ctx context.Context, req *dto.RegisterRequest) (*dto.AuthResponse, error)
+	Login(ctx context.Context, req *dto.LoginRequest, clientIP string) (*dto.AuthResponse, error)
+	Logout(ctx context.Context, principal *entities.Principal) error
+	RefreshToken(ctx context.Context, refreshToken string) (*dto.AuthResponse, error)
+	ValidateToken(ctx context.Context, token string) (*dto.UserResponse, error)
+	ForgotPassword(ctx context.Context, req *dto.ForgotPasswordRequest, clientIP string) error
+	// SendResetLink mails a reset link to a registered email, without the limits of ForgotPassword
+	SendResetLink(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, req *dto.ResetPasswordRequest) error
 }
\ No newline at end of file
@@ -0,0 +1,10 @@
+package services
+
+import "context"
+
+// FieldEncryptor encrypts values of a user at rest with the data key of the user. The additional data
+// binds a ciphertext to where it is stored, decryption fails anywhere else.
+type FieldEncryptor interface {
+	Encrypt(ctx context.Context, userID, additionalData, plaintext string) (string, error)
+	Decrypt(ctx context.Context, userID, additionalData, value string) (string, error)
+}
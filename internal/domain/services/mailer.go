@@ -0,0 +1,15 @@
+package services
+
+import "context"
+
+// Mail is a plain-text email message
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers outgoing emails such as password reset links
+type Mailer interface {
+	Send(ctx context.Context, mail *Mail) error
+}
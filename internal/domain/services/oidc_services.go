@@ -0,0 +1,12 @@
+package services
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+)
+
+type OIDCService interface {
+	Login(ctx context.Context, provider string) (*dto.OIDCLoginResponse, error)
+	Callback(ctx context.Context, provider string, req *dto.OIDCCallbackRequest) (*dto.AuthResponse, error)
+}
@@ -0,0 +1,20 @@
+package services
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type OrganizationService interface {
+	CreateOrganization(ctx context.Context, principal *entities.Principal, req *dto.CreateOrganizationRequest) (*dto.OrganizationResponse, error)
+	GetMyOrganizations(ctx context.Context, principal *entities.Principal) ([]dto.OrganizationResponse, error)
+	GetOrganization(ctx context.Context, principal *entities.Principal, id string) (*dto.OrganizationResponse, error)
+	GetMembers(ctx context.Context, principal *entities.Principal, id string) ([]dto.OrganizationMemberResponse, error)
+	InviteMember(ctx context.Context, principal *entities.Principal, id string, req *dto.InviteMemberRequest) (*dto.InviteResponse, error)
+	AcceptInvite(ctx context.Context, principal *entities.Principal, req *dto.AcceptInviteRequest) (*dto.OrganizationResponse, error)
+	LeaveOrganization(ctx context.Context, principal *entities.Principal, id string) error
+	RemoveMember(ctx context.Context, principal *entities.Principal, id string, userID string) error
+	SwitchOrganization(ctx context.Context, principal *entities.Principal, req *dto.SwitchOrganizationRequest) (*dto.AuthResponse, error)
+}
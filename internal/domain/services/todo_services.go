@@ -1,14 +1,16 @@
 package services
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"context"
+
 	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
 )
 
 type TodoService interface {
-	CreateTodo(ctx *fiber.Ctx, todo dto.TodoDTO) (*dto.TodoResponse, error)
-	GetAllTodos(ctx *fiber.Ctx) ([]dto.TodoResponse, error)
-	GetTodoByID(ctx *fiber.Ctx, id string) (*dto.TodoResponse, error)
-	UpdateTodo(ctx *fiber.Ctx, id string, todo dto.UpdateTodoRequest) (*dto.TodoResponse, error)
-	DeleteTodo(ctx *fiber.Ctx, id string) error
+	CreateTodo(ctx context.Context, principal *entities.Principal, todo dto.TodoDTO) (*dto.TodoResponse, error)
+	GetAllTodos(ctx context.Context, principal *entities.Principal) ([]dto.TodoResponse, error)
+	GetTodoByID(ctx context.Context, principal *entities.Principal, id string) (*dto.TodoResponse, error)
+	UpdateTodo(ctx context.Context, principal *entities.Principal, id string, todo dto.UpdateTodoRequest) (*dto.TodoResponse, error)
+	DeleteTodo(ctx context.Context, principal *entities.Principal, id string) error
 }
\ No newline at end of file
@@ -0,0 +1,15 @@
+package services
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type TwoFactorService interface {
+	Setup(ctx context.Context, principal *entities.Principal) (*dto.TwoFactorSetupResponse, error)
+	Enable(ctx context.Context, principal *entities.Principal, req *dto.TwoFactorCodeRequest, clientIP string) (*dto.TwoFactorEnableResponse, error)
+	Disable(ctx context.Context, principal *entities.Principal, req *dto.TwoFactorCodeRequest, clientIP string) error
+	Verify(ctx context.Context, req *dto.TwoFactorVerifyRequest, clientIP string) (*dto.AuthResponse, error)
+}
@@ -0,0 +1,16 @@
+package services
+
+import (
+	"context"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+type UserService interface {
+	GetProfile(ctx context.Context, principal *entities.Principal) (*dto.UserResponse, error)
+	UpdateProfile(ctx context.Context, principal *entities.Principal, req *dto.UpdateProfileRequest) (*dto.ProfileUpdateResponse, error)
+	VerifyEmailChange(ctx context.Context, principal *entities.Principal, req *dto.VerifyEmailChangeRequest) (*dto.UserResponse, error)
+	ChangePassword(ctx context.Context, principal *entities.Principal, req *dto.ChangePasswordRequest) error
+	DeleteAccount(ctx context.Context, principal *entities.Principal, req *dto.DeleteAccountRequest) error
+}
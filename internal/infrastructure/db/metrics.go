@@ -0,0 +1,75 @@
+package db
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/utils/metrics"
+)
+
+const queryStartKey = "metrics:query_start"
+
+// MetricsPlugin records the duration of every GORM query in metrics.DBQueryDuration
+type MetricsPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (MetricsPlugin) Name() string {
+	return "metrics"
+}
+
+// Initialize implements gorm.Plugin.
+func (p MetricsPlugin) Initialize(db *gorm.DB) error {
+	return registerAround(db, p.Name(), startQuery, observeQuery)
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func observeQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		start, ok := value.(time.Time)
+		if !ok {
+			return
+		}
+
+		metrics.DBQueryDuration.WithLabelValues(operation, tableOf(db)).Observe(time.Since(start).Seconds())
+	}
+}
+
+// tableOf returns the table of a statement, raw SQL statements have none
+func tableOf(db *gorm.DB) string {
+	if db.Statement.Table == "" {
+		return "raw"
+	}
+	return db.Statement.Table
+}
+
+// registerAround registers callbacks of a plugin before and after every kind of GORM statement.
+// after is called with the operation, e.g. "query" or "create".
+func registerAround(db *gorm.DB, plugin string, before func(*gorm.DB), after func(operation string) func(*gorm.DB)) error {
+	callbacks := db.Callback()
+	name := func(when, operation string) string {
+		return plugin + ":" + when + "_" + operation
+	}
+
+	return errors.Join(
+		callbacks.Create().Before("gorm:create").Register(name("before", "create"), before),
+		callbacks.Create().After("gorm:create").Register(name("after", "create"), after("create")),
+		callbacks.Query().Before("gorm:query").Register(name("before", "query"), before),
+		callbacks.Query().After("gorm:query").Register(name("after", "query"), after("query")),
+		callbacks.Update().Before("gorm:update").Register(name("before", "update"), before),
+		callbacks.Update().After("gorm:update").Register(name("after", "update"), after("update")),
+		callbacks.Delete().Before("gorm:delete").Register(name("before", "delete"), before),
+		callbacks.Delete().After("gorm:delete").Register(name("after", "delete"), after("delete")),
+		callbacks.Row().Before("gorm:row").Register(name("before", "row"), before),
+		callbacks.Row().After("gorm:row").Register(name("after", "row"), after("row")),
+		callbacks.Raw().Before("gorm:raw").Register(name("before", "raw"), before),
+		callbacks.Raw().After("gorm:raw").Register(name("after", "raw"), after("raw")),
+	)
+}
@@ -1,28 +1,386 @@
 package db
 
 import (
+	"cmp"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/gorm"
-	"tasius.my.id/todolistapi/internal/domain/entities"
 )
 
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// MigrationsDir is where new migrations are created, relative to the repository root
+const MigrationsDir = "internal/infrastructure/db/migrations"
+
+// migrationLockID is the advisory lock held while migrating, so only one instance migrates at a time
+const migrationLockID = 7263548196
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned schema change, Down is empty when it can't be rolled back
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration with the time it was applied, nil while it is pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+	// Unknown is set for migrations applied to the database that this binary doesn't contain
+	Unknown bool
+}
+
+// Migrator applies the migrations embedded in the binary and records them in schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations
+func NewMigrator(gormDB *gorm.DB) (*Migrator, error) {
+	sqlDB, err := gormDB.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations, err := LoadMigrations(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         sqlDB,
+		migrations: migrations,
+	}, nil
+}
+
+// Migrate applies all pending migrations
 func Migrate(db *gorm.DB) error {
-	// Enable UUID extension
-	err := db.Exec("CREATE EXTENSION IF NOT EXISTS \"uuid-ossp\"").Error
+	migrator, err := NewMigrator(db)
 	if err != nil {
-		return fmt.Errorf("failed to create uuid extension: %w", err)
+		return err
 	}
 
-	// Drop existing tables if they exist
-	err = db.Migrator().DropTable(&entities.User{})
+	_, err = migrator.Up(context.Background(), false)
+	return err
+}
+
+// LoadMigrations reads the <version>_<name>.up.sql and <version>_<name>.down.sql files, sorted by version
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
 	if err != nil {
-		return fmt.Errorf("failed to drop users table: %w", err)
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, path := range paths {
+		match := migrationFilePattern.FindStringSubmatch(path)
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s, expected <version>_<name>.up.sql or .down.sql", path)
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", path, err)
+		}
+
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+// Up applies the pending migrations in order, returning the migrations that were applied. With dryRun
+// nothing is changed and the migrations that would be applied are returned.
+func (m *Migrator) Up(ctx context.Context, dryRun bool) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, dryRun, func(conn *sql.Conn) error {
+		appliedAt, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := appliedAt[migration.Version]; ok {
+				continue
+			}
+
+			if !dryRun {
+				err := inTransaction(ctx, conn, migration.Up,
+					`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, migration.Version, migration.Name)
+				if err != nil {
+					return fmt.Errorf("migration %d_%s failed: %w", migration.Version, migration.Name, err)
+				}
+			}
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down rolls back the last steps applied migrations, newest first. With dryRun nothing is changed
+// and the migrations that would be rolled back are returned.
+func (m *Migrator) Down(ctx context.Context, steps int, dryRun bool) ([]Migration, error) {
+	if steps < 1 {
+		return nil, errors.New("number of migrations to roll back must be positive")
+	}
+
+	var rolledBack []Migration
+	err := m.withLock(ctx, dryRun, func(conn *sql.Conn) error {
+		appliedAt, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int64, 0, len(appliedAt))
+		for version := range appliedAt {
+			versions = append(versions, version)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+		if len(versions) > steps {
+			versions = versions[:steps]
+		}
+
+		for _, version := range versions {
+			migration, ok := m.find(version)
+			if !ok {
+				return fmt.Errorf("migration %d is applied but not part of this build, it can't be rolled back", version)
+			}
+			if strings.TrimSpace(migration.Down) == "" {
+				return fmt.Errorf("migration %d_%s has no down migration", migration.Version, migration.Name)
+			}
+
+			if !dryRun {
+				err := inTransaction(ctx, conn, migration.Down, `DELETE FROM schema_migrations WHERE version = $1`, migration.Version)
+				if err != nil {
+					return fmt.Errorf("rolling back migration %d_%s failed: %w", migration.Version, migration.Name, err)
+				}
+			}
+			rolledBack = append(rolledBack, migration)
+		}
+		return nil
+	})
+
+	return rolledBack, err
+}
+
+// Status lists every migration with the time it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	appliedAt, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if applied, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &applied.at
+			delete(appliedAt, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for version, applied := range appliedAt {
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: version, Name: applied.name},
+			AppliedAt: &applied.at,
+			Unknown:   true,
+		})
+	}
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return statuses, nil
+}
+
+func (m *Migrator) find(version int64) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock runs fn on a single connection holding the migration advisory lock. Other instances wait
+// for the lock and then find nothing left to do. Dry runs only read and don't take the lock.
+func (m *Migrator) withLock(ctx context.Context, dryRun bool, fn func(conn *sql.Conn) error) error {
+	// Advisory locks belong to a session, so everything runs on the same connection
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if dryRun {
+		return fn(conn)
+	}
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version bigint PRIMARY KEY,
+		name text NOT NULL,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+type appliedMigration struct {
+	name string
+	at   time.Time
+}
+
+// appliedMigrations reads schema_migrations, a database that was never migrated has no migrations applied
+func appliedMigrations(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	var exists bool
+	if err := conn.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check for schema_migrations table: %w", err)
+	}
+
+	applied := make(map[int64]appliedMigration)
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := conn.QueryContext(ctx, `SELECT version, name, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int64
+			migration appliedMigration
+		)
+		if err := rows.Scan(&version, &migration.name, &migration.at); err != nil {
+			return nil, err
+		}
+		applied[version] = migration
+	}
+
+	return applied, rows.Err()
+}
+
+// inTransaction runs a migration script and the statement recording it atomically
+func inTransaction(ctx context.Context, conn *sql.Conn, script string, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Without arguments the script is sent as a simple query, which may contain several statements
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// CreateMigration writes empty up and down files for a new migration to dir, numbered after the
+// existing migrations, and returns their paths
+func CreateMigration(dir, name string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_")
+	if name == "" {
+		return "", "", errors.New("migration name must contain letters or digits")
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+
+	version := int64(1)
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%04d_%s", version, name))
+	upPath, downPath := base+".up.sql", base+".down.sql"
+
+	header := fmt.Sprintf("-- Migration %04d_%s", version, name)
+	if err := os.WriteFile(upPath, []byte(header+" (up)\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(downPath, []byte(header+" (down)\n"), 0o644); err != nil {
+		return "", "", err
 	}
 
-	// Create tables with new schema
-	return db.AutoMigrate(
-		&entities.User{},
-		&entities.Todo{},
-	)
+	return upPath, downPath, nil
 }
@@ -0,0 +1,297 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "first", Up: "CREATE TABLE first ()", Down: "DROP TABLE first"},
+	{Version: 2, Name: "second", Up: "CREATE TABLE second ()", Down: "DROP TABLE second"},
+	{Version: 3, Name: "third", Up: "CREATE TABLE third ()", Down: "DROP TABLE third"},
+}
+
+// newTestMigrator returns a migrator of migrations on a mocked database, checked when the test ends
+func newTestMigrator(t *testing.T, migrations []Migration) (*Migrator, sqlmock.Sqlmock) {
+	t.Helper()
+
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sqlDB.Close()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return &Migrator{db: sqlDB, migrations: migrations}, mock
+}
+
+func expectLock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS schema_migrations`).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+func expectUnlock(mock sqlmock.Sqlmock) {
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectApplied reads schema_migrations with the given versions applied, nil if the table doesn't exist
+func expectApplied(mock sqlmock.Sqlmock, versions []int64) {
+	mock.ExpectQuery(`SELECT to_regclass\('schema_migrations'\) IS NOT NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(versions != nil))
+	if versions == nil {
+		return
+	}
+
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, version := range versions {
+		rows.AddRow(version, testMigrations[version-1].Name, time.Now())
+	}
+	mock.ExpectQuery(`SELECT version, name, applied_at FROM schema_migrations`).WillReturnRows(rows)
+}
+
+func versionsOf(migrations []Migration) []int64 {
+	versions := make([]int64, 0, len(migrations))
+	for _, migration := range migrations {
+		versions = append(versions, migration.Version)
+	}
+	return versions
+}
+
+func TestMigratorUp(t *testing.T) {
+	migrator, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock)
+	expectApplied(mock, []int64{1})
+	for _, migration := range testMigrations[1:] {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(migration.Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`INSERT INTO schema_migrations \(version, name\) VALUES \(\$1, \$2\)`).
+			WithArgs(migration.Version, migration.Name).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	expectUnlock(mock)
+
+	applied, err := migrator.Up(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if got := versionsOf(applied); !slices.Equal(got, []int64{2, 3}) {
+		t.Errorf("Up() applied %v, want [2 3]", got)
+	}
+}
+
+func TestMigratorUpStopsAtFailure(t *testing.T) {
+	migrator, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock)
+	expectApplied(mock, nil)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(testMigrations[0].Up)).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO schema_migrations`).WithArgs(int64(1), "first").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	// The failed migration isn't recorded and the ones after it don't run
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(testMigrations[1].Up)).WillReturnError(errors.New("syntax error"))
+	mock.ExpectRollback()
+	expectUnlock(mock)
+
+	applied, err := migrator.Up(context.Background(), false)
+	if err == nil || !strings.Contains(err.Error(), "migration 2_second failed: syntax error") {
+		t.Errorf("Up() error = %v, want migration 2 to fail", err)
+	}
+	if got := versionsOf(applied); !slices.Equal(got, []int64{1}) {
+		t.Errorf("Up() applied %v, want [1]", got)
+	}
+}
+
+func TestMigratorUpDryRun(t *testing.T) {
+	migrator, mock := newTestMigrator(t, testMigrations)
+
+	// Dry runs neither lock nor create schema_migrations, a new database has nothing applied
+	expectApplied(mock, nil)
+
+	pending, err := migrator.Up(context.Background(), true)
+	if err != nil {
+		t.Fatalf("Up() error = %v", err)
+	}
+	if got := versionsOf(pending); !slices.Equal(got, []int64{1, 2, 3}) {
+		t.Errorf("Up() dry run returned %v, want [1 2 3]", got)
+	}
+}
+
+func TestMigratorDown(t *testing.T) {
+	migrator, mock := newTestMigrator(t, testMigrations)
+
+	expectLock(mock)
+	expectApplied(mock, []int64{1, 2, 3})
+	for _, migration := range []Migration{testMigrations[2], testMigrations[1]} {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(migration.Down)).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM schema_migrations WHERE version = \$1`).
+			WithArgs(migration.Version).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+	}
+	expectUnlock(mock)
+
+	rolledBack, err := migrator.Down(context.Background(), 2, false)
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if got := versionsOf(rolledBack); !slices.Equal(got, []int64{3, 2}) {
+		t.Errorf("Down() rolled back %v, want [3 2]", got)
+	}
+}
+
+func TestMigratorDownDryRun(t *testing.T) {
+	migrator, mock := newTestMigrator(t, testMigrations)
+
+	expectApplied(mock, []int64{1, 2})
+
+	rolledBack, err := migrator.Down(context.Background(), 5, true)
+	if err != nil {
+		t.Fatalf("Down() error = %v", err)
+	}
+	if got := versionsOf(rolledBack); !slices.Equal(got, []int64{2, 1}) {
+		t.Errorf("Down() dry run returned %v, want [2 1]", got)
+	}
+}
+
+func TestMigratorDownRejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		migrations []Migration
+		wantErr    string
+	}{
+		{
+			name:       "migration without down script",
+			migrations: []Migration{testMigrations[0], {Version: 2, Name: "second", Up: "CREATE TABLE second ()"}},
+			wantErr:    "migration 2_second has no down migration",
+		},
+		{
+			name:       "migration missing from the build",
+			migrations: testMigrations[:1],
+			wantErr:    "migration 2 is applied but not part of this build",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrator, mock := newTestMigrator(t, tt.migrations)
+			expectLock(mock)
+			expectApplied(mock, []int64{1, 2})
+			expectUnlock(mock)
+
+			_, err := migrator.Down(context.Background(), 1, false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Down() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	migrator, _ := newTestMigrator(t, testMigrations)
+	if _, err := migrator.Down(context.Background(), 0, false); err == nil {
+		t.Error("Down() of 0 steps succeeded")
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int64
+		wantErr      string
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"0010_tenth.up.sql":    {Data: []byte("SELECT 10")},
+				"0002_second.up.sql":   {Data: []byte("SELECT 2")},
+				"0002_second.down.sql": {Data: []byte("SELECT -2")},
+			},
+			wantVersions: []int64{2, 10},
+		},
+		{
+			name:    "invalid file name",
+			files:   fstest.MapFS{"0001-first.sql": {Data: []byte("SELECT 1")}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name: "version used twice",
+			files: fstest.MapFS{
+				"0001_first.up.sql": {Data: []byte("SELECT 1")},
+				"0001_other.up.sql": {Data: []byte("SELECT 1")},
+			},
+			wantErr: "migration version 1 is used by",
+		},
+		{
+			name:    "down without up",
+			files:   fstest.MapFS{"0001_first.down.sql": {Data: []byte("SELECT 1")}},
+			wantErr: "migration 1_first has no up migration",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := LoadMigrations(tt.files)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("LoadMigrations() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMigrations() error = %v", err)
+			}
+			if got := versionsOf(migrations); !slices.Equal(got, tt.wantVersions) {
+				t.Errorf("LoadMigrations() versions = %v, want %v", got, tt.wantVersions)
+			}
+		})
+	}
+}
+
+// TestInitialSchemaUpgradesAutoMigrateDatabases checks that the first migration adds the columns older
+// versions of AutoMigrate didn't create before anything uses them, CREATE TABLE IF NOT EXISTS skips
+// the tables those versions created
+func TestInitialSchemaUpgradesAutoMigrateDatabases(t *testing.T) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := LoadMigrations(files)
+	if err != nil {
+		t.Fatalf("LoadMigrations() error = %v", err)
+	}
+	initial := migrations[0].Up
+
+	// Columns added to tables after AutoMigrate first created them
+	added := map[string][]string{
+		"users":    {"role", "totp_secret", "two_factor_enabled"},
+		"todos":    {"organization_id"},
+		"api_keys": {"organization_id"},
+	}
+	for table, columns := range added {
+		for _, column := range columns {
+			alter := strings.Index(initial, `ALTER TABLE "`+table+`" ADD COLUMN IF NOT EXISTS "`+column+`"`)
+			if alter < 0 {
+				t.Errorf("%s.%s isn't added to existing tables", table, column)
+				continue
+			}
+			if index := strings.Index(initial, `ON "`+table+`" ("`+column+`")`); index >= 0 && index < alter {
+				t.Errorf("%s.%s is indexed before it is added", table, column)
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS "organization_invites";
+DROP TABLE IF EXISTS "organization_members";
+DROP TABLE IF EXISTS "organizations";
+DROP TABLE IF EXISTS "user_identities";
+DROP TABLE IF EXISTS "api_keys";
+DROP TABLE IF EXISTS "recovery_codes";
+DROP TABLE IF EXISTS "todos";
+DROP TABLE IF EXISTS "users";
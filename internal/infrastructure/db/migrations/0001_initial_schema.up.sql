@@ -0,0 +1,130 @@
+-- Schema as created by the former AutoMigrate, written with IF NOT EXISTS so databases migrated
+-- by it adopt this migration. Depending on the version that migrated them, those databases lack
+-- columns added to a table after it was created, they are added before the indexes that use them.
+CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
+
+CREATE TABLE IF NOT EXISTS "users" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "email" text NOT NULL,
+    "password" text NOT NULL,
+    "name" text NOT NULL,
+    "role" text NOT NULL DEFAULT 'user',
+    "is_active" boolean DEFAULT true,
+    "totp_secret" text,
+    "two_factor_enabled" boolean DEFAULT false,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "uni_users_email" UNIQUE ("email")
+);
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "role" text NOT NULL DEFAULT 'user';
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "totp_secret" text;
+ALTER TABLE "users" ADD COLUMN IF NOT EXISTS "two_factor_enabled" boolean DEFAULT false;
+CREATE INDEX IF NOT EXISTS "idx_users_deleted_at" ON "users" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_users_role" ON "users" ("role");
+
+CREATE TABLE IF NOT EXISTS "todos" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "title" text NOT NULL,
+    "description" text NOT NULL,
+    "user_id" uuid NOT NULL,
+    "organization_id" uuid,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_todos_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+ALTER TABLE "todos" ADD COLUMN IF NOT EXISTS "organization_id" uuid;
+CREATE INDEX IF NOT EXISTS "idx_todos_deleted_at" ON "todos" ("deleted_at");
+CREATE INDEX IF NOT EXISTS "idx_todos_organization_id" ON "todos" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_todos_user_id" ON "todos" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "recovery_codes" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "code_hash" text NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_recovery_codes_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_recovery_codes_code_hash" ON "recovery_codes" ("code_hash");
+CREATE INDEX IF NOT EXISTS "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "api_keys" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "organization_id" uuid,
+    "name" text NOT NULL,
+    "prefix" text NOT NULL,
+    "key_hash" text NOT NULL,
+    "scopes" text NOT NULL,
+    "expires_at" timestamptz,
+    "last_used_at" timestamptz,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_api_keys_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+ALTER TABLE "api_keys" ADD COLUMN IF NOT EXISTS "organization_id" uuid;
+CREATE INDEX IF NOT EXISTS "idx_api_keys_deleted_at" ON "api_keys" ("deleted_at");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_organization_id" ON "api_keys" ("organization_id");
+CREATE INDEX IF NOT EXISTS "idx_api_keys_user_id" ON "api_keys" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "user_identities" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "provider" text NOT NULL,
+    "subject" text NOT NULL,
+    "email" text,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_identities_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_identities_provider_subject" ON "user_identities" ("provider", "subject");
+CREATE INDEX IF NOT EXISTS "idx_user_identities_user_id" ON "user_identities" ("user_id");
+
+CREATE TABLE IF NOT EXISTS "organizations" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "name" text NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    "deleted_at" timestamptz,
+    PRIMARY KEY ("id")
+);
+CREATE INDEX IF NOT EXISTS "idx_organizations_deleted_at" ON "organizations" ("deleted_at");
+
+CREATE TABLE IF NOT EXISTS "organization_members" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "organization_id" uuid NOT NULL,
+    "user_id" uuid NOT NULL,
+    "role" text NOT NULL DEFAULT 'member',
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organization_members_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE CASCADE ON UPDATE CASCADE,
+    CONSTRAINT "fk_organization_members_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_organization_members_user_id" ON "organization_members" ("user_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_organization_members_org_user" ON "organization_members" ("organization_id", "user_id");
+
+CREATE TABLE IF NOT EXISTS "organization_invites" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "organization_id" uuid NOT NULL,
+    "email" text NOT NULL,
+    "role" text NOT NULL,
+    "token_hash" text NOT NULL,
+    "invited_by_id" uuid NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "accepted_at" timestamptz,
+    "created_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_organization_invites_organization" FOREIGN KEY ("organization_id") REFERENCES "organizations"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_organization_invites_token_hash" ON "organization_invites" ("token_hash");
+CREATE INDEX IF NOT EXISTS "idx_organization_invites_organization_id" ON "organization_invites" ("organization_id");
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS "user_data_keys" (
+    "id" uuid DEFAULT uuid_generate_v4(),
+    "user_id" uuid NOT NULL,
+    "master_key_id" text NOT NULL,
+    "wrapped_key" bytea NOT NULL,
+    "created_at" timestamptz,
+    "updated_at" timestamptz,
+    PRIMARY KEY ("id"),
+    CONSTRAINT "fk_user_data_keys_user" FOREIGN KEY ("user_id") REFERENCES "users"("id") ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS "idx_user_data_keys_master_key_id" ON "user_data_keys" ("master_key_id");
+CREATE UNIQUE INDEX IF NOT EXISTS "idx_user_data_keys_user_id" ON "user_data_keys" ("user_id");
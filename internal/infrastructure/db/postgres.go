@@ -2,7 +2,7 @@ package db
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -22,7 +22,7 @@ func NewPostgresConnection(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
-	log.Println("Database connected and migrated successfully")
+	slog.Info("Database connected and migrated")
 	return db, nil
 }
 
@@ -39,7 +39,7 @@ func ConnectWithoutMigration(cfg *config.Config) (*gorm.DB, error) {
 	)
 
 	var logLevel logger.LogLevel
-	if cfg.AppEnv == "development" {
+	if cfg.AppEnv == config.EnvDevelopment {
 		logLevel = logger.Info
 	} else {
 		logLevel = logger.Error
@@ -52,7 +52,7 @@ func ConnectWithoutMigration(cfg *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("Database connected successfully")
+	slog.Info("Database connected")
 	return db, nil
 }
 
@@ -3,7 +3,7 @@ package db
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -31,6 +31,6 @@ func NewRedisConnection(cfg *config.Config) (*redis.Client, error) {
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	log.Println("Redis connected successfully")
+	slog.Info("Redis connected")
 	return client, nil
 }
\ No newline at end of file
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/utils/tracing"
+)
+
+const querySpanKey = "tracing:query_span"
+
+// TracingPlugin wraps every GORM statement in a span, as a child of the span in the statement's context.
+// Statements outside of a trace aren't traced.
+type TracingPlugin struct{}
+
+// Name implements gorm.Plugin.
+func (TracingPlugin) Name() string {
+	return "tracing"
+}
+
+// Initialize implements gorm.Plugin.
+func (p TracingPlugin) Initialize(db *gorm.DB) error {
+	return registerAround(db, p.Name(), startQuerySpan, endQuerySpan)
+}
+
+func startQuerySpan(db *gorm.DB) {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, span := tracing.StartChild(ctx, "gorm", trace.WithSpanKind(trace.SpanKindClient))
+	db.Statement.Context = ctx
+	db.InstanceSet(querySpanKey, span)
+}
+
+func endQuerySpan(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(querySpanKey)
+		if !ok {
+			return
+		}
+		span, ok := value.(trace.Span)
+		if !ok {
+			return
+		}
+		if !span.IsRecording() {
+			span.End()
+			return
+		}
+
+		table := tableOf(db)
+		span.SetName(operation + " " + table)
+		// The statement holds placeholders, the values of the query aren't recorded
+		span.SetAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBCollectionName(table),
+			semconv.DBQueryText(db.Statement.SQL.String()),
+			semconv.DBResponseReturnedRows(int(db.Statement.RowsAffected)),
+		)
+		tracing.End(span, db.Error, gorm.ErrRecordNotFound)
+	}
+}
+
+// RedisTracingHook wraps every Redis command in a span, as a child of the span in the command's
+// context. Commands outside of a trace aren't traced.
+type RedisTracingHook struct{}
+
+// DialHook implements redis.Hook.
+func (RedisTracingHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook.
+func (RedisTracingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := tracing.StartChild(ctx, cmd.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName(cmd.Name())),
+		)
+		err := next(ctx, cmd)
+		// A missing key is an expected outcome, e.g. a cache miss
+		tracing.End(span, err, redis.Nil)
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook.
+func (RedisTracingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := tracing.StartChild(ctx, "pipeline",
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(semconv.DBSystemNameRedis, semconv.DBOperationName("pipeline")),
+		)
+		err := next(ctx, cmds)
+		tracing.End(span, err, redis.Nil)
+		return err
+	}
+}
@@ -0,0 +1,233 @@
+// Package fieldcrypto encrypts selected columns at rest with envelope encryption: every user has a
+// random data key, stored wrapped by a master key from the configuration. Rotating the master key
+// only rewraps the data keys, the encrypted columns stay untouched.
+package fieldcrypto
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+)
+
+// prefix marks encrypted values, values without it are plaintext written before encryption was enabled
+const prefix = "enc:v1:"
+
+const (
+	masterKeySize = 32
+	// maxCachedKeys bounds the unwrapped data keys kept in memory
+	maxCachedKeys = 10000
+)
+
+// Encryptor encrypts and decrypts column values with the data key of their owner. It is safe for concurrent use.
+type Encryptor struct {
+	masterKeyID string
+	masterKeys  map[string][]byte
+	dataKeys    repositories.DataKeyRepository
+
+	mu    sync.Mutex
+	cache map[string][]byte
+}
+
+// NewEncryptor returns nil when no master key is configured, callers then store values as they are
+func NewEncryptor(cfg *config.FieldEncryptionConfig, dataKeys repositories.DataKeyRepository) (*Encryptor, error) {
+	if cfg.MasterKeyID == "" {
+		return nil, nil
+	}
+
+	masterKeys := make(map[string][]byte, len(cfg.MasterKeys))
+	for id, encoded := range cfg.MasterKeys {
+		if id == "" {
+			return nil, errors.New("master keys must be formatted as <id>:<base64 key>")
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != masterKeySize {
+			return nil, fmt.Errorf("master key %q must be %d base64 encoded bytes", id, masterKeySize)
+		}
+		masterKeys[id] = key
+	}
+	if _, ok := masterKeys[cfg.MasterKeyID]; !ok {
+		return nil, fmt.Errorf("master key %q is not configured", cfg.MasterKeyID)
+	}
+
+	return &Encryptor{
+		masterKeyID: cfg.MasterKeyID,
+		masterKeys:  masterKeys,
+		dataKeys:    dataKeys,
+		cache:       make(map[string][]byte),
+	}, nil
+}
+
+// IsEncrypted reports whether a stored value is encrypted
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt encrypts a value of userID. The additional data binds the ciphertext to its row and column,
+// so it can't be copied to another field.
+func (e *Encryptor) Encrypt(ctx context.Context, userID, additionalData, plaintext string) (string, error) {
+	dataKey, err := e.dataKey(ctx, userID, true)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := crypto.EncryptAES(dataKey, []byte(plaintext), []byte(additionalData))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt field: %w", err)
+	}
+
+	return prefix + base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypts a value written by Encrypt, plaintext values are returned as they are
+func (e *Encryptor) Decrypt(ctx context.Context, userID, additionalData, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, prefix))
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted field: %w", err)
+	}
+
+	dataKey, err := e.dataKey(ctx, userID, false)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := crypto.DecryptAES(dataKey, ciphertext, []byte(additionalData))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt field: %w", err)
+	}
+
+	return string(plaintext), nil
+}
+
+// RewrapDataKeys wraps every data key that still uses a previous master key with the current one,
+// returning how many keys were rewrapped. Previous master keys can be removed once it returns.
+func (e *Encryptor) RewrapDataKeys(ctx context.Context, batchSize int) (int, error) {
+	rewrapped := 0
+	for {
+		keys, err := e.dataKeys.ListNotWrappedWith(ctx, e.masterKeyID, batchSize)
+		if err != nil {
+			return rewrapped, err
+		}
+		if len(keys) == 0 {
+			return rewrapped, nil
+		}
+
+		for _, key := range keys {
+			dataKey, err := e.unwrap(&key)
+			if err != nil {
+				return rewrapped, err
+			}
+
+			wrappedKey, err := e.wrap(key.UserID, dataKey)
+			if err != nil {
+				return rewrapped, err
+			}
+
+			if err := e.dataKeys.UpdateWrappedKey(ctx, key.ID, e.masterKeyID, wrappedKey); err != nil {
+				return rewrapped, err
+			}
+			rewrapped++
+		}
+	}
+}
+
+// dataKey returns the unwrapped data key of a user, creating it on first use when create is set
+func (e *Encryptor) dataKey(ctx context.Context, userID string, create bool) ([]byte, error) {
+	e.mu.Lock()
+	dataKey, ok := e.cache[userID]
+	e.mu.Unlock()
+	if ok {
+		return dataKey, nil
+	}
+
+	stored, err := e.dataKeys.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if stored == nil {
+		if !create {
+			return nil, fmt.Errorf("no data key for user %s", userID)
+		}
+		if stored, err = e.createDataKey(ctx, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	dataKey, err = e.unwrap(stored)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if len(e.cache) >= maxCachedKeys {
+		clear(e.cache)
+	}
+	e.cache[userID] = dataKey
+	e.mu.Unlock()
+
+	return dataKey, nil
+}
+
+func (e *Encryptor) createDataKey(ctx context.Context, userID string) (*entities.DataKey, error) {
+	dataKey, err := crypto.GenerateAESKey()
+	if err != nil {
+		return nil, err
+	}
+
+	wrappedKey, err := e.wrap(userID, dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.dataKeys.Create(ctx, &entities.DataKey{UserID: userID, MasterKeyID: e.masterKeyID, WrappedKey: wrappedKey}); err != nil {
+		return nil, err
+	}
+
+	// Read the key back, a concurrent request may have stored its key first
+	stored, err := e.dataKeys.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, fmt.Errorf("data key for user %s was not stored", userID)
+	}
+	return stored, nil
+}
+
+func (e *Encryptor) wrap(userID string, dataKey []byte) ([]byte, error) {
+	wrappedKey, err := crypto.EncryptAES(e.masterKeys[e.masterKeyID], dataKey, wrapAdditionalData(userID, e.masterKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	return wrappedKey, nil
+}
+
+func (e *Encryptor) unwrap(key *entities.DataKey) ([]byte, error) {
+	masterKey, ok := e.masterKeys[key.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("data key of user %s is wrapped with unknown master key %q", key.UserID, key.MasterKeyID)
+	}
+
+	dataKey, err := crypto.DecryptAES(masterKey, key.WrappedKey, wrapAdditionalData(key.UserID, key.MasterKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key of user %s: %w", key.UserID, err)
+	}
+	return dataKey, nil
+}
+
+// wrapAdditionalData binds a wrapped data key to its user, so keys can't be swapped between users
+func wrapAdditionalData(userID, masterKeyID string) []byte {
+	return []byte("user_data_keys\n" + userID + "\n" + masterKeyID)
+}
@@ -0,0 +1,26 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+
+	"tasius.my.id/todolistapi/internal/domain/services"
+)
+
+type logMailer struct {
+	logger *slog.Logger
+}
+
+// NewLogMailer creates a mailer that prints messages to the log, for local development
+func NewLogMailer(logger *slog.Logger) services.Mailer {
+	return &logMailer{
+		logger: logger,
+	}
+}
+
+// Send implements services.Mailer.
+func (m *logMailer) Send(ctx context.Context, mail *services.Mail) error {
+	// Tokens in the links of the body are redacted by the logger, like any sensitive query parameter
+	m.logger.InfoContext(ctx, "Mail not sent, no SMTP host configured", "to", mail.To, "subject", mail.Subject, "body", mail.Body)
+	return nil
+}
@@ -0,0 +1,17 @@
+package mailer
+
+import (
+	"log/slog"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/services"
+)
+
+// NewMailer returns an SMTP mailer when an SMTP host is configured,
+// otherwise a mailer that only writes messages to the log
+func NewMailer(cfg *config.MailConfig, logger *slog.Logger) services.Mailer {
+	if cfg.Host == "" {
+		return NewLogMailer(logger)
+	}
+	return NewSMTPMailer(cfg)
+}
@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/services"
+)
+
+type smtpMailer struct {
+	config *config.MailConfig
+}
+
+func NewSMTPMailer(cfg *config.MailConfig) services.Mailer {
+	return &smtpMailer{
+		config: cfg,
+	}
+}
+
+// Send implements services.Mailer.
+func (m *smtpMailer) Send(ctx context.Context, mail *services.Mail) error {
+	addr := fmt.Sprintf("%s:%d", m.config.Host, m.config.Port)
+
+	var auth smtp.Auth
+	if m.config.Username != "" {
+		auth = smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+	}
+
+	if err := smtp.SendMail(addr, auth, m.config.From, []string{mail.To}, m.buildMessage(mail)); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	return nil
+}
+
+func (m *smtpMailer) buildMessage(mail *services.Mail) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + m.config.From + "\r\n")
+	sb.WriteString("To: " + mail.To + "\r\n")
+	sb.WriteString("Subject: " + mail.Subject + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(mail.Body)
+	return []byte(sb.String())
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) repositories.APIKeyRepository {
+	return &apiKeyRepository{
+		db: db,
+	}
+}
+
+// Create implements repositories.APIKeyRepository.
+func (r *apiKeyRepository) Create(ctx context.Context, apiKey *entities.APIKey) error {
+	if err := r.db.WithContext(ctx).Omit("User").Create(apiKey).Error; err != nil {
+		return fmt.Errorf("failed to create api key: %w", err)
+	}
+	return nil
+}
+
+// GetByHash implements repositories.APIKeyRepository.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*entities.APIKey, error) {
+	var apiKey entities.APIKey
+	if err := r.db.WithContext(ctx).Preload("User").Where("key_hash = ?", keyHash).First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return &apiKey, nil
+}
+
+// GetAllByUserID implements repositories.APIKeyRepository.
+func (r *apiKeyRepository) GetAllByUserID(ctx context.Context, userID string) ([]entities.APIKey, error) {
+	var apiKeys []entities.APIKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&apiKeys).Error; err != nil {
+		return nil, err
+	}
+	return apiKeys, nil
+}
+
+// Delete implements repositories.APIKeyRepository.
+func (r *apiKeyRepository) Delete(ctx context.Context, id string, userID string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return fmt.Errorf(errInvalidIDFormat, err)
+	}
+
+	result := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Delete(&entities.APIKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete api key: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// UpdateLastUsed implements repositories.APIKeyRepository.
+func (r *apiKeyRepository) UpdateLastUsed(ctx context.Context, id string, lastUsedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.APIKey{}).Where("id = ?", id).UpdateColumn("last_used_at", lastUsedAt).Error
+}
+
+// CountByUserID implements repositories.APIKeyRepository.
+func (r *apiKeyRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&entities.APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count api keys: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+)
+
+type dataKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewDataKeyRepository(db *gorm.DB) repositories.DataKeyRepository {
+	return &dataKeyRepository{
+		db: db,
+	}
+}
+
+// GetByUserID implements repositories.DataKeyRepository.
+func (r *dataKeyRepository) GetByUserID(ctx context.Context, userID string) (*entities.DataKey, error) {
+	var key entities.DataKey
+	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get data key: %w", err)
+	}
+	return &key, nil
+}
+
+// Create implements repositories.DataKeyRepository.
+func (r *dataKeyRepository) Create(ctx context.Context, key *entities.DataKey) error {
+	// Two requests may create the first todo of a user at once, the first key wins
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(key).Error
+	if err != nil {
+		return fmt.Errorf("failed to create data key: %w", err)
+	}
+	return nil
+}
+
+// ListNotWrappedWith implements repositories.DataKeyRepository.
+func (r *dataKeyRepository) ListNotWrappedWith(ctx context.Context, masterKeyID string, limit int) ([]entities.DataKey, error) {
+	var keys []entities.DataKey
+	err := r.db.WithContext(ctx).
+		Where("master_key_id <> ?", masterKeyID).
+		Order("id").
+		Limit(limit).
+		Find(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list data keys: %w", err)
+	}
+	return keys, nil
+}
+
+// UpdateWrappedKey implements repositories.DataKeyRepository.
+func (r *dataKeyRepository) UpdateWrappedKey(ctx context.Context, id string, masterKeyID string, wrappedKey []byte) error {
+	result := r.db.WithContext(ctx).
+		Model(&entities.DataKey{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"master_key_id": masterKeyID, "wrapped_key": wrappedKey})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update data key: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
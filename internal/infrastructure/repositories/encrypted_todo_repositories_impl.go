@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/infrastructure/fieldcrypto"
+)
+
+// encryptedTodoRepository encrypts the title and description of todos with the data key of their owner
+// before they are stored, and decrypts them when they are read
+type encryptedTodoRepository struct {
+	repositories.TodoRepository
+	encryptor *fieldcrypto.Encryptor
+}
+
+// NewEncryptedTodoRepository wraps a TodoRepository with field encryption, todoRepo is returned as is
+// when encryptor is nil
+func NewEncryptedTodoRepository(todoRepo repositories.TodoRepository, encryptor *fieldcrypto.Encryptor) repositories.TodoRepository {
+	if encryptor == nil {
+		return todoRepo
+	}
+	return &encryptedTodoRepository{
+		TodoRepository: todoRepo,
+		encryptor:      encryptor,
+	}
+}
+
+// Create implements repositories.TodoRepository.
+func (r *encryptedTodoRepository) Create(ctx context.Context, todo *entities.Todo) error {
+	if todo == nil {
+		return r.TodoRepository.Create(ctx, todo)
+	}
+
+	// The ID is part of the additional data, so it is assigned before the row is inserted
+	if todo.ID == "" {
+		todo.ID = uuid.NewString()
+	}
+
+	restore, err := encryptTodo(ctx, r.encryptor, todo)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	return r.TodoRepository.Create(ctx, todo)
+}
+
+// Update implements repositories.TodoRepository.
+func (r *encryptedTodoRepository) Update(ctx context.Context, id string, organizationID string, todo *entities.Todo) error {
+	if todo == nil {
+		return r.TodoRepository.Update(ctx, id, organizationID, todo)
+	}
+
+	// Fields are encrypted with the key of the owner, which may not be the user making the change
+	if todo.UserID == "" {
+		existing, err := r.TodoRepository.GetByID(ctx, id, organizationID)
+		if err != nil {
+			return err
+		}
+		if existing == nil {
+			return gorm.ErrRecordNotFound
+		}
+		todo.UserID = existing.UserID
+	}
+	todo.ID = id
+
+	restore, err := encryptTodo(ctx, r.encryptor, todo)
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	return r.TodoRepository.Update(ctx, id, organizationID, todo)
+}
+
+// GetAll implements repositories.TodoRepository.
+func (r *encryptedTodoRepository) GetAll(ctx context.Context, userID string, organizationID string) ([]entities.Todo, error) {
+	todos, err := r.TodoRepository.GetAll(ctx, userID, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range todos {
+		if err := decryptTodo(ctx, r.encryptor, &todos[i]); err != nil {
+			return nil, err
+		}
+	}
+	return todos, nil
+}
+
+// GetByID implements repositories.TodoRepository.
+func (r *encryptedTodoRepository) GetByID(ctx context.Context, id string, organizationID string) (*entities.Todo, error) {
+	todo, err := r.TodoRepository.GetByID(ctx, id, organizationID)
+	if err != nil || todo == nil {
+		return todo, err
+	}
+
+	if err := decryptTodo(ctx, r.encryptor, todo); err != nil {
+		return nil, err
+	}
+	return todo, nil
+}
+
+// encryptedPattern matches values with the prefix of encrypted fields
+const encryptedPattern = "enc:v1:%"
+
+// EncryptTodoFields encrypts the todos still stored in plaintext, including deleted ones, returning how many
+// todos were encrypted. It is used to encrypt existing data after field encryption is enabled.
+func EncryptTodoFields(ctx context.Context, db *gorm.DB, encryptor *fieldcrypto.Encryptor, batchSize int) (int, error) {
+	encrypted := 0
+	lastID := ""
+	for {
+		var todos []entities.Todo
+		query := db.WithContext(ctx).Unscoped().
+			Where("title NOT LIKE ? OR description NOT LIKE ?", encryptedPattern, encryptedPattern).
+			Order("id").
+			Limit(batchSize)
+		if lastID != "" {
+			query = query.Where("id > ?", lastID)
+		}
+		if err := query.Find(&todos).Error; err != nil {
+			return encrypted, fmt.Errorf("failed to list todos: %w", err)
+		}
+		if len(todos) == 0 {
+			return encrypted, nil
+		}
+
+		for i := range todos {
+			todo := &todos[i]
+			lastID = todo.ID
+
+			updates := make(map[string]interface{}, 2)
+			for column, value := range map[string]string{"title": todo.Title, "description": todo.Description} {
+				if fieldcrypto.IsEncrypted(value) {
+					continue
+				}
+				ciphertext, err := encryptor.Encrypt(ctx, todo.UserID, todoAdditionalData(todo.ID, column), value)
+				if err != nil {
+					return encrypted, err
+				}
+				updates[column] = ciphertext
+			}
+
+			// UpdateColumns keeps updated_at, encrypting a todo doesn't change it
+			err := db.WithContext(ctx).Unscoped().Model(&entities.Todo{}).Where("id = ?", todo.ID).
+				UpdateColumns(updates).Error
+			if err != nil {
+				return encrypted, fmt.Errorf("failed to update todo: %w", err)
+			}
+			encrypted++
+		}
+	}
+}
+
+// encryptTodo encrypts the fields of a todo in place, restore puts the plaintext back for the caller
+func encryptTodo(ctx context.Context, encryptor *fieldcrypto.Encryptor, todo *entities.Todo) (restore func(), err error) {
+	title, description := todo.Title, todo.Description
+	restore = func() {
+		todo.Title, todo.Description = title, description
+	}
+
+	if todo.Title, err = encryptor.Encrypt(ctx, todo.UserID, todoAdditionalData(todo.ID, "title"), title); err != nil {
+		restore()
+		return nil, err
+	}
+	if todo.Description, err = encryptor.Encrypt(ctx, todo.UserID, todoAdditionalData(todo.ID, "description"), description); err != nil {
+		restore()
+		return nil, err
+	}
+
+	return restore, nil
+}
+
+func decryptTodo(ctx context.Context, encryptor *fieldcrypto.Encryptor, todo *entities.Todo) (err error) {
+	if todo.Title, err = encryptor.Decrypt(ctx, todo.UserID, todoAdditionalData(todo.ID, "title"), todo.Title); err != nil {
+		return fmt.Errorf("failed to decrypt todo %s: %w", todo.ID, err)
+	}
+	if todo.Description, err = encryptor.Decrypt(ctx, todo.UserID, todoAdditionalData(todo.ID, "description"), todo.Description); err != nil {
+		return fmt.Errorf("failed to decrypt todo %s: %w", todo.ID, err)
+	}
+	return nil
+}
+
+func todoAdditionalData(id, column string) string {
+	return "todos." + column + "\n" + id
+}
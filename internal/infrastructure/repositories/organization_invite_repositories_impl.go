@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+)
+
+const errInviteAlreadyUsed = "invite has already been used"
+
+type organizationInviteRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationInviteRepository(db *gorm.DB) repositories.OrganizationInviteRepository {
+	return &organizationInviteRepository{
+		db: db,
+	}
+}
+
+// Create implements repositories.OrganizationInviteRepository.
+func (r *organizationInviteRepository) Create(ctx context.Context, invite *entities.OrganizationInvite) error {
+	return r.db.WithContext(ctx).Omit("Organization").Create(invite).Error
+}
+
+// GetByTokenHash implements repositories.OrganizationInviteRepository.
+func (r *organizationInviteRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.OrganizationInvite, error) {
+	var invite entities.OrganizationInvite
+	err := r.db.WithContext(ctx).
+		Joins("Organization").
+		Where("organization_invites.token_hash = ?", tokenHash).
+		First(&invite).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// Accept implements repositories.OrganizationInviteRepository.
+func (r *organizationInviteRepository) Accept(ctx context.Context, invite *entities.OrganizationInvite, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Marking the invite first makes sure it can only be accepted once
+		result := tx.Model(&entities.OrganizationInvite{}).
+			Where("id = ? AND accepted_at IS NULL", invite.ID).
+			Update("accepted_at", time.Now())
+		if result.Error != nil {
+			return fmt.Errorf("failed to accept invite: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return errors.New(errInviteAlreadyUsed)
+		}
+
+		member := &entities.OrganizationMember{
+			OrganizationID: invite.OrganizationID,
+			UserID:         userID,
+			Role:           invite.Role,
+		}
+		if err := tx.Omit("Organization", "User").Create(member).Error; err != nil {
+			return fmt.Errorf("failed to add member: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+)
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) repositories.OrganizationRepository {
+	return &organizationRepository{
+		db: db,
+	}
+}
+
+// CreateWithOwner implements repositories.OrganizationRepository.
+func (r *organizationRepository) CreateWithOwner(ctx context.Context, organization *entities.Organization, ownerID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return fmt.Errorf("failed to create organization: %w", err)
+		}
+
+		member := &entities.OrganizationMember{
+			OrganizationID: organization.ID,
+			UserID:         ownerID,
+			Role:           entities.OrgRoleOwner,
+		}
+		if err := tx.Omit("Organization", "User").Create(member).Error; err != nil {
+			return fmt.Errorf("failed to add owner: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetByID implements repositories.OrganizationRepository.
+func (r *organizationRepository) GetByID(ctx context.Context, id string) (*entities.Organization, error) {
+	var organization entities.Organization
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&organization).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &organization, nil
+}
+
+// GetMember implements repositories.OrganizationRepository.
+func (r *organizationRepository) GetMember(ctx context.Context, organizationID string, userID string) (*entities.OrganizationMember, error) {
+	var member entities.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Joins("Organization").
+		Where("organization_members.organization_id = ? AND organization_members.user_id = ?", organizationID, userID).
+		First(&member).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetMembers implements repositories.OrganizationRepository.
+func (r *organizationRepository) GetMembers(ctx context.Context, organizationID string) ([]entities.OrganizationMember, error) {
+	var members []entities.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Joins("User").
+		Where("organization_members.organization_id = ?", organizationID).
+		Order("organization_members.created_at").
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// GetMembershipsByUserID implements repositories.OrganizationRepository.
+func (r *organizationRepository) GetMembershipsByUserID(ctx context.Context, userID string) ([]entities.OrganizationMember, error) {
+	var members []entities.OrganizationMember
+	err := r.db.WithContext(ctx).
+		Joins("Organization").
+		Where("organization_members.user_id = ?", userID).
+		Order("organization_members.created_at").
+		Find(&members).Error
+	if err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+// RemoveMember implements repositories.OrganizationRepository.
+func (r *organizationRepository) RemoveMember(ctx context.Context, organizationID string, userID string) error {
+	result := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Delete(&entities.OrganizationMember{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove member: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// CountMembersByRole implements repositories.OrganizationRepository.
+func (r *organizationRepository) CountMembersByRole(ctx context.Context, organizationID string, role string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&entities.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", organizationID, role).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count members: %w", err)
+	}
+	return count, nil
+}
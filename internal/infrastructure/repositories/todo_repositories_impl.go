@@ -44,7 +44,7 @@ func (t *todoRepository) Create(ctx context.Context, todo *entities.Todo) error
 }
 
 // Delete implements repositories.TodoRepository.
-func (t *todoRepository) Delete(ctx context.Context, id string) error {
+func (t *todoRepository) Delete(ctx context.Context, id string, organizationID string) error {
 	if id == "" {
 		return errors.New(errIDRequired)
 	}
@@ -53,7 +53,7 @@ func (t *todoRepository) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf(errInvalidIDFormat, err)
 	}
 
-	result := t.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.Todo{})
+	result := t.db.WithContext(ctx).Scopes(inOrganization(organizationID)).Where("id = ?", id).Delete(&entities.Todo{})
 	if result.Error != nil {
 		return fmt.Errorf("failed to delete todo: %w", result.Error)
 	}
@@ -65,17 +65,39 @@ func (t *todoRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// DeleteByUserID implements repositories.TodoRepository.
+func (t *todoRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	if userID == "" {
+		return errors.New(errUserIDRequired)
+	}
+
+	if err := t.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.Todo{}).Error; err != nil {
+		return fmt.Errorf("failed to delete todos: %w", err)
+	}
+
+	return nil
+}
+
+// CountByUserID implements repositories.TodoRepository.
+func (t *todoRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	var count int64
+	if err := t.db.WithContext(ctx).Model(&entities.Todo{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count todos: %w", err)
+	}
+	return count, nil
+}
+
 // GetAll implements repositories.TodoRepository.
-func (t *todoRepository) GetAll(ctx context.Context, userID string) ([]entities.Todo, error) {
+func (t *todoRepository) GetAll(ctx context.Context, userID string, organizationID string) ([]entities.Todo, error) {
 	var todos []entities.Todo
-	if err := t.db.WithContext(ctx).Where("user_id = ?", userID).Find(&todos).Error; err != nil {
+	if err := t.db.WithContext(ctx).Scopes(inOrganization(organizationID)).Where("user_id = ?", userID).Find(&todos).Error; err != nil {
 		return nil, err
 	}
 	return todos, nil
 }
 
 // GetByID implements repositories.TodoRepository.
-func (t *todoRepository) GetByID(ctx context.Context, id string) (*entities.Todo, error) {
+func (t *todoRepository) GetByID(ctx context.Context, id string, organizationID string) (*entities.Todo, error) {
 	if id == "" {
 		return nil, errors.New("id is required")
 	}
@@ -85,7 +107,7 @@ func (t *todoRepository) GetByID(ctx context.Context, id string) (*entities.Todo
 	}
 
 	var todo entities.Todo
-	if err := t.db.WithContext(ctx).Where("id = ?", id).First(&todo).Error; err != nil {
+	if err := t.db.WithContext(ctx).Scopes(inOrganization(organizationID)).Where("id = ?", id).First(&todo).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, nil
 		}
@@ -96,7 +118,7 @@ func (t *todoRepository) GetByID(ctx context.Context, id string) (*entities.Todo
 }
 
 // Update implements repositories.TodoRepository.
-func (t *todoRepository) Update(ctx context.Context, id string, todo *entities.Todo) error {
+func (t *todoRepository) Update(ctx context.Context, id string, organizationID string, todo *entities.Todo) error {
 	if id == "" {
 		return errors.New(errIDRequired)
 	}
@@ -109,7 +131,7 @@ func (t *todoRepository) Update(ctx context.Context, id string, todo *entities.T
 		return errors.New(errTodoNil)
 	}
 
-	result := t.db.WithContext(ctx).Model(&entities.Todo{}).Where("id = ?", id).Updates(todo)
+	result := t.db.WithContext(ctx).Model(&entities.Todo{}).Scopes(inOrganization(organizationID)).Where("id = ?", id).Updates(todo)
 	if result.Error != nil {
 		return fmt.Errorf("failed to update todo: %w", result.Error)
 	}
@@ -121,6 +143,16 @@ func (t *todoRepository) Update(ctx context.Context, id string, todo *entities.T
 	return nil
 }
 
+// inOrganization limits a query to the todos of an organization, or to personal todos when organizationID is empty
+func inOrganization(organizationID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if organizationID == "" {
+			return db.Where("organization_id IS NULL")
+		}
+		return db.Where("organization_id = ?", organizationID)
+	}
+}
+
 func NewTodoRepository(db *gorm.DB) repositories.TodoRepository {
 	return &todoRepository{
 		db: db,
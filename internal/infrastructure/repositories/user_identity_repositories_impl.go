@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+)
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) repositories.UserIdentityRepository {
+	return &userIdentityRepository{
+		db: db,
+	}
+}
+
+// Create implements repositories.UserIdentityRepository.
+func (r *userIdentityRepository) Create(ctx context.Context, identity *entities.UserIdentity) error {
+	return r.db.WithContext(ctx).Omit("User").Create(identity).Error
+}
+
+// CreateWithUser implements repositories.UserIdentityRepository.
+func (r *userIdentityRepository) CreateWithUser(ctx context.Context, user *entities.User, identity *entities.UserIdentity) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+
+		identity.UserID = user.ID
+		if err := tx.Omit("User").Create(identity).Error; err != nil {
+			return fmt.Errorf("failed to create identity: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetByProviderSubject implements repositories.UserIdentityRepository.
+func (r *userIdentityRepository) GetByProviderSubject(ctx context.Context, provider string, subject string) (*entities.UserIdentity, error) {
+	var identity entities.UserIdentity
+	if err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &identity, nil
+}
+
+// Delete implements repositories.UserIdentityRepository.
+func (r *userIdentityRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.UserIdentity{}).Error
+}
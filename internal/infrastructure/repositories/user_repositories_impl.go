@@ -37,6 +37,21 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entitie
 	return &user, nil
 }
 
+func (r *userRepository) GetMemberByEmail(ctx context.Context, organizationID string, email string) (*entities.User, error) {
+	var user entities.User
+	err := r.db.WithContext(ctx).
+		Where("email = ?", email).
+		Where("id IN (?)", r.memberIDs(organizationID)).
+		First(&user).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) GetByID(ctx context.Context, id string) (*entities.User, error) {
 	var user entities.User
 	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&user).Error; err != nil {
@@ -62,4 +77,41 @@ func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool,
 		return false, err
 	}
 	return count > 0, nil
-}
\ No newline at end of file
+}
+
+func (r *userRepository) List(ctx context.Context, filter repositories.UserFilter) ([]entities.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&entities.User{})
+
+	if filter.Search != "" {
+		pattern := "%" + filter.Search + "%"
+		query = query.Where("email ILIKE ? OR name ILIKE ?", pattern, pattern)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("is_active = ?", *filter.IsActive)
+	}
+	if filter.OrganizationID != "" {
+		query = query.Where("id IN (?)", r.memberIDs(filter.OrganizationID))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []entities.User
+	if err := query.Order("created_at DESC").Offset(filter.Offset).Limit(filter.Limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// memberIDs is a subquery of the IDs of the members of an organization
+func (r *userRepository) memberIDs(organizationID string) *gorm.DB {
+	return r.db.Model(&entities.OrganizationMember{}).
+		Select("user_id").
+		Where("organization_id = ?", organizationID)
+}
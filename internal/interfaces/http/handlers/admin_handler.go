@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils"
+)
+
+type AdminHandler struct {
+	adminService services.AdminService
+}
+
+func NewAdminHandler(adminService services.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+	}
+}
+
+func (h *AdminHandler) ListUsers(c *fiber.Ctx) error {
+	var req dto.ListUsersRequest
+	if err := c.QueryParser(&req); err != nil {
+		return errInvalidQuery
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	users, err := h.adminService.ListUsers(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Users fetched successfully", users)
+}
+
+func (h *AdminHandler) GetUser(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.adminService.GetUser(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User fetched successfully", user)
+}
+
+func (h *AdminHandler) ActivateUser(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.adminService.SetUserActive(c.UserContext(), principal, id, true)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User activated successfully", user)
+}
+
+func (h *AdminHandler) DeactivateUser(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.adminService.SetUserActive(c.UserContext(), principal, id, false)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User deactivated successfully", user)
+}
+
+func (h *AdminHandler) UpdateUserRole(c *fiber.Ctx) error {
+	var req dto.UpdateRoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	user, err := h.adminService.UpdateUserRole(c.UserContext(), principal, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User role updated successfully", user)
+}
+
+func (h *AdminHandler) ForceLogout(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.ForceLogout(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User logged out from all sessions", nil)
+}
+
+func (h *AdminHandler) ResetUserPassword(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.ResetUserPassword(c.UserContext(), principal, id); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Password reset link sent to the user", nil)
+}
+
+func (h *AdminHandler) UnlockUser(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.adminService.UnlockUser(c.UserContext(), id); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User login unlocked", nil)
+}
+
+func (h *AdminHandler) GetUserStats(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	stats, err := h.adminService.GetUserStats(c.UserContext(), id)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "User stats fetched successfully", stats)
+}
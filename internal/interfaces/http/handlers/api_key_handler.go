@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils"
+)
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req dto.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Create API key
+	response, err := h.apiKeyService.CreateAPIKey(c.UserContext(), principal, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.CreatedResponse(c, "API key created, copy it now as it won't be shown again", response)
+}
+
+func (h *APIKeyHandler) GetAllAPIKeys(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	apiKeys, err := h.apiKeyService.GetAllAPIKeys(c.UserContext(), principal)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "API keys fetched successfully", apiKeys)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Revoke API key
+	if err := h.apiKeyService.RevokeAPIKey(c.UserContext(), principal, id); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "API key revoked successfully", nil)
+}
@@ -4,39 +4,35 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"tasius.my.id/todolistapi/internal/application/dto"
 	"tasius.my.id/todolistapi/internal/domain/services"
-	validators "tasius.my.id/todolistapi/internal/interfaces/validator"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
 	"tasius.my.id/todolistapi/internal/utils"
 )
 
 type AuthHandler struct {
 	authService services.AuthService
-	validator   *validators.AuthValidator
 }
 
-const INVALID_REQUEST_BODY = "Invalid request body"
-
 func NewAuthHandler(authService services.AuthService) *AuthHandler {
 	return &AuthHandler{
 		authService: authService,
-		validator:   validators.NewAuthValidator(),
 	}
 }
 
 func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	var req dto.RegisterRequest
 	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, INVALID_REQUEST_BODY)
+		return errInvalidRequestBody
 	}
 
 	// Validate request
-	if errors := h.validator.ValidateRegister(&req); len(errors) > 0 {
-		return utils.ValidationErrorResponse(c, errors)
+	if err := validate(c, &req); err != nil {
+		return err
 	}
 
 	// Register user
-	response, err := h.authService.Register(c, &req)
+	response, err := h.authService.Register(c.UserContext(), &req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return utils.CreatedResponse(c, "User registered successfully", response)
@@ -45,26 +41,31 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	var req dto.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, INVALID_REQUEST_BODY)
+		return errInvalidRequestBody
 	}
 
 	// Validate request
-	if errors := h.validator.ValidateLogin(&req); len(errors) > 0 {
-		return utils.ValidationErrorResponse(c, errors)
+	if err := validate(c, &req); err != nil {
+		return err
 	}
 
 	// Login user
-	response, err := h.authService.Login(c, &req)
+	response, err := h.authService.Login(c.UserContext(), &req, c.IP())
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Login successful", response)
 }
 
 func (h *AuthHandler) Logout(c *fiber.Ctx) error {
-	if err := h.authService.Logout(c); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.authService.Logout(c.UserContext(), principal); err != nil {
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Successfully logged out", nil)
@@ -73,19 +74,56 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	var req dto.RefreshTokenRequest
 	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, INVALID_REQUEST_BODY)
+		return errInvalidRequestBody
 	}
 
 	// Validate request
-	if errors := h.validator.ValidateRefreshToken(&req); len(errors) > 0 {
-		return utils.ValidationErrorResponse(c, errors)
+	if err := validate(c, &req); err != nil {
+		return err
 	}
 
 	// Refresh token
-	response, err := h.authService.RefreshToken(c, req.RefreshToken)
+	response, err := h.authService.RefreshToken(c.UserContext(), req.RefreshToken)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Token refreshed successfully", response)
-}
\ No newline at end of file
+}
+func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	// Send reset link
+	if err := h.authService.ForgotPassword(c.UserContext(), &req, c.IP()); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "If the email is registered, a password reset link has been sent", nil)
+}
+
+func (h *AuthHandler) ResetPassword(c *fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	// Reset password
+	if err := h.authService.ResetPassword(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Password reset successfully", nil)
+}
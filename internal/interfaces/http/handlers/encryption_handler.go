@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/utils"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+)
+
+type EncryptionHandler struct {
+	keyring *crypto.Keyring
+}
+
+func NewEncryptionHandler(keyring *crypto.Keyring) *EncryptionHandler {
+	return &EncryptionHandler{
+		keyring: keyring,
+	}
+}
+
+// GetPublicKey returns the key clients should encrypt new requests for
+func (h *EncryptionHandler) GetPublicKey(c *fiber.Ctx) error {
+	keyID, privateKey := h.keyring.Current()
+	publicKey, err := crypto.EncodePublicKeyPEM(privateKey.Public())
+	if err != nil {
+		return fmt.Errorf("failed to encode public key: %w", err)
+	}
+
+	// Clients may cache the key, a request for a rotated key tells them to fetch it again
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return utils.SuccessResponse(c, "Public key retrieved successfully", &dto.PublicKeyResponse{
+		KeyID:     keyID,
+		Algorithm: privateKey.Public().Algorithm(),
+		PublicKey: publicKey,
+	})
+}
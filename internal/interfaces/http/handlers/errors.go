@@ -0,0 +1,11 @@
+package handlers
+
+import "tasius.my.id/todolistapi/internal/domain/apperrors"
+
+// Errors of requests rejected before they reach a service
+var (
+	errInvalidRequestBody     = apperrors.Validation("invalid_request_body", "Invalid request body")
+	errInvalidQuery           = apperrors.Validation("invalid_query", "Invalid query parameters")
+	errIdentityProviderDenied = apperrors.Unauthenticated("identity_provider_denied", "Login with identity provider failed")
+	errInvalidMetricsToken    = apperrors.Unauthenticated("invalid_metrics_token", "Invalid metrics token")
+)
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/utils"
+	"tasius.my.id/todolistapi/internal/utils/health"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{
+		checker: checker,
+	}
+}
+
+// Livez reports that the process is running. It doesn't check dependencies, an outage of the
+// database shouldn't get every instance restarted.
+func (h *HealthHandler) Livez(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return utils.SuccessResponse(c, "OK", nil)
+}
+
+// Readyz reports whether the instance should receive traffic, with the status and latency of every
+// dependency. It fails with 503 while a dependency is down, in maintenance mode and during shutdown.
+func (h *HealthHandler) Readyz(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	report := h.checker.Check(c.UserContext())
+	if report.Ready() {
+		return utils.SuccessResponse(c, "Ready", report)
+	}
+
+	return c.Status(fiber.StatusServiceUnavailable).JSON(utils.Response{
+		RequestId: c.Locals("requestid").(string),
+		Success:   false,
+		Message:   "Not ready: " + report.Status,
+		Data:      report,
+	})
+}
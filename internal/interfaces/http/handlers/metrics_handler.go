@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"tasius.my.id/todolistapi/internal/utils/metrics"
+)
+
+type MetricsHandler struct {
+	token   string
+	handler fiber.Handler
+}
+
+// NewMetricsHandler serves the metrics registry, requiring token as a bearer token unless it is empty
+func NewMetricsHandler(token string) *MetricsHandler {
+	return &MetricsHandler{
+		token:   token,
+		handler: adaptor.HTTPHandler(promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})),
+	}
+}
+
+// GetMetrics returns the metrics in the Prometheus text format
+func (h *MetricsHandler) GetMetrics(c *fiber.Ctx) error {
+	if h.token != "" {
+		expected := "Bearer " + h.token
+		if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte(expected)) != 1 {
+			return errInvalidMetricsToken
+		}
+	}
+
+	return h.handler(c)
+}
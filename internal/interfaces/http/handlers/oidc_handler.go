@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/utils"
+)
+
+type OIDCHandler struct {
+	oidcService services.OIDCService
+}
+
+func NewOIDCHandler(oidcService services.OIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		oidcService: oidcService,
+	}
+}
+
+func (h *OIDCHandler) Login(c *fiber.Ctx) error {
+	response, err := h.oidcService.Login(c.UserContext(), c.Params("provider"))
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Continue the login at the identity provider", response)
+}
+
+func (h *OIDCHandler) Callback(c *fiber.Ctx) error {
+	var req dto.OIDCCallbackRequest
+	if err := c.QueryParser(&req); err != nil {
+		return errInvalidQuery
+	}
+
+	// The user denied access or the provider could not authenticate them
+	if req.Error != "" {
+		return errIdentityProviderDenied.WithMessage("Login with identity provider failed: " + req.Error)
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	// Complete the login
+	response, err := h.oidcService.Callback(c.UserContext(), c.Params("provider"), &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Login successful", response)
+}
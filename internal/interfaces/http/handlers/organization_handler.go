@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils"
+)
+
+type OrganizationHandler struct {
+	organizationService services.OrganizationService
+}
+
+func NewOrganizationHandler(organizationService services.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{
+		organizationService: organizationService,
+	}
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *fiber.Ctx) error {
+	var req dto.CreateOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	organization, err := h.organizationService.CreateOrganization(c.UserContext(), principal, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.CreatedResponse(c, "Organization created successfully", organization)
+}
+
+func (h *OrganizationHandler) GetMyOrganizations(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	organizations, err := h.organizationService.GetMyOrganizations(c.UserContext(), principal)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Organizations fetched successfully", organizations)
+}
+
+func (h *OrganizationHandler) GetOrganization(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	organization, err := h.organizationService.GetOrganization(c.UserContext(), principal, id)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Organization fetched successfully", organization)
+}
+
+func (h *OrganizationHandler) GetMembers(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	members, err := h.organizationService.GetMembers(c.UserContext(), principal, id)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Members fetched successfully", members)
+}
+
+func (h *OrganizationHandler) InviteMember(c *fiber.Ctx) error {
+	var req dto.InviteMemberRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	invite, err := h.organizationService.InviteMember(c.UserContext(), principal, id, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.CreatedResponse(c, "Invite sent successfully", invite)
+}
+
+func (h *OrganizationHandler) AcceptInvite(c *fiber.Ctx) error {
+	var req dto.AcceptInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	organization, err := h.organizationService.AcceptInvite(c.UserContext(), principal, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Joined organization successfully", organization)
+}
+
+func (h *OrganizationHandler) LeaveOrganization(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	if err := h.organizationService.LeaveOrganization(c.UserContext(), principal, id); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Left organization successfully", nil)
+}
+
+func (h *OrganizationHandler) RemoveMember(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuidParam(c, "userId")
+	if err != nil {
+		return err
+	}
+
+	if err := h.organizationService.RemoveMember(c.UserContext(), principal, id, userID); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Member removed successfully", nil)
+}
+
+func (h *OrganizationHandler) SwitchOrganization(c *fiber.Ctx) error {
+	var req dto.SwitchOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.organizationService.SwitchOrganization(c.UserContext(), principal, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Switched organization successfully", response)
+}
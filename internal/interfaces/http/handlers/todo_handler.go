@@ -5,7 +5,6 @@ import (
 	"tasius.my.id/todolistapi/internal/application/dto"
 	"tasius.my.id/todolistapi/internal/domain/services"
 	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
-	validators "tasius.my.id/todolistapi/internal/interfaces/validator"
 	"tasius.my.id/todolistapi/internal/utils"
 )
 
@@ -22,25 +21,23 @@ func NewTodoHandler(todoService services.TodoService) *TodoHandler {
 func (h *TodoHandler) CreateTodo(c *fiber.Ctx) error {
 	var req dto.TodoDTO
 	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, INVALID_REQUEST_BODY)
+		return errInvalidRequestBody
 	}
 
-	// Add user id from middleware
-	userID, err := middleware.GetUserIDFromContext(c)
-	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
 	}
-	req.UserID = userID
 
-	// Validate request
-	if errors := validators.ValidateCreateTodo(&req); len(errors) > 0 {
-		return utils.ValidationErrorResponse(c, errors)
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
 	}
 
 	// Create todo
-	response, err := h.todoService.CreateTodo(c, req)
+	response, err := h.todoService.CreateTodo(c.UserContext(), principal, req)
 	if err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
+		return err
 	}
 
 	return utils.CreatedResponse(c, "Todo created successfully", response)
@@ -49,87 +46,81 @@ func (h *TodoHandler) CreateTodo(c *fiber.Ctx) error {
 func (h *TodoHandler) UpdateTodo(c *fiber.Ctx) error {
 	var req dto.UpdateTodoRequest
 	if err := c.BodyParser(&req); err != nil {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, INVALID_REQUEST_BODY)
+		return errInvalidRequestBody
 	}
 
-	id := c.Params("id")
-	if id == "" {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "id is required")
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
 	}
 
 	// Validate request
-	if errors := validators.ValidateUpdateTodo(&req); len(errors) > 0 {
-		return utils.ValidationErrorResponse(c, errors)
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
 	}
 
 	// Update todo
-	response, err := h.todoService.UpdateTodo(c, id, req)
+	response, err := h.todoService.UpdateTodo(c.UserContext(), principal, id, req)
 	if err != nil {
-		switch err.Error() {
-		case "Unauthorized":
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
-		case "Not found todo with id: " + id:
-			return utils.ErrorResponse(c, fiber.StatusNotFound, err.Error())
-		default:
-			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
-		}
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Todo updated successfully", response)
 }
 
 func (h *TodoHandler) DeleteTodo(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if id == "" {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "id is required")
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
 	}
 
 	// Delete todo
-	if err := h.todoService.DeleteTodo(c, id); err != nil {
-		switch err.Error() {
-		case "Unauthorized":
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
-		case "Not found todo with id: " + id:
-			return utils.ErrorResponse(c, fiber.StatusNotFound, err.Error())
-		default:
-			return utils.ErrorResponse(c, fiber.StatusBadRequest, err.Error())
-		}
+	if err := h.todoService.DeleteTodo(c.UserContext(), principal, id); err != nil {
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Todo deleted successfully", nil)
 }
 
 func (h *TodoHandler) GetAllTodos(c *fiber.Ctx) error {
-	todos, err := h.todoService.GetAllTodos(c)
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	todos, err := h.todoService.GetAllTodos(c.UserContext(), principal)
 	if err != nil {
-		switch err.Error() {
-		case "Unauthorized":
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
-		default:
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
-		}
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Todos fetched successfully", todos)
 }
 
 func (h *TodoHandler) GetTodoByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-	if id == "" {
-		return utils.ErrorResponse(c, fiber.StatusBadRequest, "id is required")
+	id, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
 	}
 
 	// Get todo
-	todo, err := h.todoService.GetTodoByID(c, id)
+	todo, err := h.todoService.GetTodoByID(c.UserContext(), principal, id)
 	if err != nil {
-		switch err.Error() {
-		case "Unauthorized":
-			return utils.ErrorResponse(c, fiber.StatusUnauthorized, err.Error())
-		case "Not found todo with id: " + id:
-			return utils.ErrorResponse(c, fiber.StatusNotFound, err.Error())
-		default:
-			return utils.ErrorResponse(c, fiber.StatusInternalServerError, err.Error())
-		}
+		return err
 	}
 
 	return utils.SuccessResponse(c, "Todo fetched successfully", todo)
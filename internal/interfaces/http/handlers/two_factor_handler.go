@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorHandler(twoFactorService services.TwoFactorService) *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: twoFactorService,
+	}
+}
+
+func (h *TwoFactorHandler) Setup(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	response, err := h.twoFactorService.Setup(c.UserContext(), principal)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Scan the QR code with your authenticator app and confirm with a code", response)
+}
+
+func (h *TwoFactorHandler) Enable(c *fiber.Ctx) error {
+	var req dto.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Enable two-factor authentication
+	response, err := h.twoFactorService.Enable(c.UserContext(), principal, &req, c.IP())
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Two-factor authentication enabled, store your recovery codes safely", response)
+}
+
+func (h *TwoFactorHandler) Disable(c *fiber.Ctx) error {
+	var req dto.TwoFactorCodeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Disable two-factor authentication
+	if err := h.twoFactorService.Disable(c.UserContext(), principal, &req, c.IP()); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Two-factor authentication disabled", nil)
+}
+
+func (h *TwoFactorHandler) Verify(c *fiber.Ctx) error {
+	var req dto.TwoFactorVerifyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	// Complete the login
+	response, err := h.twoFactorService.Verify(c.UserContext(), &req, c.IP())
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Login successful", response)
+}
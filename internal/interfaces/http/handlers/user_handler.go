@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils"
+)
+
+type UserHandler struct {
+	userService services.UserService
+}
+
+func NewUserHandler(userService services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+func (h *UserHandler) GetProfile(c *fiber.Ctx) error {
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.userService.GetProfile(c.UserContext(), principal)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Profile fetched successfully", user)
+}
+
+func (h *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	var req dto.UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Update profile
+	response, err := h.userService.UpdateProfile(c.UserContext(), principal, &req)
+	if err != nil {
+		return err
+	}
+
+	if response.PendingEmail != "" {
+		return utils.SuccessResponse(c, "Profile updated, check your new email address to confirm the change", response)
+	}
+	return utils.SuccessResponse(c, "Profile updated successfully", response)
+}
+
+func (h *UserHandler) VerifyEmailChange(c *fiber.Ctx) error {
+	var req dto.VerifyEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Apply the pending email change
+	user, err := h.userService.VerifyEmailChange(c.UserContext(), principal, &req)
+	if err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Email updated successfully", user)
+}
+
+func (h *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	var req dto.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Change password
+	if err := h.userService.ChangePassword(c.UserContext(), principal, &req); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Password changed successfully, please log in again", nil)
+}
+
+func (h *UserHandler) DeleteAccount(c *fiber.Ctx) error {
+	var req dto.DeleteAccountRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errInvalidRequestBody
+	}
+
+	// Validate request
+	if err := validate(c, &req); err != nil {
+		return err
+	}
+
+	principal, err := middleware.GetPrincipalFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	// Delete account
+	if err := h.userService.DeleteAccount(c.UserContext(), principal, &req); err != nil {
+		return err
+	}
+
+	return utils.SuccessResponse(c, "Account deleted successfully", nil)
+}
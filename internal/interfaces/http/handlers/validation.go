@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	validators "tasius.my.id/todolistapi/internal/interfaces/validator"
+)
+
+// requestValidator checks requests against the validate tags of their DTOs
+var requestValidator = validators.New()
+
+// validate checks req, with messages in the language the client accepts
+func validate(c *fiber.Ctx, req any) error {
+	return requestValidator.Struct(req, c.AcceptsLanguages(validators.Languages...))
+}
+
+// uuidParam returns the path parameter name, which has to be a UUID. Invalid IDs would otherwise
+// fail as queries.
+func uuidParam(c *fiber.Ctx, name string) (string, error) {
+	value := c.Params(name)
+	return value, requestValidator.Var(name, value, "required,uuid", c.AcceptsLanguages(validators.Languages...))
+}
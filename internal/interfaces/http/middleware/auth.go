@@ -2,55 +2,221 @@ package middleware
 
 import (
 	"errors"
-	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
 	"tasius.my.id/todolistapi/internal/utils/jwt"
 )
 
 const (
 	AuthorizationHeader = "Authorization"
-	BearerSchema      = "Bearer"
+	BearerSchema        = "Bearer"
+	APIKeySchema        = "ApiKey"
+	APIKeyHeader        = "X-API-Key"
+
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+var (
+	errAuthorizationRequired = apperrors.Unauthenticated("authorization_required", "authorization header is required")
+	errInvalidAuthorization  = apperrors.Unauthenticated("invalid_authorization_header", "invalid authorization header format")
+	errUserInactive          = apperrors.Unauthenticated("user_inactive", "user is inactive or no longer exists")
+	errNoLongerMember        = apperrors.Unauthenticated("no_longer_member", "no longer a member of the organization")
+	errInsufficientOrgRole   = apperrors.Forbidden("insufficient_organization_role", "insufficient organization permissions")
+	errInsufficientScope     = apperrors.Forbidden("insufficient_scope", "insufficient scope")
+	errInsufficientRole      = apperrors.Forbidden("insufficient_role", "insufficient permissions")
+	errSessionRequired       = apperrors.Forbidden("session_required", "this endpoint requires a user session")
+	errNotAuthenticated      = apperrors.Unauthenticated("not_authenticated", "user ID not found in context")
 )
 
-func AuthMiddleware(jwtManager *jwt.TokenManager) fiber.Handler {
+// AuthMiddleware authenticates requests with a bearer access token, or with a
+// personal API key when an apiKeyService is given. Tokens of inactive users are rejected,
+// as are credentials acting in an organization the user is no longer a member of.
+func AuthMiddleware(jwtManager *jwt.TokenManager, userRepo repositories.UserRepository, orgRepo repositories.OrganizationRepository, apiKeyService services.APIKeyService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
+		if apiKey := extractAPIKey(c); apiKey != "" && apiKeyService != nil {
+			return authenticateAPIKey(c, apiKeyService, orgRepo, apiKey)
+		}
+
 		authHeader := c.Get(AuthorizationHeader)
 		if authHeader == "" {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-				"error": "authorization header is required",
-			})
+			return errAuthorizationRequired
 		}
 
 		parts := strings.Split(authHeader, " ")
 		if len(parts) != 2 || parts[0] != BearerSchema {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-				"error": "invalid authorization header format",
-			})
+			return errInvalidAuthorization
 		}
 
 		token := parts[1]
 		claims, err := jwtManager.ValidateToken(token, jwt.AccessToken)
 		if err != nil {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-				"error": "invalid or expired token",
-			})
+			return err
+		}
+
+		user, err := userRepo.GetByID(c.UserContext(), claims.UserID)
+		if err != nil {
+			return err
+		}
+		if user == nil || !user.IsActive {
+			return errUserInactive
+		}
+
+		if err := setOrganization(c, orgRepo, claims.UserID, claims.OrganizationID); err != nil {
+			return err
 		}
 
 		// Add user info to context
 		c.Locals("userID", claims.UserID)
+		setLogUser(c, claims.UserID)
 		c.Locals("email", claims.Email)
+		c.Locals("role", user.Role)
+		c.Locals("authMethod", AuthMethodJWT)
+		c.Locals("scopes", claims.Scopes)
+
+		return c.Next()
+	}
+}
+
+// RequireOrgRole rejects requests that don't act in an organization where the user has one of the given roles.
+// It must run after AuthMiddleware.
+func RequireOrgRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("organizationRole").(string)
+		if !slices.Contains(roles, role) {
+			return errInsufficientOrgRole
+		}
+		return c.Next()
+	}
+}
+
+// RequireScopes rejects requests whose credential wasn't granted all of the given scopes.
+// It must run after AuthMiddleware.
+func RequireScopes(scopes ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		granted := GetScopesFromContext(c)
+		for _, scope := range scopes {
+			if !slices.Contains(granted, scope) {
+				return errInsufficientScope.WithMessage("insufficient scope, requires " + strings.Join(scopes, " "))
+			}
+		}
+		return c.Next()
+	}
+}
+
+// RequireRole rejects requests from users that have none of the given roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(string)
+		if !slices.Contains(roles, role) {
+			return errInsufficientRole
+		}
+		return c.Next()
+	}
+}
 
+// RequireJWT rejects requests authenticated with an API key, for endpoints that
+// must only be reachable from an interactive session
+func RequireJWT() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Locals("authMethod") != AuthMethodJWT {
+			return errSessionRequired
+		}
 		return c.Next()
 	}
 }
 
+func authenticateAPIKey(c *fiber.Ctx, apiKeyService services.APIKeyService, orgRepo repositories.OrganizationRepository, key string) error {
+	apiKey, err := apiKeyService.Authenticate(c.UserContext(), key)
+	if err != nil {
+		return err
+	}
+
+	organizationID := ""
+	if apiKey.OrganizationID != nil {
+		organizationID = *apiKey.OrganizationID
+	}
+	if err := setOrganization(c, orgRepo, apiKey.UserID, organizationID); err != nil {
+		return err
+	}
+
+	// Add user info to context
+	c.Locals("userID", apiKey.UserID)
+	setLogUser(c, apiKey.UserID)
+	c.Locals("email", apiKey.User.Email)
+	c.Locals("role", apiKey.User.Role)
+	c.Locals("authMethod", AuthMethodAPIKey)
+	c.Locals("scopes", apiKey.Scopes)
+
+	return c.Next()
+}
+
+// setOrganization stores the organization the request acts in, after checking the user still belongs to it.
+// An empty organizationID is the user's personal space.
+func setOrganization(c *fiber.Ctx, orgRepo repositories.OrganizationRepository, userID, organizationID string) error {
+	if organizationID == "" {
+		return nil
+	}
+
+	member, err := orgRepo.GetMember(c.UserContext(), organizationID, userID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		return errNoLongerMember
+	}
+
+	c.Locals("organizationID", organizationID)
+	c.Locals("organizationRole", member.Role)
+	return nil
+}
+
+// extractAPIKey reads the key from the X-API-Key header or an "Authorization: ApiKey <key>" header
+func extractAPIKey(c *fiber.Ctx) string {
+	if key := c.Get(APIKeyHeader); key != "" {
+		return key
+	}
+
+	parts := strings.Split(c.Get(AuthorizationHeader), " ")
+	if len(parts) == 2 && parts[0] == APIKeySchema {
+		return parts[1]
+	}
+
+	return ""
+}
+
+// GetPrincipalFromContext returns the caller authenticated by AuthMiddleware
+func GetPrincipalFromContext(c *fiber.Ctx) (*entities.Principal, error) {
+	userID, err := GetUserIDFromContext(c)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _ := c.Locals("email").(string)
+	role, _ := c.Locals("role").(string)
+	organizationRole, _ := c.Locals("organizationRole").(string)
+	return &entities.Principal{
+		UserID:           userID,
+		Email:            email,
+		Role:             role,
+		Scopes:           GetScopesFromContext(c),
+		OrganizationID:   GetOrganizationIDFromContext(c),
+		OrganizationRole: organizationRole,
+	}, nil
+}
+
 // GetUserIDFromContext gets the user ID from the context
 func GetUserIDFromContext(c *fiber.Ctx) (string, error) {
 	userID, ok := c.Locals("userID").(string)
 	if !ok {
-		return "", errors.New("user ID not found in context")
+		return "", errNotAuthenticated
 	}
 	return userID, nil
 }
@@ -63,3 +229,15 @@ func GetEmailFromContext(c *fiber.Ctx) (string, error) {
 	}
 	return email, nil
 }
+
+// GetScopesFromContext gets the scopes granted to the request's credential
+func GetScopesFromContext(c *fiber.Ctx) []string {
+	scopes, _ := c.Locals("scopes").([]string)
+	return scopes
+}
+
+// GetOrganizationIDFromContext gets the organization the request acts in, empty for the personal space
+func GetOrganizationIDFromContext(c *fiber.Ctx) string {
+	organizationID, _ := c.Locals("organizationID").(string)
+	return organizationID
+}
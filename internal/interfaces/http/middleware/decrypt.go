@@ -3,56 +3,100 @@ package middleware
 import (
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"mime"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
 	"tasius.my.id/todolistapi/internal/utils/crypto"
+	"tasius.my.id/todolistapi/internal/utils/metrics"
+	"tasius.my.id/todolistapi/internal/utils/replay"
 )
 
 // RequestBody represents the expected request body format with encrypted data
 type RequestBody struct {
-	Data string `json:"data"` // Base64 encoded encrypted data
+	Data string `json:"data"` // Encrypted data, a versioned envelope or the legacy hex format
 }
 
-// decryptData handles the decryption of the request data
-func decryptData(privateKeyPath, encryptedInput string) ([]byte, error) {
-	// Load private key
-	privateKey, err := crypto.LoadPrivateKey(privateKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load private key: %w", err)
+var (
+	errInvalidEncryptedRequest = apperrors.Validation("invalid_encrypted_request", "invalid encrypted request")
+	errMalformedEnvelope       = apperrors.Validation("malformed_envelope", "malformed encrypted data")
+	errStaleRequest            = apperrors.Validation("stale_request", "encrypted request is too old")
+	errUnknownKeyID            = apperrors.Validation("unknown_key", "unknown key ID, fetch the current public key from /api/encryption/public-key")
+	errReplayedRequest         = apperrors.Conflict("replayed_request", "encrypted request has already been received")
+	errLegacyRejected          = apperrors.Validation("legacy_encryption_rejected", "unversioned encrypted data is no longer accepted, send a "+crypto.EnvelopeVersion+" envelope")
+	errDecryptionFailed        = apperrors.Validation("decryption_failed", "Failed to decrypt data. Invalid or corrupted data.")
+	errInvalidDecryptedJSON    = apperrors.Validation("invalid_decrypted_json", "Decrypted data is not valid JSON")
+)
+
+// encryptedPayload is the parsed legacy "data" field: [<key_id>:]<encrypted_key_hex>:<encrypted_data_hex>
+type encryptedPayload struct {
+	keyID         string
+	encryptedKey  []byte
+	encryptedData []byte
+}
+
+func parsePayload(data string) (*encryptedPayload, error) {
+	parts := strings.Split(data, ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return nil, fmt.Errorf("invalid encrypted data format. Expected format: [<key_id>:]<encrypted_key_hex>:<encrypted_data_hex>")
 	}
 
-	// Split the input into encrypted key and data
-	parts := strings.Split(encryptedInput, ":")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid input format. Expected format: <encrypted_key_hex>:<encrypted_data_hex>")
+	// Payloads without key ID come from clients built before key rotation
+	var payload encryptedPayload
+	if len(parts) == 3 {
+		payload.keyID = parts[0]
+		parts = parts[1:]
 	}
 
-	encryptedKey, err := hex.DecodeString(parts[0])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode encrypted key: %w", err)
+	var err error
+	if payload.encryptedKey, err = hex.DecodeString(parts[0]); err != nil {
+		return nil, fmt.Errorf("invalid encrypted key format: not a valid hex string")
+	}
+	if payload.encryptedData, err = hex.DecodeString(parts[1]); err != nil {
+		return nil, fmt.Errorf("invalid encrypted data format: not a valid hex string")
 	}
 
-	encryptedData, err := hex.DecodeString(parts[1])
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode encrypted data: %w", err)
+	return &payload, nil
+}
+
+// decryptData handles the decryption of legacy request data, returning it together with the AES session key
+func decryptData(keyring *crypto.Keyring, payload *encryptedPayload) ([]byte, []byte, error) {
+	var candidates []crypto.PrivateKey
+	if payload.keyID != "" {
+		privateKey, ok := keyring.Get(payload.keyID)
+		if !ok {
+			return nil, nil, errUnknownKeyID
+		}
+		candidates = []crypto.PrivateKey{privateKey}
+	} else {
+		candidates = keyring.All()
 	}
 
-	// Decrypt the AES key with RSA
-	aesKey, err := crypto.DecryptWithPrivateKey(privateKey, encryptedKey)
+	// Decrypt the AES key
+	var (
+		aesKey []byte
+		err    error
+	)
+	for _, privateKey := range candidates {
+		if aesKey, err = privateKey.Decapsulate(payload.encryptedKey); err == nil {
+			break
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+		return nil, nil, fmt.Errorf("failed to decrypt AES key: %w", err)
 	}
 
 	// Decrypt the message with AES
-	decryptedData, err := crypto.DecryptAES(aesKey, encryptedData)
+	decryptedData, err := crypto.DecryptAES(aesKey, payload.encryptedData, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decrypt message: %w", err)
+		return nil, nil, fmt.Errorf("failed to decrypt message: %w", err)
 	}
 
-	return decryptedData, nil
+	return decryptedData, aesKey, nil
 }
 
 // validateAndProcessRequest handles the request validation and processing
@@ -66,61 +110,137 @@ func validateAndProcessRequest(c *fiber.Ctx, body []byte) (*RequestBody, error)
 		return nil, fmt.Errorf("invalid request format: %w", err)
 	}
 
-	// Validate that the data field exists and has the expected format
+	// Validate that the data field exists
 	if reqBody.Data == "" {
 		return nil, fmt.Errorf("encrypted data is required in 'data' field")
 	}
 
-	// Check if the data appears to be in the expected format: <encrypted_key_hex>:<encrypted_data_hex>
-	parts := strings.Split(reqBody.Data, ":")
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("invalid encrypted data format. Expected format: <encrypted_key_hex>:<encrypted_data_hex>")
+	return &reqBody, nil
+}
+
+// openEnvelope decrypts a versioned envelope, rejecting it if it is stale, was sealed for another
+// request or has been sent before. The nonce is only recorded once the envelope is authenticated.
+func openEnvelope(c *fiber.Ctx, keyring *crypto.Keyring, replayGuard *replay.Guard, data string) ([]byte, []byte, error) {
+	envelope, err := crypto.ParseEnvelope(data)
+	if err != nil {
+		return nil, nil, &decryptError{reason: "malformed", public: errMalformedEnvelope.WithMessage(err.Error())}
 	}
 
-	// Validate that both parts are valid hex strings
-	if _, err := hex.DecodeString(parts[0]); err != nil {
-		return nil, fmt.Errorf("invalid encrypted key format: not a valid hex string")
+	if err := replayGuard.CheckTimestamp(envelope.Timestamp); err != nil {
+		return nil, nil, &decryptError{reason: "stale", public: errStaleRequest.WithMessage(err.Error())}
 	}
 
-	if _, err := hex.DecodeString(parts[1]); err != nil {
-		return nil, fmt.Errorf("invalid encrypted data format: not a valid hex string")
+	privateKey, ok := keyring.Get(envelope.KeyID)
+	if !ok {
+		return nil, nil, &decryptError{reason: "unknown_key", public: errUnknownKeyID}
 	}
 
-	return &reqBody, nil
+	decryptedData, sessionKey, err := envelope.Open(privateKey, c.Method(), c.Path())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := replayGuard.UseNonce(c.UserContext(), envelope.KeyID, envelope.Nonce); err != nil {
+		if errors.Is(err, replay.ErrReplayedNonce) {
+			return nil, nil, &decryptError{reason: "replayed", public: errReplayedRequest.WithMessage(err.Error())}
+		}
+		return nil, nil, &decryptError{reason: "error", err: fmt.Errorf("failed to verify encrypted request: %w", err)}
+	}
+
+	return decryptedData, sessionKey, nil
+}
+
+// openLegacy decrypts the unversioned [<key_id>:]<encrypted_key_hex>:<encrypted_data_hex> format
+func openLegacy(keyring *crypto.Keyring, data string) ([]byte, []byte, error) {
+	payload, err := parsePayload(data)
+	if err != nil {
+		return nil, nil, &decryptError{reason: "malformed", public: errMalformedEnvelope.WithMessage(err.Error())}
+	}
+
+	decryptedData, sessionKey, err := decryptData(keyring, payload)
+	if errors.Is(err, errUnknownKeyID) {
+		return nil, nil, &decryptError{reason: "unknown_key", public: errUnknownKeyID}
+	}
+	return decryptedData, sessionKey, err
 }
 
-// DecryptMiddleware decrypts the request body before passing it to the handler
-func DecryptMiddleware(privateKeyPath string) fiber.Handler {
+// decryptError is a failure reported to the client as public, other errors get a generic message
+type decryptError struct {
+	// reason labels the failure in metrics.DecryptionFailures
+	reason string
+	// public is returned to the client, internal failures have none and return err instead
+	public *apperrors.Error
+	err    error
+}
+
+func (e *decryptError) Error() string {
+	if e.public != nil {
+		return e.public.Error()
+	}
+	return e.err.Error()
+}
+
+// response returns the error the request fails with
+func (e *decryptError) response() error {
+	if e.public != nil {
+		return e.public
+	}
+	return e.err
+}
+
+// DecryptMiddleware decrypts the request body with the keys of the keyring before passing it to the handler.
+// Bodies are {"data":"<envelope>"}, see crypto.Envelope. The legacy format is only accepted if allowLegacy is set.
+// Requests without a body pass through, any other body is rejected unless it is encrypted.
+func DecryptMiddleware(keyring *crypto.Keyring, replayGuard *replay.Guard, allowLegacy bool, logger *slog.Logger) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Only process JSON requests
-		if c.Get("Content-Type") != "application/json" {
+		// Nothing to decrypt, e.g. for the OIDC redirects
+		contentType := c.Get(fiber.HeaderContentType)
+		if contentType == "" && len(c.Request().Body()) == 0 {
 			return c.Next()
 		}
 
+		// Handlers parse bodies of other types too, passing them on would skip the replay checks
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != fiber.MIMEApplicationJSON {
+			logger.WarnContext(c.UserContext(), "Unencrypted request body rejected", "content_type", contentType)
+			metrics.DecryptionFailures.WithLabelValues("invalid_request").Inc()
+			return errInvalidEncryptedRequest.WithMessage("request body must be encrypted JSON")
+		}
+
 		// Read and validate request
 		reqBody, err := validateAndProcessRequest(c, c.Request().Body())
 		if err != nil {
-			log.Printf("Request validation failed: %v", err)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
+			logger.WarnContext(c.UserContext(), "Encrypted request validation failed", "error", err)
+			metrics.DecryptionFailures.WithLabelValues("invalid_request").Inc()
+			return errInvalidEncryptedRequest.WithMessage(err.Error())
 		}
 
 		// Decrypt the data
-		decryptedData, err := decryptData(privateKeyPath, reqBody.Data)
+		var decryptedData, sessionKey []byte
+		switch {
+		case crypto.IsEnvelope(reqBody.Data):
+			decryptedData, sessionKey, err = openEnvelope(c, keyring, replayGuard, reqBody.Data)
+		case allowLegacy:
+			decryptedData, sessionKey, err = openLegacy(keyring, reqBody.Data)
+		default:
+			err = &decryptError{reason: "legacy_rejected", public: errLegacyRejected}
+		}
 		if err != nil {
-			log.Printf("Decryption failed: %v", err)
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Failed to decrypt data. Invalid or corrupted data.",
-			})
+			logger.WarnContext(c.UserContext(), "Decryption failed", "error", err)
+
+			var decryptErr *decryptError
+			if errors.As(err, &decryptErr) {
+				metrics.DecryptionFailures.WithLabelValues(decryptErr.reason).Inc()
+				return decryptErr.response()
+			}
+			metrics.DecryptionFailures.WithLabelValues("decrypt").Inc()
+			return errDecryptionFailed
 		}
 
 		// Validate JSON
 		if !json.Valid(decryptedData) {
-			log.Print("Decrypted data is not valid JSON")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Decrypted data is not valid JSON",
-			})
+			logger.WarnContext(c.UserContext(), "Decrypted data is not valid JSON")
+			metrics.DecryptionFailures.WithLabelValues("invalid_json").Inc()
+			return errInvalidDecryptedJSON
 		}
 
 		// Update request with decrypted data
@@ -128,6 +248,7 @@ func DecryptMiddleware(privateKeyPath string) fiber.Handler {
 		c.Request().Header.SetContentType("application/json")
 		c.Request().SetRequestURI(c.OriginalURL())
 		c.Locals("decryptedBody", decryptedData)
+		c.Locals(sessionKeyLocal, sessionKey)
 
 		return c.Next()
 	}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/interfaces/http/problem"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+	"tasius.my.id/todolistapi/internal/utils/replay"
+)
+
+// newDecryptApp serves POST /echo behind DecryptMiddleware, answering with the body the handler got.
+// It returns a function sealing bodies for the app's key.
+func newDecryptApp(t *testing.T) (*fiber.App, func(plaintext string) string) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey(crypto.KeyTypeX25519)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	keyPath := filepath.Join(t.TempDir(), "private.pem")
+	if err := crypto.SavePrivateKey(keyPath, key, nil); err != nil {
+		t.Fatalf("SavePrivateKey() error = %v", err)
+	}
+	keyring, err := crypto.NewKeyring(nil, keyPath)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	keyID, _ := keyring.Current()
+
+	redisClient := redis.NewClient(&redis.Options{Addr: miniredis.RunT(t).Addr()})
+	t.Cleanup(func() { redisClient.Close() })
+	replayGuard := replay.NewGuard(&config.HybridEncryptionConfig{MaxClockSkew: time.Minute}, redisClient)
+
+	app := fiber.New(fiber.Config{ErrorHandler: problem.Write})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	app.Post("/echo", DecryptMiddleware(keyring, replayGuard, false, logger), func(c *fiber.Ctx) error {
+		return c.Send(c.Body())
+	})
+
+	seal := func(plaintext string) string {
+		envelope, _, err := crypto.SealEnvelope(key.Public(), keyID, http.MethodPost, "/echo", []byte(plaintext))
+		if err != nil {
+			t.Fatalf("SealEnvelope() error = %v", err)
+		}
+		body, _ := json.Marshal(RequestBody{Data: envelope.String()})
+		return string(body)
+	}
+	return app, seal
+}
+
+func TestDecryptMiddleware(t *testing.T) {
+	app, seal := newDecryptApp(t)
+	replayed := seal(`{"email":"alice@example.com"}`)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+		// wantBody is the body the handler gets, or the code of the problem
+		wantBody string
+	}{
+		{name: "envelope", contentType: "application/json", body: seal(`{"email":"alice@example.com"}`), wantStatus: http.StatusOK, wantBody: `{"email":"alice@example.com"}`},
+		{name: "envelope with charset", contentType: "application/json; charset=utf-8", body: replayed, wantStatus: http.StatusOK, wantBody: `{"email":"alice@example.com"}`},
+		// Sent a second time, after the case above
+		{name: "replayed envelope", contentType: "application/json", body: replayed, wantStatus: http.StatusConflict, wantBody: "replayed_request"},
+		{name: "no body", wantStatus: http.StatusOK},
+		{name: "plaintext JSON", contentType: "application/json", body: `{"email":"alice@example.com"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid_encrypted_request"},
+		{name: "plaintext JSON with charset", contentType: "application/json; charset=utf-8", body: `{"email":"alice@example.com"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid_encrypted_request"},
+		{name: "plaintext JSON in upper case", contentType: "Application/JSON", body: `{"email":"alice@example.com"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid_encrypted_request"},
+		{name: "JSON without envelope", contentType: "application/json", body: `{"data":"plaintext"}`, wantStatus: http.StatusBadRequest, wantBody: "legacy_encryption_rejected"},
+		{name: "form", contentType: "application/x-www-form-urlencoded", body: "email=alice%40example.com", wantStatus: http.StatusBadRequest, wantBody: "invalid_encrypted_request"},
+		{name: "body without content type", body: `{"email":"alice@example.com"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid_encrypted_request"},
+		{name: "invalid content type", contentType: "application/json; charset", body: `{"email":"alice@example.com"}`, wantStatus: http.StatusBadRequest, wantBody: "invalid_encrypted_request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/echo", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("app.Test() error = %v", err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body %s", resp.StatusCode, tt.wantStatus, body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if string(body) != tt.wantBody {
+					t.Errorf("handler got body %q, want %q", body, tt.wantBody)
+				}
+				return
+			}
+			var details struct {
+				Code string `json:"code"`
+			}
+			if err := json.Unmarshal(body, &details); err != nil || details.Code != tt.wantBody {
+				t.Errorf("problem = %s, want code %q", body, tt.wantBody)
+			}
+		})
+	}
+}
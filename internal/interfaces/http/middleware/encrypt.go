@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+)
+
+const (
+	// ClientPublicKeyHeader carries a base64 encoded DER (PKIX) RSA, X25519 or P-256 public key the response
+	// is encrypted for, for requests that didn't send an encrypted body
+	ClientPublicKeyHeader = "X-Client-Public-Key"
+	// EncryptedResponseHeader marks responses whose body is encrypted
+	EncryptedResponseHeader = "X-Encrypted-Response"
+
+	sessionKeyLocal     = "sessionKey"
+	sessionEncryption   = "session"
+	publicKeyEncryption = "public-key"
+)
+
+var errInvalidClientPublicKey = apperrors.Validation("invalid_client_public_key", "invalid "+ClientPublicKeyHeader+" header")
+
+// EncryptResponseMiddleware encrypts the response body when the request was encrypted with
+// DecryptMiddleware, using the same AES session key, so the client reads it as {"data":"<data_hex>"}.
+// Clients that sent no encrypted body can pass their public key in X-Client-Public-Key instead,
+// the response is then hybrid encrypted like requests: {"data":"<encrypted_key_hex>:<encrypted_data_hex>"}.
+func EncryptResponseMiddleware(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Check the client key before running the handler, so a bad key doesn't return a plaintext response later
+		clientKey := c.Get(ClientPublicKeyHeader)
+		if clientKey != "" {
+			if _, err := parseClientPublicKey(clientKey); err != nil {
+				return errInvalidClientPublicKey.WithMessage(err.Error())
+			}
+		}
+
+		// Errors are turned into responses here so they get encrypted as well
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				return handlerErr
+			}
+		}
+
+		body := c.Response().Body()
+		if len(body) == 0 {
+			return nil
+		}
+
+		var (
+			data   string
+			method string
+			err    error
+		)
+		if sessionKey, ok := c.Locals(sessionKeyLocal).([]byte); ok {
+			data, err = encryptWithSessionKey(sessionKey, body)
+			method = sessionEncryption
+		} else if clientKey != "" {
+			data, err = encryptWithClientKey(clientKey, body)
+			method = publicKeyEncryption
+		} else {
+			return nil
+		}
+
+		if err != nil {
+			logger.ErrorContext(c.UserContext(), "Response encryption failed", "error", err)
+			// The error handler of the app answers with a plaintext problem
+			c.Response().ResetBody()
+			return fmt.Errorf("failed to encrypt response: %w", err)
+		}
+
+		encrypted, err := json.Marshal(RequestBody{Data: data})
+		if err != nil {
+			return err
+		}
+
+		c.Set(EncryptedResponseHeader, method)
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		c.Response().SetBodyRaw(encrypted)
+		return nil
+	}
+}
+
+func encryptWithSessionKey(sessionKey, body []byte) (string, error) {
+	ciphertext, err := crypto.EncryptAES(sessionKey, body, nil)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(ciphertext), nil
+}
+
+func encryptWithClientKey(clientKey string, body []byte) (string, error) {
+	publicKey, err := parseClientPublicKey(clientKey)
+	if err != nil {
+		return "", err
+	}
+
+	aesKey, encryptedKey, err := publicKey.Encapsulate()
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := crypto.EncryptAES(aesKey, body, nil)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(encryptedKey) + ":" + hex.EncodeToString(ciphertext), nil
+}
+
+func parseClientPublicKey(value string) (crypto.PublicKey, error) {
+	der, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: not valid base64", ClientPublicKeyHeader)
+	}
+
+	publicKey, err := crypto.ParsePublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s header: %w", ClientPublicKeyHeader, err)
+	}
+
+	return publicKey, nil
+}
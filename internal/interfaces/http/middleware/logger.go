@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"tasius.my.id/todolistapi/internal/utils/logging"
+)
+
+// RequestLogger logs every request once it is handled. Records logged with the request's user context
+// carry the request ID, and the user ID once authenticated. It must run after the request ID middleware.
+func RequestLogger(logger *slog.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		requestID, _ := c.Locals("requestid").(string)
+		ctx := logging.WithFields(c.UserContext(),
+			slog.String("request_id", utils.CopyString(requestID)),
+			slog.String("method", utils.CopyString(c.Method())),
+			slog.String("path", utils.CopyString(c.Path())),
+		)
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		route, status := requestOutcome(c, err)
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= fiber.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		logger.LogAttrs(ctx, level, "Request handled", attrs...)
+
+		return err
+	}
+}
+
+// setLogUser adds the authenticated user to the log fields of the request
+func setLogUser(c *fiber.Ctx, userID string) {
+	logging.AddFields(c.UserContext(), slog.String("user_id", userID))
+}
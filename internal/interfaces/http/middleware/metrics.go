@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"tasius.my.id/todolistapi/internal/interfaces/http/problem"
+	"tasius.my.id/todolistapi/internal/utils/metrics"
+)
+
+// unmatchedRoute labels requests no route matched, so unknown paths don't create new series
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware records the count and latency of requests by route template, e.g. /api/todos/:id
+func MetricsMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		route, status := requestOutcome(c, err)
+
+		// The method points into the request buffer, which is reused after the request
+		labels := []string{utils.CopyString(c.Method()), route, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// requestOutcome returns the route template and status code of a handled request. Returned errors are
+// turned into responses by the error handler only after the middleware returns.
+func requestOutcome(c *fiber.Ctx, err error) (string, int) {
+	route := c.Route().Path
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = problem.Status(err)
+		// Fiber returns a 404 *fiber.Error when no route matched, domain errors keep their route
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) && fiberErr.Code == fiber.StatusNotFound {
+			route = unmatchedRoute
+		}
+	}
+	return route, status
+}
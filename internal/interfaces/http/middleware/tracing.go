@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+	"tasius.my.id/todolistapi/internal/utils/tracing"
+)
+
+// TracingMiddleware starts a server span for every request, continuing the trace of the caller's
+// traceparent header. Handlers and services get the span through c.UserContext().
+func TracingMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Spans are exported after the request, when fiber has reused the buffers its strings point into
+		method := utils.CopyString(c.Method())
+
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracing.Tracer().Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(utils.CopyString(c.Path())),
+			),
+		)
+		defer span.End()
+
+		if requestID, ok := c.Locals("requestid").(string); ok {
+			span.SetAttributes(requestIDAttribute.String(utils.CopyString(requestID)))
+		}
+
+		c.SetUserContext(ctx)
+		err := c.Next()
+
+		// The route is only known once it matched, e.g. "GET /api/todos/:id"
+		route, status := requestOutcome(c, err)
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+
+		return err
+	}
+}
+
+const requestIDAttribute = attribute.Key("http.request.id")
+
+// headerCarrier reads the trace context from request headers and writes it to response headers
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
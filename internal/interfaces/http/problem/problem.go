@@ -0,0 +1,113 @@
+// Package problem turns errors into RFC 7807 problem details responses. It is the one place where
+// errors are mapped to HTTP status codes: handlers and middleware return errors, and the error
+// handler of the app writes them with Write.
+package problem
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+)
+
+const (
+	// ContentType is the media type of problem details
+	ContentType = "application/problem+json"
+	// TypePrefix is followed by the code of the problem in its type URI
+	TypePrefix = "urn:todolistapi:problem:"
+	// CodeInternal is the code of unexpected errors, whose message is never shown to clients
+	CodeInternal = "internal_error"
+)
+
+// statuses maps the kinds of domain errors to HTTP status codes
+var statuses = map[apperrors.Kind]int{
+	apperrors.KindNotFound:        fiber.StatusNotFound,
+	apperrors.KindForbidden:       fiber.StatusForbidden,
+	apperrors.KindConflict:        fiber.StatusConflict,
+	apperrors.KindValidation:      fiber.StatusBadRequest,
+	apperrors.KindUnauthenticated: fiber.StatusUnauthorized,
+	apperrors.KindRateLimited:     fiber.StatusTooManyRequests,
+	apperrors.KindUpstream:        fiber.StatusBadGateway,
+}
+
+// Details is a problem details object. Code, RequestID and Errors are extension members.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	Code      string            `json:"code"`
+	RequestID string            `json:"requestId,omitempty"`
+	Errors    map[string]string `json:"errors,omitempty"`
+}
+
+// Status returns the HTTP status code of err: the status of its kind for domain errors, the code of
+// a *fiber.Error, and 500 for anything else
+func Status(err error) int {
+	if appErr, ok := apperrors.As(err); ok {
+		if status, ok := statuses[appErr.Kind]; ok {
+			return status
+		}
+	}
+
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+
+	return fiber.StatusInternalServerError
+}
+
+// New describes err as a problem. Messages of errors other than domain errors and *fiber.Error
+// may contain internals and are replaced by a generic detail.
+func New(err error) *Details {
+	status := Status(err)
+	details := &Details{
+		Title:  http.StatusText(status),
+		Status: status,
+	}
+
+	var fiberErr *fiber.Error
+	if appErr, ok := apperrors.As(err); ok {
+		details.Code = appErr.Code
+		details.Detail = appErr.Message
+		details.Errors = appErr.Errors
+	} else if errors.As(err, &fiberErr) {
+		details.Code = codeOf(status)
+		details.Detail = fiberErr.Message
+	} else {
+		details.Code = CodeInternal
+		details.Detail = "An unexpected error occurred"
+	}
+
+	details.Type = TypePrefix + details.Code
+	return details
+}
+
+// Write sends err as a problem details response. It is the fiber.ErrorHandler of the app.
+func Write(c *fiber.Ctx, err error) error {
+	details := New(err)
+	details.Instance = c.Path()
+	details.RequestID, _ = c.Locals("requestid").(string)
+
+	if appErr, ok := apperrors.As(err); ok && appErr.RetryAfter > 0 {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+	}
+
+	return c.Status(details.Status).JSON(details, ContentType)
+}
+
+// codeOf derives a code from a status without a domain error, e.g. "method_not_allowed" for 405
+func codeOf(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return CodeInternal
+	}
+	return strings.ReplaceAll(strings.ToLower(text), " ", "_")
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/services"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+)
+
+func SetupAdminRoutes(api fiber.Router, deps RoutesDependencies) {
+
+	userRepo := repositories.NewUserRepository(deps.Db)
+	todoRepo := newTodoRepository(deps)
+	apiKeyRepo := repositories.NewAPIKeyRepository(deps.Db)
+	authService := services.NewTracedAuthService(services.NewAuthService(userRepo, deps.RedisClient, &deps.Config.JWT, deps.JWTManager, deps.Mailer, &deps.Config.PasswordReset, deps.LoginTracker, deps.Tasks))
+	adminService := services.NewAdminService(userRepo, todoRepo, apiKeyRepo, authService, deps.JWTManager, deps.LoginTracker)
+	adminHandler := handlers.NewAdminHandler(adminService)
+
+	admin := api.Group("/admin",
+		authMiddleware(deps),
+		middleware.RequireJWT(),
+		middleware.RequireRole(entities.RoleAdmin),
+		middleware.RequireScopes(entities.ScopeAdmin),
+	)
+
+	users := admin.Group("/users")
+	users.Get("", adminHandler.ListUsers)
+	users.Get("/:id", adminHandler.GetUser)
+	users.Get("/:id/stats", adminHandler.GetUserStats)
+	users.Post("/:id/activate", adminHandler.ActivateUser)
+	users.Post("/:id/deactivate", adminHandler.DeactivateUser)
+	users.Post("/:id/role", adminHandler.UpdateUserRole)
+	users.Post("/:id/logout", adminHandler.ForceLogout)
+	users.Post("/:id/reset-password", adminHandler.ResetUserPassword)
+	users.Post("/:id/unlock", adminHandler.UnlockUser)
+}
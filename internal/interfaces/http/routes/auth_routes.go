@@ -3,22 +3,43 @@ package routes
 import (
 	"github.com/gofiber/fiber/v2"
 	"tasius.my.id/todolistapi/internal/application/services"
+	"tasius.my.id/todolistapi/internal/domain/entities"
 	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
 	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
 	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils/oidc"
 )
 
 func SetupAuthRoutes(api fiber.Router, deps RoutesDependencies) {
 
 	userRepo := repositories.NewUserRepository(deps.Db)
-	authService := services.NewAuthService(userRepo, deps.RedisClient, &deps.Config.JWT, deps.JWTManager)
+	authService := services.NewTracedAuthService(services.NewAuthService(userRepo, deps.RedisClient, &deps.Config.JWT, deps.JWTManager, deps.Mailer, &deps.Config.PasswordReset, deps.LoginTracker, deps.Tasks))
 	authHandler := handlers.NewAuthHandler(authService)
 
-	auth := api.Group("/auth", middleware.DecryptMiddleware(deps.Config.HybridEncryption.PrivateKeyPath))
+	recoveryCodeRepo := repositories.NewRecoveryCodeRepository(deps.Db)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo, deps.RedisClient, deps.JWTManager, &deps.Config.JWT, &deps.Config.TwoFactor, deps.LoginTracker)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
+
+	identityRepo := repositories.NewUserIdentityRepository(deps.Db)
+	oidcProviders := oidc.NewProviders(&deps.Config.OIDC, nil)
+	oidcService := services.NewOIDCService(userRepo, identityRepo, deps.RedisClient, deps.JWTManager, &deps.Config.JWT, &deps.Config.OIDC, oidcProviders)
+	oidcHandler := handlers.NewOIDCHandler(oidcService)
+
+	// Tokens go back encrypted with the session key of the request, or for the client's public key
+	auth := api.Group("/auth", decryptMiddleware(deps), middleware.EncryptResponseMiddleware(deps.Logger))
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
 	auth.Post("/refresh", authHandler.RefreshToken)
+	auth.Post("/forgot-password", authHandler.ForgotPassword)
+	auth.Post("/reset-password", authHandler.ResetPassword)
+	auth.Post("/2fa/verify", twoFactorHandler.Verify)
+	auth.Get("/oidc/:provider/login", oidcHandler.Login)
+	auth.Get("/oidc/:provider/callback", oidcHandler.Callback)
 
-	authProtected := api.Group("/auth", middleware.AuthMiddleware(deps.JWTManager))
+	// Signing out and changing two-factor settings affect every session of the account
+	authProtected := api.Group("/auth", middleware.AuthMiddleware(deps.JWTManager, userRepo, repositories.NewOrganizationRepository(deps.Db), nil), middleware.RequireScopes(entities.ScopeProfileWrite))
 	authProtected.Post("/logout", authHandler.Logout)
+	authProtected.Post("/2fa/setup", twoFactorHandler.Setup)
+	authProtected.Post("/2fa/enable", twoFactorHandler.Enable)
+	authProtected.Post("/2fa/disable", twoFactorHandler.Disable)
 }
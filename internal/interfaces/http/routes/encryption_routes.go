@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
+)
+
+func SetupEncryptionRoutes(api fiber.Router, deps RoutesDependencies) {
+	encryptionHandler := handlers.NewEncryptionHandler(deps.Keyring)
+
+	encryption := api.Group("/encryption")
+	encryption.Get("/public-key", encryptionHandler.GetPublicKey)
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
+)
+
+// SetupHealthRoutes mounts the probes at the root, where orchestrators expect them, and keeps
+// /api/health as an alias of the liveness probe
+func SetupHealthRoutes(app *fiber.App, api fiber.Router, deps RoutesDependencies) {
+	healthHandler := handlers.NewHealthHandler(deps.Health)
+
+	app.Get("/livez", healthHandler.Livez)
+	app.Get("/readyz", healthHandler.Readyz)
+	api.Get("/health", healthHandler.Livez)
+}
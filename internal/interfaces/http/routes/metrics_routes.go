@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
+)
+
+// SetupMetricsRoutes mounts the Prometheus endpoint at the root, where scrapers expect it
+func SetupMetricsRoutes(app *fiber.App, deps RoutesDependencies) {
+	metricsHandler := handlers.NewMetricsHandler(deps.Config.Metrics.Token)
+
+	app.Get("/metrics", metricsHandler.GetMetrics)
+}
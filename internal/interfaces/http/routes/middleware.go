@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/services"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/utils/replay"
+)
+
+// authMiddleware accepts both bearer access tokens and personal API keys
+func authMiddleware(deps RoutesDependencies) fiber.Handler {
+	userRepo := repositories.NewUserRepository(deps.Db)
+	orgRepo := repositories.NewOrganizationRepository(deps.Db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(deps.Db)
+	return middleware.AuthMiddleware(deps.JWTManager, userRepo, orgRepo, services.NewAPIKeyService(apiKeyRepo))
+}
+
+// decryptMiddleware decrypts request bodies with the hybrid encryption keys, rejecting replayed requests
+func decryptMiddleware(deps RoutesDependencies) fiber.Handler {
+	replayGuard := replay.NewGuard(&deps.Config.HybridEncryption, deps.RedisClient)
+	return middleware.DecryptMiddleware(deps.Keyring, replayGuard, deps.Config.HybridEncryption.AllowLegacyFormat, deps.Logger)
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/services"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+)
+
+func SetupOrganizationRoutes(api fiber.Router, deps RoutesDependencies) {
+
+	orgRepo := repositories.NewOrganizationRepository(deps.Db)
+	inviteRepo := repositories.NewOrganizationInviteRepository(deps.Db)
+	userRepo := repositories.NewUserRepository(deps.Db)
+	organizationService := services.NewOrganizationService(orgRepo, inviteRepo, userRepo, deps.JWTManager, &deps.Config.JWT, deps.Mailer, &deps.Config.Organization)
+	organizationHandler := handlers.NewOrganizationHandler(organizationService)
+
+	canRead := middleware.RequireScopes(entities.ScopeProfileRead)
+	canWrite := middleware.RequireScopes(entities.ScopeProfileWrite)
+
+	// Memberships are managed from user sessions, API keys stay bound to their organization
+	organizations := api.Group("/organizations", authMiddleware(deps), middleware.RequireJWT())
+	organizations.Get("", canRead, organizationHandler.GetMyOrganizations)
+	organizations.Post("", canWrite, organizationHandler.CreateOrganization)
+	organizations.Post("/switch", canWrite, organizationHandler.SwitchOrganization)
+	organizations.Post("/invites/accept", canWrite, organizationHandler.AcceptInvite)
+	organizations.Get("/:id", canRead, organizationHandler.GetOrganization)
+	organizations.Get("/:id/members", canRead, organizationHandler.GetMembers)
+	organizations.Post("/:id/invites", canWrite, organizationHandler.InviteMember)
+	organizations.Post("/:id/leave", canWrite, organizationHandler.LeaveOrganization)
+	organizations.Delete("/:id/members/:userId", canWrite, organizationHandler.RemoveMember)
+}
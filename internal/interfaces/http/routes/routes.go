@@ -2,43 +2,77 @@ package routes
 
 import (
 	"encoding/json"
+	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 	"tasius.my.id/todolistapi/internal/config"
-	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+	"tasius.my.id/todolistapi/internal/domain/repositories"
+	"tasius.my.id/todolistapi/internal/domain/services"
+	"tasius.my.id/todolistapi/internal/infrastructure/fieldcrypto"
+	infrarepositories "tasius.my.id/todolistapi/internal/infrastructure/repositories"
 	"tasius.my.id/todolistapi/internal/utils"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+	"tasius.my.id/todolistapi/internal/utils/health"
 	"tasius.my.id/todolistapi/internal/utils/jwt"
+	"tasius.my.id/todolistapi/internal/utils/lifecycle"
+	"tasius.my.id/todolistapi/internal/utils/lockout"
 )
 
 type RoutesDependencies struct {
-	Db          *gorm.DB
-	RedisClient *redis.Client
-	Config      *config.Config
-	JWTManager  *jwt.TokenManager
+	Db           *gorm.DB
+	RedisClient  *redis.Client
+	Config       *config.Config
+	JWTManager   *jwt.TokenManager
+	Mailer       services.Mailer
+	LoginTracker *lockout.AttemptTracker
+	Keyring      *crypto.Keyring
+	// Tasks tracks background work of requests, shutdown waits for it
+	Tasks *lifecycle.Tasks
+	// FieldEncryptor encrypts todo contents at rest, nil when field encryption is off
+	FieldEncryptor *fieldcrypto.Encryptor
+	// Health runs the readiness checks, shutdown marks it as draining
+	Health *health.Checker
+	// Logger writes structured logs, log with the request's user context to include its fields
+	Logger *slog.Logger
 }
 
-
-
-func SetupRoutes(app *fiber.App, deps RoutesDependencies)  {
+func SetupRoutes(app *fiber.App, deps RoutesDependencies) {
 	api := app.Group("/api")
 
-	api.Get("/health", func(c *fiber.Ctx) error {
-		return utils.SuccessResponse(c, "OK", nil)
-	})
+	SetupHealthRoutes(app, api, deps)
+	SetupMetricsRoutes(app, deps)
 
-	if deps.Config.AppEnv == "development" {
-		api.Post("/decrypt", middleware.DecryptMiddleware(deps.Config.HybridEncryption.PrivateKeyPath), func(c *fiber.Ctx) error {
+	if deps.Config.AppEnv == config.EnvDevelopment {
+		api.Post("/decrypt", decryptMiddleware(deps), func(c *fiber.Ctx) error {
 			var result map[string]interface{}
 			if err := json.Unmarshal(c.Body(), &result); err != nil {
-				return utils.ErrorResponse(c, fiber.StatusBadRequest, "failed to unmarshal JSON data")
+				return apperrors.Validation("invalid_json", "failed to unmarshal JSON data")
 			}
 
 			return utils.SuccessResponse(c, "OK", result)
 		})
 	}
 
+	SetupEncryptionRoutes(api, deps)
 	SetupAuthRoutes(api, deps)
 	SetupTodoRoutes(api, deps)
-}
\ No newline at end of file
+	SetupUserRoutes(api, deps)
+	SetupAdminRoutes(api, deps)
+	SetupOrganizationRoutes(api, deps)
+}
+
+// newTodoRepository returns the todo repository, encrypting todo contents when field encryption is on
+func newTodoRepository(deps RoutesDependencies) repositories.TodoRepository {
+	return infrarepositories.NewEncryptedTodoRepository(infrarepositories.NewTodoRepository(deps.Db), deps.FieldEncryptor)
+}
+
+// fieldEncryptor returns the field encryptor, or a nil interface when field encryption is off
+func fieldEncryptor(deps RoutesDependencies) services.FieldEncryptor {
+	if deps.FieldEncryptor == nil {
+		return nil
+	}
+	return deps.FieldEncryptor
+}
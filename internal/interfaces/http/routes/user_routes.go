@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"tasius.my.id/todolistapi/internal/application/services"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/infrastructure/repositories"
+	"tasius.my.id/todolistapi/internal/interfaces/http/handlers"
+	"tasius.my.id/todolistapi/internal/interfaces/http/middleware"
+)
+
+func SetupUserRoutes(api fiber.Router, deps RoutesDependencies) {
+
+	userRepo := repositories.NewUserRepository(deps.Db)
+	todoRepo := newTodoRepository(deps)
+	userService := services.NewUserService(userRepo, todoRepo, deps.RedisClient, deps.JWTManager, deps.Mailer, &deps.Config.EmailVerification)
+	userHandler := handlers.NewUserHandler(userService)
+
+	apiKeyRepo := repositories.NewAPIKeyRepository(deps.Db)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	canRead := middleware.RequireScopes(entities.ScopeProfileRead)
+	canWrite := middleware.RequireScopes(entities.ScopeProfileWrite)
+
+	me := api.Group("/me", authMiddleware(deps))
+	me.Get("", canRead, userHandler.GetProfile)
+	me.Patch("", canWrite, userHandler.UpdateProfile)
+	me.Post("/email/verify", canWrite, userHandler.VerifyEmailChange)
+
+	// Endpoints carrying passwords use the same hybrid encryption as the auth routes
+	decrypt := decryptMiddleware(deps)
+	encrypt := middleware.EncryptResponseMiddleware(deps.Logger)
+	me.Post("/password", canWrite, decrypt, encrypt, userHandler.ChangePassword)
+	me.Delete("", canWrite, decrypt, encrypt, userHandler.DeleteAccount)
+
+	// API keys can't be used to manage API keys
+	apiKeys := me.Group("/api-keys", middleware.RequireJWT(), canWrite)
+	apiKeys.Get("", apiKeyHandler.GetAllAPIKeys)
+	apiKeys.Post("", apiKeyHandler.CreateAPIKey)
+	apiKeys.Delete("/:id", apiKeyHandler.RevokeAPIKey)
+}
@@ -0,0 +1,49 @@
+package validators
+
+import (
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// messages are the messages of the custom rules by language. {0} is the field, {1} the parameter
+// of the rule, for enums the values it allows.
+var messages = map[string]map[string]string{
+	"en": {
+		"enum":     "{0} must be one of [{1}]",
+		"timezone": "{0} must be a valid time zone, e.g. Asia/Jakarta",
+		"rrule":    "{0} must be a valid recurrence rule, e.g. FREQ=WEEKLY;BYDAY=MO",
+	},
+	"id": {
+		"enum":     "{0} harus berupa salah satu dari [{1}]",
+		"timezone": "{0} harus berupa zona waktu yang valid, misalnya Asia/Jakarta",
+		"rrule":    "{0} harus berupa aturan pengulangan yang valid, misalnya FREQ=WEEKLY;BYDAY=MO",
+	},
+}
+
+func registerMessages(validate *validator.Validate, translator ut.Translator, language string) error {
+	for tag, message := range messages[language] {
+		register := func(translator ut.Translator) error {
+			// Replaces the messages of the validator package for rules overridden here, e.g. timezone
+			return translator.Add(tag, message, true)
+		}
+		if err := validate.RegisterTranslation(tag, translator, register, translate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func translate(translator ut.Translator, fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+	if values, ok := enums[param]; ok && fieldErr.Tag() == "enum" {
+		param = strings.Join(values, " ")
+	}
+
+	message, err := translator.T(fieldErr.Tag(), fieldErr.Field(), param)
+	if err != nil {
+		return fieldErr.Error()
+	}
+	return message
+}
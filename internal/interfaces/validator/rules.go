@@ -0,0 +1,159 @@
+package validators
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	// Embedded so time zones validate without the zoneinfo of the host
+	_ "time/tzdata"
+
+	"github.com/go-playground/validator/v10"
+	"tasius.my.id/todolistapi/internal/domain/entities"
+)
+
+// rules are the custom rules, on top of those of the validator package, e.g. "uuid" and "email"
+var rules = map[string]validator.Func{
+	"enum":     isEnumValue,
+	"timezone": isTimezone,
+	"rrule":    isRRuleField,
+}
+
+// enums are the sets of values the enum rule checks against, e.g. `validate:"enum=role"`. They come
+// from the entities, so adding a value there allows it in requests.
+var enums = map[string][]string{
+	"role": {entities.RoleUser, entities.RoleAdmin},
+	// Owners are only made by creating an organization
+	"organization_role": {entities.OrgRoleAdmin, entities.OrgRoleMember},
+	"scope":             entities.UserScopes,
+}
+
+func isEnumValue(fl validator.FieldLevel) bool {
+	values, ok := enums[fl.Param()]
+	if !ok {
+		panic("validators: unknown enum " + fl.Param())
+	}
+	return slices.Contains(values, fl.Field().String())
+}
+
+// isTimezone accepts IANA time zone names, e.g. "Asia/Jakarta". Unlike time.LoadLocation it
+// rejects "Local", whose meaning depends on the server.
+func isTimezone(fl validator.FieldLevel) bool {
+	name := fl.Field().String()
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := time.LoadLocation(name)
+	return err == nil
+}
+
+func isRRuleField(fl validator.FieldLevel) bool {
+	return isRRule(fl.Field().String())
+}
+
+var (
+	rruleFrequencies  = []string{"SECONDLY", "MINUTELY", "HOURLY", "DAILY", "WEEKLY", "MONTHLY", "YEARLY"}
+	rruleWeekdays     = []string{"MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+	rruleUntilLayouts = []string{"20060102", "20060102T150405", "20060102T150405Z"}
+)
+
+// isRRule reports whether value is a recurrence rule of RFC 5545, e.g. "FREQ=WEEKLY;BYDAY=MO,FR",
+// with or without the "RRULE:" prefix
+func isRRule(value string) bool {
+	parts := make(map[string]string)
+	for _, part := range strings.Split(strings.TrimPrefix(value, "RRULE:"), ";") {
+		name, partValue, ok := strings.Cut(part, "=")
+		if !ok || partValue == "" {
+			return false
+		}
+		if _, duplicate := parts[name]; duplicate {
+			return false
+		}
+		parts[name] = partValue
+	}
+
+	if !slices.Contains(rruleFrequencies, parts["FREQ"]) {
+		return false
+	}
+	// A rule ends after a number of occurrences or at a time, not both
+	if parts["COUNT"] != "" && parts["UNTIL"] != "" {
+		return false
+	}
+
+	for name, partValue := range parts {
+		if !isRRulePart(name, partValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func isRRulePart(name, value string) bool {
+	switch name {
+	case "FREQ":
+		return true
+	case "UNTIL":
+		return slices.ContainsFunc(rruleUntilLayouts, func(layout string) bool {
+			_, err := time.Parse(layout, value)
+			return err == nil
+		})
+	case "COUNT", "INTERVAL":
+		n, err := strconv.Atoi(value)
+		return err == nil && n > 0
+	case "WKST":
+		return slices.Contains(rruleWeekdays, value)
+	case "BYDAY":
+		return allOf(value, isRRuleWeekday)
+	case "BYMONTH":
+		return allOf(value, inRange(1, 12, false))
+	case "BYMONTHDAY":
+		return allOf(value, inRange(1, 31, true))
+	case "BYYEARDAY", "BYSETPOS":
+		return allOf(value, inRange(1, 366, true))
+	case "BYWEEKNO":
+		return allOf(value, inRange(1, 53, true))
+	case "BYHOUR":
+		return allOf(value, inRange(0, 23, false))
+	case "BYMINUTE":
+		return allOf(value, inRange(0, 59, false))
+	case "BYSECOND":
+		// 60 is a leap second
+		return allOf(value, inRange(0, 60, false))
+	default:
+		return false
+	}
+}
+
+// isRRuleWeekday accepts a weekday with an optional occurrence, e.g. "MO", "2TU" or "-1FR"
+func isRRuleWeekday(value string) bool {
+	if len(value) < 2 || !slices.Contains(rruleWeekdays, value[len(value)-2:]) {
+		return false
+	}
+	occurrence := value[:len(value)-2]
+	return occurrence == "" || inRange(1, 53, true)(occurrence)
+}
+
+// allOf reports whether every value of a comma separated list is valid
+func allOf(list string, valid func(string) bool) bool {
+	for _, value := range strings.Split(list, ",") {
+		if !valid(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// inRange accepts integers between min and max, and their negatives if signed, e.g. -1 for the last
+// day of the month
+func inRange(min, max int, signed bool) func(string) bool {
+	return func(value string) bool {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return false
+		}
+		if signed && n < 0 {
+			n = -n
+		}
+		return n >= min && n <= max
+	}
+}
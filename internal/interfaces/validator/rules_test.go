@@ -0,0 +1,72 @@
+package validators
+
+import "testing"
+
+func TestIsRRule(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"FREQ=DAILY", true},
+		{"RRULE:FREQ=WEEKLY;BYDAY=MO,FR", true},
+		{"FREQ=MONTHLY;BYDAY=2TU,-1FR", true},
+		{"FREQ=MONTHLY;BYMONTHDAY=-1", true},
+		{"FREQ=YEARLY;BYMONTH=1,12;BYYEARDAY=-366", true},
+		{"FREQ=WEEKLY;INTERVAL=2;COUNT=10;WKST=SU", true},
+		{"FREQ=DAILY;UNTIL=20261231", true},
+		{"FREQ=DAILY;UNTIL=20261231T235959Z", true},
+		{"FREQ=MINUTELY;BYHOUR=0,23;BYMINUTE=59;BYSECOND=60", true},
+		{"", false},
+		{"BYDAY=MO", false},
+		{"FREQ=FORTNIGHTLY", false},
+		{"freq=daily", false},
+		{"FREQ=DAILY;", false},
+		{"FREQ=DAILY;COUNT", false},
+		{"FREQ=DAILY;FREQ=WEEKLY", false},
+		{"FREQ=DAILY;COUNT=5;UNTIL=20261231", false},
+		{"FREQ=DAILY;COUNT=0", false},
+		{"FREQ=DAILY;INTERVAL=-1", false},
+		{"FREQ=DAILY;UNTIL=2026-12-31", false},
+		{"FREQ=WEEKLY;BYDAY=MONDAY", false},
+		{"FREQ=WEEKLY;BYDAY=0MO", false},
+		{"FREQ=WEEKLY;WKST=2MO", false},
+		{"FREQ=YEARLY;BYMONTH=13", false},
+		{"FREQ=YEARLY;BYMONTH=-1", false},
+		{"FREQ=MONTHLY;BYMONTHDAY=32", false},
+		{"FREQ=YEARLY;BYWEEKNO=54", false},
+		{"FREQ=DAILY;BYHOUR=24", false},
+		{"FREQ=DAILY;BYDAY=MO,,FR", false},
+		{"FREQ=DAILY;X-NAME=value", false},
+	}
+
+	for _, tt := range tests {
+		if got := isRRule(tt.value); got != tt.want {
+			t.Errorf("isRRule(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsTimezone(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"Asia/Jakarta", true},
+		{"America/Argentina/Buenos_Aires", true},
+		{"UTC", true},
+		{"", false},
+		{"Local", false},
+		{"Mars/Olympus_Mons", false},
+		{"asia/jakarta", false},
+		{"+07:00", false},
+		{"../../etc/passwd", false},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		err := v.Var("timezone", tt.value, "timezone", "en")
+		if got := err == nil; got != tt.want {
+			t.Errorf("timezone %q valid = %v, want %v (error = %v)", tt.value, got, tt.want, err)
+		}
+	}
+}
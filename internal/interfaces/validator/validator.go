@@ -0,0 +1,119 @@
+// Package validators checks requests against the validate tags of their DTOs. Problems are keyed by
+// the field as the client sent it, e.g. "scopes[1]", with messages in the client's language.
+package validators
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	idtranslations "github.com/go-playground/validator/v10/translations/id"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+)
+
+// Languages are the languages of messages, the first one is used when the client accepts none of them
+var Languages = []string{"en", "id"}
+
+type Validator struct {
+	validate    *validator.Validate
+	translators *ut.UniversalTranslator
+}
+
+// New creates a validator with the custom rules and the messages of every language. It panics if
+// a rule or message can't be registered, which is a programming error.
+func New() *Validator {
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	validate.RegisterTagNameFunc(fieldName)
+
+	for tag, rule := range rules {
+		if err := validate.RegisterValidation(tag, rule); err != nil {
+			panic("validators: failed to register rule " + tag + ": " + err.Error())
+		}
+	}
+
+	translators := ut.New(en.New(), en.New(), id.New())
+	registerDefaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": entranslations.RegisterDefaultTranslations,
+		"id": idtranslations.RegisterDefaultTranslations,
+	}
+	for _, language := range Languages {
+		translator, _ := translators.GetTranslator(language)
+		if err := registerDefaults[language](validate, translator); err != nil {
+			panic("validators: failed to register " + language + " messages: " + err.Error())
+		}
+		if err := registerMessages(validate, translator, language); err != nil {
+			panic("validators: failed to register " + language + " messages: " + err.Error())
+		}
+	}
+
+	return &Validator{
+		validate:    validate,
+		translators: translators,
+	}
+}
+
+// Struct validates req, with messages in the given language. It returns a validation failed error
+// keyed by field, or nil if req is valid.
+func (v *Validator) Struct(req any, language string) error {
+	err := v.validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
+	}
+
+	translator, _ := v.translators.GetTranslator(language)
+	fields := make(map[string]string, len(validationErrors))
+	for _, fieldErr := range validationErrors {
+		key := fieldKey(fieldErr.Namespace())
+		if _, exists := fields[key]; !exists {
+			fields[key] = fieldErr.Translate(translator)
+		}
+	}
+
+	return apperrors.ValidationFailed(fields)
+}
+
+// Var validates a single value against tag, e.g. a path parameter, reporting problems under field.
+// The value is validated as the field of a struct, so messages name it.
+func (v *Validator) Var(field string, value any, tag, language string) error {
+	structType := reflect.StructOf([]reflect.StructField{{
+		Name: "Value",
+		Type: reflect.TypeOf(value),
+		Tag:  reflect.StructTag(fmt.Sprintf("json:%q validate:%q", field, tag)),
+	}})
+	req := reflect.New(structType)
+	req.Elem().Field(0).Set(reflect.ValueOf(value))
+	return v.Struct(req.Interface(), language)
+}
+
+// fieldName names fields after their json, query or params tag, the names clients send them by
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "params"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// fieldKey drops the name of the validated struct from a namespace, e.g.
+// "CreateAPIKeyRequest.scopes[1]" becomes "scopes[1]"
+func fieldKey(namespace string) string {
+	if _, key, ok := strings.Cut(namespace, "."); ok {
+		return key
+	}
+	return namespace
+}
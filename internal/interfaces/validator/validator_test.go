@@ -0,0 +1,117 @@
+package validators
+
+import (
+	"errors"
+	"maps"
+	"testing"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+)
+
+type scheduleRequest struct {
+	Timezone   string `json:"timezone" validate:"required,timezone"`
+	Recurrence string `query:"recurrence" validate:"omitempty,rrule"`
+	Internal   string `json:"-" validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      any
+		language string
+		want     map[string]string
+	}{
+		{
+			name:     "valid",
+			req:      &dto.CreateAPIKeyRequest{Name: "CI pipeline", Scopes: []string{"todos:read"}},
+			language: "en",
+		},
+		{
+			name:     "element of a list in English",
+			req:      &dto.CreateAPIKeyRequest{Scopes: []string{"todos:read", "todos:delete"}},
+			language: "en",
+			want: map[string]string{
+				"name":      "name is a required field",
+				"scopes[1]": "scopes[1] must be one of [todos:read todos:write profile:read profile:write]",
+			},
+		},
+		{
+			name:     "element of a list in Indonesian",
+			req:      &dto.CreateAPIKeyRequest{Scopes: []string{"todos:read", "todos:delete"}},
+			language: "id",
+			want: map[string]string{
+				"name":      "name wajib diisi",
+				"scopes[1]": "scopes[1] harus berupa salah satu dari [todos:read todos:write profile:read profile:write]",
+			},
+		},
+		{
+			name:     "unsupported language falls back to English",
+			req:      &dto.CreateAPIKeyRequest{Name: "CI pipeline"},
+			language: "fr",
+			want:     map[string]string{"scopes": "scopes is a required field"},
+		},
+		{
+			name:     "custom rules in English",
+			req:      &scheduleRequest{Timezone: "Local", Recurrence: "FREQ=FORTNIGHTLY", Internal: "set"},
+			language: "en",
+			want: map[string]string{
+				"timezone":   "timezone must be a valid time zone, e.g. Asia/Jakarta",
+				"recurrence": "recurrence must be a valid recurrence rule, e.g. FREQ=WEEKLY;BYDAY=MO",
+			},
+		},
+		{
+			name:     "custom rules in Indonesian",
+			req:      &scheduleRequest{Timezone: "Local", Recurrence: "FREQ=FORTNIGHTLY", Internal: "set"},
+			language: "id",
+			want: map[string]string{
+				"timezone":   "timezone harus berupa zona waktu yang valid, misalnya Asia/Jakarta",
+				"recurrence": "recurrence harus berupa aturan pengulangan yang valid, misalnya FREQ=WEEKLY;BYDAY=MO",
+			},
+		},
+		{
+			name:     "field without a name keeps the field name",
+			req:      &scheduleRequest{Timezone: "Asia/Jakarta"},
+			language: "en",
+			want:     map[string]string{"Internal": "Internal is a required field"},
+		},
+	}
+
+	v := New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Struct(tt.req, tt.language)
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("Struct() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var appErr *apperrors.Error
+			if !errors.As(err, &appErr) || appErr.Code != apperrors.CodeValidationFailed {
+				t.Fatalf("Struct() error = %v, want %s", err, apperrors.CodeValidationFailed)
+			}
+			if !maps.Equal(appErr.Errors, tt.want) {
+				t.Errorf("Struct() errors = %v, want %v", appErr.Errors, tt.want)
+			}
+		})
+	}
+}
+
+func TestVar(t *testing.T) {
+	v := New()
+
+	if err := v.Var("id", "0b9f3c4e-8a43-4f43-9a4b-9f0e6c1d2a7b", "uuid", "en"); err != nil {
+		t.Errorf("Var() error = %v, want nil", err)
+	}
+
+	err := v.Var("id", "not-a-uuid", "uuid", "id")
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		t.Fatalf("Var() error = %v, want a validation error", err)
+	}
+	if want := map[string]string{"id": "id harus berupa UUID yang valid"}; !maps.Equal(appErr.Errors, want) {
+		t.Errorf("Var() errors = %v, want %v", appErr.Errors, want)
+	}
+}
@@ -9,82 +9,136 @@ import (
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 )
 
 // GenerateRSAKeyPair generates a new RSA key pair
 func GenerateRSAKeyPair() (*rsa.PrivateKey, *rsa.PublicKey, error) {
-	privateKey, err := rsa.GenerateKey(rand.Reader, 4096)
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
 	if err != nil {
 		return nil, nil, err
 	}
 	return privateKey, &privateKey.PublicKey, nil
 }
 
-// SavePEMKey saves a key to a PEM file
-func SavePEMKey(filename string, key *rsa.PrivateKey) error {
-	file, err := os.Create(filename)
+// SavePrivateKey saves a private key to a PKCS#8 PEM file, encrypted with the passphrase if one is given
+func SavePrivateKey(filename string, key PrivateKey, passphrase []byte) error {
+	der, err := key.MarshalPKCS8()
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	privateKeyBytes := x509.MarshalPKCS1PrivateKey(key)
-	privateKeyBlock := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: privateKeyBytes,
+	block := &pem.Block{
+		Type:  "PRIVATE KEY",
+		Bytes: der,
 	}
 
-	if err := pem.Encode(file, privateKeyBlock); err != nil {
-		return err
+	if len(passphrase) > 0 {
+		encrypted, err := encryptPKCS8(der, passphrase)
+		if err != nil {
+			return err
+		}
+		block = &pem.Block{
+			Type:  "ENCRYPTED PRIVATE KEY",
+			Bytes: encrypted,
+		}
 	}
 
-	return nil
+	return os.WriteFile(filename, pem.EncodeToMemory(block), 0600)
 }
 
-// SavePublicPEMKey saves a public key to a PEM file
-func SavePublicPEMKey(filename string, pubkey *rsa.PublicKey) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	publicKeyBytes, err := x509.MarshalPKIXPublicKey(pubkey)
+// SavePublicKey saves a public key to a PEM file
+func SavePublicKey(filename string, key PublicKey) error {
+	publicKeyPEM, err := EncodePublicKeyPEM(key)
 	if err != nil {
 		return err
 	}
 
-	publicKeyBlock := &pem.Block{
-		Type:  "PUBLIC KEY",
-		Bytes: publicKeyBytes,
-	}
+	return os.WriteFile(filename, []byte(publicKeyPEM), 0644)
+}
 
-	if err := pem.Encode(file, publicKeyBlock); err != nil {
-		return err
+// EncodePublicKeyPEM encodes a public key as a PKIX "PUBLIC KEY" PEM block
+func EncodePublicKeyPEM(key PublicKey) (string, error) {
+	der, err := key.MarshalPKIX()
+	if err != nil {
+		return "", err
 	}
 
-	return nil
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
 }
 
-// LoadPrivateKey loads a private key from a PEM file
-func LoadPrivateKey(filename string) (*rsa.PrivateKey, error) {
+// LoadPrivateKey loads a private key from a PEM file. PKCS#1, SEC1 and PKCS#8 keys are supported,
+// encrypted keys are decrypted with the passphrase.
+func LoadPrivateKey(filename string, passphrase []byte) (PrivateKey, error) {
 	keyData, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
 	}
 
+	return ParsePrivateKeyPEM(keyData, passphrase)
+}
+
+// ParsePrivateKeyPEM parses a PEM encoded private key, see LoadPrivateKey
+func ParsePrivateKeyPEM(keyData, passphrase []byte) (PrivateKey, error) {
 	block, _ := pem.Decode(keyData)
-	if block == nil || block.Type != "RSA PRIVATE KEY" {
+	if block == nil {
 		return nil, errors.New("failed to decode PEM block containing private key")
 	}
 
-	return x509.ParsePKCS1PrivateKey(block.Bytes)
+	der := block.Bytes
+	encrypted := block.Type == "ENCRYPTED PRIVATE KEY" || x509.IsEncryptedPEMBlock(block)
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		if len(passphrase) == 0 {
+			return nil, errors.New("private key is encrypted, a passphrase is required")
+		}
+
+		decrypted, err := decryptPKCS8(der, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		der = decrypted
+	} else if x509.IsEncryptedPEMBlock(block) {
+		// Legacy OpenSSL encryption (Proc-Type: 4,ENCRYPTED) of PKCS#1 and SEC1 keys
+		if len(passphrase) == 0 {
+			return nil, errors.New("private key is encrypted, a passphrase is required")
+		}
+
+		decrypted, err := x509.DecryptPEMBlock(block, passphrase)
+		if err != nil {
+			return nil, errIncorrectPassphrase
+		}
+		der = decrypted
+	}
+
+	var (
+		key any
+		err error
+	)
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(der)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(der)
+	case "PRIVATE KEY", "ENCRYPTED PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(der)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+	if err != nil {
+		if encrypted {
+			// A wrong passphrase leaves valid padding now and then, the garbage it decrypts to doesn't parse
+			return nil, errIncorrectPassphrase
+		}
+		return nil, err
+	}
+
+	return NewPrivateKey(key)
 }
 
 // LoadPublicKey loads a public key from a PEM file
-func LoadPublicKey(filename string) (*rsa.PublicKey, error) {
+func LoadPublicKey(filename string) (PublicKey, error) {
 	keyData, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, err
@@ -95,17 +149,17 @@ func LoadPublicKey(filename string) (*rsa.PublicKey, error) {
 		return nil, errors.New("failed to decode PEM block containing public key")
 	}
 
-	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	return ParsePublicKey(block.Bytes)
+}
+
+// ParsePublicKey parses a DER encoded PKIX public key, RSA, X25519 and P-256 keys are supported
+func ParsePublicKey(der []byte) (PublicKey, error) {
+	pub, err := x509.ParsePKIXPublicKey(der)
 	if err != nil {
 		return nil, err
 	}
 
-	switch pub := pub.(type) {
-	case *rsa.PublicKey:
-		return pub, nil
-	default:
-		return nil, errors.New("key type is not RSA")
-	}
+	return NewPublicKey(pub)
 }
 
 // EncryptWithPublicKey encrypts data with RSA public key
@@ -137,8 +191,9 @@ func GenerateAESKey() ([]byte, error) {
 	return key, nil
 }
 
-// EncryptAES encrypts data using AES-GCM
-func EncryptAES(key, plaintext []byte) ([]byte, error) {
+// EncryptAES encrypts data using AES-GCM. The additional data is authenticated but not encrypted,
+// decryption fails unless the same additional data is passed to DecryptAES.
+func EncryptAES(key, plaintext, additionalData []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -154,12 +209,12 @@ func EncryptAES(key, plaintext []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	ciphertext := aesgcm.Seal(nil, nonce, plaintext, nil)
+	ciphertext := aesgcm.Seal(nil, nonce, plaintext, additionalData)
 	return append(nonce, ciphertext...), nil
 }
 
 // DecryptAES decrypts data using AES-GCM
-func DecryptAES(key, data []byte) ([]byte, error) {
+func DecryptAES(key, data, additionalData []byte) ([]byte, error) {
 	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
@@ -176,7 +231,7 @@ func DecryptAES(key, data []byte) ([]byte, error) {
 	}
 
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
-	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, nil)
+	plaintext, err := aesgcm.Open(nil, nonce, ciphertext, additionalData)
 	if err != nil {
 		return nil, err
 	}
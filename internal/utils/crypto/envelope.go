@@ -0,0 +1,147 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EnvelopeVersion prefixes every envelope, the legacy format has no version
+	EnvelopeVersion = "v1"
+
+	envelopeParts = 6
+	nonceSize     = 16
+)
+
+var envelopeEncoding = base64.RawURLEncoding
+
+// Envelope is a hybrid encrypted request bound to the key it was encrypted for, the time it was
+// created and the request it belongs to. Its compact form is
+// v1.<key_id>.<timestamp>.<nonce>.<encrypted_key>.<ciphertext> with base64url (unpadded) binary parts.
+// The encrypted key is the encapsulated key of the recipient's algorithm, see PublicKey.
+type Envelope struct {
+	KeyID        string
+	Timestamp    time.Time
+	Nonce        string
+	EncryptedKey []byte
+	Ciphertext   []byte
+}
+
+// IsEnvelope reports whether data uses the versioned envelope instead of the legacy hex format
+func IsEnvelope(data string) bool {
+	return strings.HasPrefix(data, EnvelopeVersion+".")
+}
+
+// ParseEnvelope decodes the compact form of an envelope
+func ParseEnvelope(data string) (*Envelope, error) {
+	parts := strings.Split(data, ".")
+	if len(parts) != envelopeParts || parts[0] != EnvelopeVersion {
+		return nil, errors.New("invalid envelope format. Expected format: v1.<key_id>.<timestamp>.<nonce>.<encrypted_key>.<ciphertext>")
+	}
+
+	if parts[1] == "" {
+		return nil, errors.New("invalid envelope key ID")
+	}
+
+	timestamp, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid envelope timestamp")
+	}
+
+	nonce, err := envelopeEncoding.DecodeString(parts[3])
+	if err != nil || len(nonce) != nonceSize {
+		return nil, errors.New("invalid envelope nonce")
+	}
+
+	encryptedKey, err := envelopeEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, errors.New("invalid envelope encrypted key: not valid base64url")
+	}
+
+	ciphertext, err := envelopeEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, errors.New("invalid envelope ciphertext: not valid base64url")
+	}
+
+	return &Envelope{
+		KeyID:        parts[1],
+		Timestamp:    time.Unix(timestamp, 0),
+		Nonce:        parts[3],
+		EncryptedKey: encryptedKey,
+		Ciphertext:   ciphertext,
+	}, nil
+}
+
+// String returns the compact form of the envelope
+func (e *Envelope) String() string {
+	return strings.Join([]string{
+		EnvelopeVersion,
+		e.KeyID,
+		strconv.FormatInt(e.Timestamp.Unix(), 10),
+		e.Nonce,
+		envelopeEncoding.EncodeToString(e.EncryptedKey),
+		envelopeEncoding.EncodeToString(e.Ciphertext),
+	}, ".")
+}
+
+// AdditionalData is authenticated by AES-GCM, so the ciphertext can't be moved to another
+// endpoint or have its key ID, timestamp or nonce swapped
+func (e *Envelope) AdditionalData(method, path string) []byte {
+	return []byte(strings.Join([]string{
+		EnvelopeVersion,
+		strings.ToUpper(method),
+		path,
+		e.KeyID,
+		strconv.FormatInt(e.Timestamp.Unix(), 10),
+		e.Nonce,
+	}, "\n"))
+}
+
+// SealEnvelope encrypts plaintext for the public key with the given ID, bound to the request method and path.
+// The AES session key is returned so the caller can decrypt the response.
+func SealEnvelope(publicKey PublicKey, keyID, method, path string, plaintext []byte) (*Envelope, []byte, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	aesKey, encryptedKey, err := publicKey.Encapsulate()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt AES key: %w", err)
+	}
+
+	envelope := &Envelope{
+		KeyID:        keyID,
+		Timestamp:    time.Now(),
+		Nonce:        envelopeEncoding.EncodeToString(nonce),
+		EncryptedKey: encryptedKey,
+	}
+
+	envelope.Ciphertext, err = EncryptAES(aesKey, plaintext, envelope.AdditionalData(method, path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt message: %w", err)
+	}
+
+	return envelope, aesKey, nil
+}
+
+// Open decrypts the envelope with the private key, failing if it was sealed for another method or path.
+// The AES session key is returned along with the plaintext.
+func (e *Envelope) Open(privateKey PrivateKey, method, path string) ([]byte, []byte, error) {
+	aesKey, err := privateKey.Decapsulate(e.EncryptedKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt AES key: %w", err)
+	}
+
+	plaintext, err := DecryptAES(aesKey, e.Ciphertext, e.AdditionalData(method, path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt message: %w", err)
+	}
+
+	return plaintext, aesKey, nil
+}
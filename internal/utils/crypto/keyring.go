@@ -0,0 +1,179 @@
+package crypto
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// keyIDLength is the number of hex characters of the public key fingerprint used as key ID
+const keyIDLength = 16
+
+// Keyring holds the private keys requests can be encrypted for. The first key is the
+// current one published to clients, the others are previous keys still accepted during a rotation.
+type Keyring struct {
+	paths      []string
+	passphrase []byte
+
+	mu       sync.RWMutex
+	keys     map[string]PrivateKey
+	order    []string
+	modTimes []time.Time
+}
+
+// NewKeyring loads the current private key followed by any previous keys, encrypted keys
+// are decrypted with the passphrase
+func NewKeyring(passphrase []byte, currentPath string, previousPaths ...string) (*Keyring, error) {
+	k := &Keyring{
+		paths:      append([]string{currentPath}, previousPaths...),
+		passphrase: passphrase,
+	}
+	if err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// KeyID derives the ID clients put in front of their payload from the public key fingerprint
+func KeyID(publicKey PublicKey) (string, error) {
+	der, err := publicKey.MarshalPKIX()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])[:keyIDLength], nil
+}
+
+// Reload reads all key files again. On error the previously loaded keys stay in use.
+func (k *Keyring) Reload() error {
+	keys := make(map[string]PrivateKey, len(k.paths))
+	order := make([]string, 0, len(k.paths))
+	modTimes := make([]time.Time, 0, len(k.paths))
+
+	for _, path := range k.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return fmt.Errorf("failed to load private key %s: %w", path, err)
+		}
+
+		privateKey, err := LoadPrivateKey(path, k.passphrase)
+		if err != nil {
+			return fmt.Errorf("failed to load private key %s: %w", path, err)
+		}
+
+		id, err := KeyID(privateKey.Public())
+		if err != nil {
+			return err
+		}
+		if _, ok := keys[id]; !ok {
+			keys[id] = privateKey
+			order = append(order, id)
+		}
+		modTimes = append(modTimes, info.ModTime())
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.order = order
+	k.modTimes = modTimes
+	k.mu.Unlock()
+
+	return nil
+}
+
+// Current returns the ID and key new requests should be encrypted for
+func (k *Keyring) Current() (string, PrivateKey) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	id := k.order[0]
+	return id, k.keys[id]
+}
+
+// Get returns the key with the given ID
+func (k *Keyring) Get(id string) (PrivateKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	key, ok := k.keys[id]
+	return key, ok
+}
+
+// All returns every active key, current key first
+func (k *Keyring) All() []PrivateKey {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	keys := make([]PrivateKey, 0, len(k.order))
+	for _, id := range k.order {
+		keys = append(keys, k.keys[id])
+	}
+	return keys
+}
+
+// Watch reloads the keys on SIGHUP and whenever one of the key files changes on disk,
+// checking every interval. It returns when ctx is done.
+func (k *Keyring) Watch(ctx context.Context, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			k.reload("SIGHUP")
+		case <-tick:
+			if k.changed() {
+				k.reload("key file change")
+			}
+		}
+	}
+}
+
+func (k *Keyring) reload(reason string) {
+	if err := k.Reload(); err != nil {
+		slog.Warn("Keeping current encryption keys, reload failed", "reason", reason, "error", err)
+		return
+	}
+
+	id, _ := k.Current()
+	slog.Info("Reloaded encryption keys", "reason", reason, "key_id", id)
+}
+
+// changed reports whether a key file has been modified since it was loaded
+func (k *Keyring) changed() bool {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	for i, path := range k.paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			// A file being replaced may be missing for a moment, try again on the next tick
+			if errors.Is(err, os.ErrNotExist) {
+				return false
+			}
+			continue
+		}
+		if !info.ModTime().Equal(k.modTimes[i]) {
+			return true
+		}
+	}
+	return false
+}
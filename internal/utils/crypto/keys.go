@@ -0,0 +1,265 @@
+package crypto
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// Algorithms of the hybrid encryption schemes, published with the public key so clients know how to encrypt
+const (
+	AlgorithmRSA    = "RSA-OAEP-SHA256+AES-256-GCM"
+	AlgorithmX25519 = "ECDH-X25519+HKDF-SHA256+AES-256-GCM"
+	AlgorithmP256   = "ECDH-P256+HKDF-SHA256+AES-256-GCM"
+)
+
+// Key types accepted by GenerateKey
+const (
+	KeyTypeRSA    = "rsa"
+	KeyTypeX25519 = "x25519"
+	KeyTypeP256   = "p256"
+)
+
+const (
+	aesKeySize = 32
+	rsaKeySize = 4096
+	// minRSAKeySize is the smallest RSA key accepted, for our own keys and keys sent by clients
+	minRSAKeySize = 2048
+)
+
+// PublicKey is a key clients encrypt requests for. Encapsulate returns a fresh AES session key and the
+// encapsulated key sent along with the ciphertext: the RSA-OAEP encrypted session key, or for ECDH keys the
+// ephemeral public key the session key is derived from.
+type PublicKey interface {
+	Algorithm() string
+	Encapsulate() (aesKey, encapsulatedKey []byte, err error)
+	MarshalPKIX() ([]byte, error)
+}
+
+// PrivateKey recovers the AES session key from an encapsulated key
+type PrivateKey interface {
+	Public() PublicKey
+	Decapsulate(encapsulatedKey []byte) ([]byte, error)
+	MarshalPKCS8() ([]byte, error)
+}
+
+// GenerateKey generates a private key of the given type
+func GenerateKey(keyType string) (PrivateKey, error) {
+	switch keyType {
+	case KeyTypeRSA:
+		privateKey, _, err := GenerateRSAKeyPair()
+		if err != nil {
+			return nil, err
+		}
+		return &rsaPrivateKey{privateKey}, nil
+	case KeyTypeX25519:
+		return generateECDHKey(ecdh.X25519())
+	case KeyTypeP256:
+		return generateECDHKey(ecdh.P256())
+	default:
+		return nil, fmt.Errorf("unsupported key type %q, use %s, %s or %s", keyType, KeyTypeRSA, KeyTypeX25519, KeyTypeP256)
+	}
+}
+
+func generateECDHKey(curve ecdh.Curve) (PrivateKey, error) {
+	privateKey, err := curve.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdhPrivateKey{privateKey}, nil
+}
+
+// NewPrivateKey wraps a parsed private key, RSA, X25519 and P-256 keys are supported
+func NewPrivateKey(key any) (PrivateKey, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		if err := checkRSAKeySize(&key.PublicKey); err != nil {
+			return nil, err
+		}
+		return &rsaPrivateKey{key}, nil
+	case *ecdsa.PrivateKey:
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return newECDHPrivateKey(ecdhKey)
+	case *ecdh.PrivateKey:
+		return newECDHPrivateKey(key)
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+func newECDHPrivateKey(key *ecdh.PrivateKey) (PrivateKey, error) {
+	if _, err := ecdhAlgorithm(key.Curve()); err != nil {
+		return nil, err
+	}
+	return &ecdhPrivateKey{key}, nil
+}
+
+// NewPublicKey wraps a parsed public key, RSA, X25519 and P-256 keys are supported
+func NewPublicKey(key any) (PublicKey, error) {
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		if err := checkRSAKeySize(key); err != nil {
+			return nil, err
+		}
+		return &rsaPublicKey{key}, nil
+	case *ecdsa.PublicKey:
+		ecdhKey, err := key.ECDH()
+		if err != nil {
+			return nil, err
+		}
+		return newECDHPublicKey(ecdhKey)
+	case *ecdh.PublicKey:
+		return newECDHPublicKey(key)
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+func newECDHPublicKey(key *ecdh.PublicKey) (PublicKey, error) {
+	if _, err := ecdhAlgorithm(key.Curve()); err != nil {
+		return nil, err
+	}
+	return &ecdhPublicKey{key}, nil
+}
+
+func checkRSAKeySize(key *rsa.PublicKey) error {
+	if key.N.BitLen() < minRSAKeySize {
+		return fmt.Errorf("RSA key must be at least %d bits", minRSAKeySize)
+	}
+	return nil
+}
+
+type rsaPublicKey struct {
+	key *rsa.PublicKey
+}
+
+func (k *rsaPublicKey) Algorithm() string {
+	return AlgorithmRSA
+}
+
+func (k *rsaPublicKey) Encapsulate() ([]byte, []byte, error) {
+	aesKey, err := GenerateAESKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encryptedKey, err := EncryptWithPublicKey(k.key, aesKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aesKey, encryptedKey, nil
+}
+
+func (k *rsaPublicKey) MarshalPKIX() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(k.key)
+}
+
+type rsaPrivateKey struct {
+	key *rsa.PrivateKey
+}
+
+func (k *rsaPrivateKey) Public() PublicKey {
+	return &rsaPublicKey{&k.key.PublicKey}
+}
+
+func (k *rsaPrivateKey) Decapsulate(encapsulatedKey []byte) ([]byte, error) {
+	return DecryptWithPrivateKey(k.key, encapsulatedKey)
+}
+
+func (k *rsaPrivateKey) MarshalPKCS8() ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(k.key)
+}
+
+type ecdhPublicKey struct {
+	key *ecdh.PublicKey
+}
+
+func (k *ecdhPublicKey) Algorithm() string {
+	algorithm, _ := ecdhAlgorithm(k.key.Curve())
+	return algorithm
+}
+
+// Encapsulate derives the session key from an ephemeral key pair, the ephemeral public key is the encapsulated key
+func (k *ecdhPublicKey) Encapsulate() ([]byte, []byte, error) {
+	ephemeral, err := k.key.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sharedSecret, err := ephemeral.ECDH(k.key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encapsulatedKey := ephemeral.PublicKey().Bytes()
+	aesKey, err := deriveSessionKey(k.Algorithm(), sharedSecret, encapsulatedKey, k.key.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return aesKey, encapsulatedKey, nil
+}
+
+func (k *ecdhPublicKey) MarshalPKIX() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(k.key)
+}
+
+type ecdhPrivateKey struct {
+	key *ecdh.PrivateKey
+}
+
+func (k *ecdhPrivateKey) Public() PublicKey {
+	return &ecdhPublicKey{k.key.PublicKey()}
+}
+
+func (k *ecdhPrivateKey) Decapsulate(encapsulatedKey []byte) ([]byte, error) {
+	// NewPublicKey rejects points that are not on the curve
+	ephemeral, err := k.key.Curve().NewPublicKey(encapsulatedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral public key: %w", err)
+	}
+
+	sharedSecret, err := k.key.ECDH(ephemeral)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveSessionKey(k.Public().Algorithm(), sharedSecret, encapsulatedKey, k.key.PublicKey().Bytes())
+}
+
+func (k *ecdhPrivateKey) MarshalPKCS8() ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(k.key)
+}
+
+// deriveSessionKey binds the AES key to the algorithm and both public keys, so an encapsulated key
+// can't be reused with another recipient key
+func deriveSessionKey(algorithm string, sharedSecret, ephemeralPublicKey, recipientPublicKey []byte) ([]byte, error) {
+	info := make([]byte, 0, len(algorithm)+1+len(ephemeralPublicKey)+len(recipientPublicKey))
+	info = append(info, algorithm...)
+	info = append(info, 0)
+	info = append(info, ephemeralPublicKey...)
+	info = append(info, recipientPublicKey...)
+
+	return hkdf.Key(sha256.New, sharedSecret, nil, string(info), aesKeySize)
+}
+
+func ecdhAlgorithm(curve ecdh.Curve) (string, error) {
+	switch curve {
+	case ecdh.X25519():
+		return AlgorithmX25519, nil
+	case ecdh.P256():
+		return AlgorithmP256, nil
+	default:
+		return "", errors.New("unsupported curve, use X25519 or P-256")
+	}
+}
@@ -0,0 +1,313 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The keys in testdata were encrypted by OpenSSL with this passphrase, e.g.
+// openssl pkcs8 -topk8 -v2 aes-256-cbc -in key.pem -out rsa_aes256.pem
+const fixturePassphrase = "fixture-passphrase"
+
+func TestPKCS8RoundTrip(t *testing.T) {
+	for _, keyType := range []string{KeyTypeRSA, KeyTypeX25519, KeyTypeP256} {
+		t.Run(keyType, func(t *testing.T) {
+			key, err := GenerateKey(keyType)
+			if err != nil {
+				t.Fatalf("GenerateKey() error = %v", err)
+			}
+			der, err := key.MarshalPKCS8()
+			if err != nil {
+				t.Fatalf("MarshalPKCS8() error = %v", err)
+			}
+
+			encrypted, err := encryptPKCS8(der, []byte("the passphrase"))
+			if err != nil {
+				t.Fatalf("encryptPKCS8() error = %v", err)
+			}
+			if bytes.Contains(encrypted, der) {
+				t.Fatal("encryptPKCS8() output contains the plaintext key")
+			}
+
+			decrypted, err := decryptPKCS8(encrypted, []byte("the passphrase"))
+			if err != nil {
+				t.Fatalf("decryptPKCS8() error = %v", err)
+			}
+			if !bytes.Equal(decrypted, der) {
+				t.Error("decryptPKCS8() returned another key")
+			}
+		})
+	}
+}
+
+func TestSaveAndLoadEncryptedPrivateKey(t *testing.T) {
+	key, err := GenerateKey(KeyTypeX25519)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "private.pem")
+	if err := SavePrivateKey(filename, key, []byte("the passphrase")); err != nil {
+		t.Fatalf("SavePrivateKey() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if block, _ := pem.Decode(data); block == nil || block.Type != "ENCRYPTED PRIVATE KEY" {
+		t.Fatalf("SavePrivateKey() wrote %q, want an ENCRYPTED PRIVATE KEY block", data)
+	}
+
+	loaded, err := LoadPrivateKey(filename, []byte("the passphrase"))
+	if err != nil {
+		t.Fatalf("LoadPrivateKey() error = %v", err)
+	}
+	assertSamePublicKey(t, loaded.Public(), key.Public())
+
+	if _, err := LoadPrivateKey(filename, nil); err == nil || !strings.Contains(err.Error(), "passphrase is required") {
+		t.Errorf("LoadPrivateKey() without a passphrase error = %v, want a passphrase to be required", err)
+	}
+}
+
+func TestLoadOpenSSLEncryptedPrivateKey(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		publicKey  string
+		wantCipher asn1.ObjectIdentifier
+	}{
+		// PBKDF2-HMAC-SHA256 with AES-256-CBC, the default of OpenSSL 3
+		{name: "RSA", filename: "rsa_aes256.pem", publicKey: "rsa_public.pem", wantCipher: oidAES256CBC},
+		{name: "X25519", filename: "x25519_aes256.pem", publicKey: "x25519_public.pem", wantCipher: oidAES256CBC},
+		// The PRF is omitted for HMAC-SHA1, its default
+		{name: "P-256", filename: "p256_aes128_sha1.pem", publicKey: "p256_public.pem", wantCipher: oidAES128CBC},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join("testdata", tt.filename)
+
+			key, err := LoadPrivateKey(filename, []byte(fixturePassphrase))
+			if err != nil {
+				t.Fatalf("LoadPrivateKey() error = %v", err)
+			}
+			publicKey, err := LoadPublicKey(filepath.Join("testdata", tt.publicKey))
+			if err != nil {
+				t.Fatalf("LoadPublicKey() error = %v", err)
+			}
+			assertSamePublicKey(t, key.Public(), publicKey)
+
+			if got := encryptionScheme(t, filename); !got.Equal(tt.wantCipher) {
+				t.Errorf("fixture cipher = %s, want %s", got, tt.wantCipher)
+			}
+
+			// Decrypting the RSA fixture with this passphrase happens to leave valid padding
+			if _, err := LoadPrivateKey(filename, []byte("another passphrase")); !errors.Is(err, errIncorrectPassphrase) {
+				t.Errorf("LoadPrivateKey() with a wrong passphrase error = %v, want %v", err, errIncorrectPassphrase)
+			}
+		})
+	}
+}
+
+func TestDecryptPKCS8Rejects(t *testing.T) {
+	der, err := os.ReadFile(filepath.Join("testdata", "x25519_aes256.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(der)
+
+	tests := []struct {
+		name    string
+		tamper  func(info *encryptedPrivateKeyInfo, params *pbes2Params, kdf *pbkdf2Params)
+		wantErr string
+	}{
+		{
+			name: "other scheme",
+			tamper: func(info *encryptedPrivateKeyInfo, _ *pbes2Params, _ *pbkdf2Params) {
+				info.Algorithm.Algorithm = oidPBKDF2
+			},
+			wantErr: "only PBES2 is supported",
+		},
+		{
+			name: "too many iterations",
+			tamper: func(_ *encryptedPrivateKeyInfo, _ *pbes2Params, kdf *pbkdf2Params) {
+				kdf.IterationCount = maxPBKDF2Iterations + 1
+			},
+			wantErr: "invalid PBKDF2 iteration count",
+		},
+		{
+			name: "unsupported PRF",
+			tamper: func(_ *encryptedPrivateKeyInfo, _ *pbes2Params, kdf *pbkdf2Params) {
+				kdf.PRF.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 5}
+			},
+			wantErr: "unsupported PBKDF2 PRF",
+		},
+		{
+			name: "unsupported cipher",
+			tamper: func(_ *encryptedPrivateKeyInfo, params *pbes2Params, _ *pbkdf2Params) {
+				params.EncryptionScheme.Algorithm = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+			},
+			wantErr: "unsupported private key cipher",
+		},
+		{
+			name: "truncated ciphertext",
+			tamper: func(info *encryptedPrivateKeyInfo, _ *pbes2Params, _ *pbkdf2Params) {
+				info.EncryptedData = info.EncryptedData[:len(info.EncryptedData)-1]
+			},
+			wantErr: "invalid encrypted private key length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := tamperPKCS8(t, block.Bytes, tt.tamper)
+			_, err := decryptPKCS8(tampered, []byte(fixturePassphrase))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("decryptPKCS8() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := decryptPKCS8([]byte("not DER"), []byte(fixturePassphrase)); err == nil {
+		t.Error("decryptPKCS8() of garbage succeeded")
+	}
+}
+
+func TestPad(t *testing.T) {
+	for _, size := range []int{0, 1, 15, 16, 17, 32} {
+		data := bytes.Repeat([]byte{0xAB}, size)
+
+		padded := pad(data)
+		if len(padded)%16 != 0 || len(padded) <= size {
+			t.Errorf("pad() of %d bytes has %d bytes", size, len(padded))
+		}
+
+		unpadded, err := unpad(padded)
+		if err != nil {
+			t.Errorf("unpad(pad()) of %d bytes error = %v", size, err)
+		}
+		if !bytes.Equal(unpadded, data) {
+			t.Errorf("unpad(pad()) of %d bytes = %x, want %x", size, unpadded, data)
+		}
+	}
+}
+
+func TestUnpad(t *testing.T) {
+	block := func(tail ...byte) []byte {
+		return append(bytes.Repeat([]byte{0xAB}, 16-len(tail)), tail...)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []byte
+		wantErr bool
+	}{
+		{name: "one byte of padding", data: block(1), want: bytes.Repeat([]byte{0xAB}, 15)},
+		{name: "four bytes of padding", data: block(4, 4, 4, 4), want: bytes.Repeat([]byte{0xAB}, 12)},
+		{name: "whole block of padding", data: bytes.Repeat([]byte{16}, 16), want: []byte{}},
+		{name: "zero padding", data: block(0), wantErr: true},
+		{name: "padding longer than a block", data: bytes.Repeat([]byte{17}, 32), wantErr: true},
+		{name: "padding longer than the data", data: []byte{1, 4, 4}, wantErr: true},
+		{name: "inconsistent padding", data: block(3, 4, 4, 4), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := unpad(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, errIncorrectPassphrase) {
+					t.Errorf("unpad() error = %v, want %v", err, errIncorrectPassphrase)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unpad() error = %v", err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("unpad() = %x, want %x", got, tt.want)
+			}
+		})
+	}
+}
+
+func assertSamePublicKey(t *testing.T, got, want PublicKey) {
+	t.Helper()
+
+	gotDER, err := got.MarshalPKIX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantDER, err := want.MarshalPKIX()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotDER, wantDER) {
+		t.Error("private key doesn't match the public key")
+	}
+}
+
+// encryptionScheme returns the cipher an encrypted private key file uses
+func encryptionScheme(t *testing.T, filename string) asn1.ObjectIdentifier {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, _ := pem.Decode(data)
+
+	var info encryptedPrivateKeyInfo
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(block.Bytes, &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	return params.EncryptionScheme.Algorithm
+}
+
+// tamperPKCS8 decodes an EncryptedPrivateKeyInfo, lets tamper change it and encodes it again
+func tamperPKCS8(t *testing.T, der []byte, tamper func(*encryptedPrivateKeyInfo, *pbes2Params, *pbkdf2Params)) []byte {
+	t.Helper()
+
+	var info encryptedPrivateKeyInfo
+	var params pbes2Params
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+
+	tamper(&info, &params, &kdf)
+
+	kdfDER, err := asn1.Marshal(kdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.KeyDerivationFunc.Parameters = asn1.RawValue{FullBytes: kdfDER}
+	paramsDER, err := asn1.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Algorithm = pkix.AlgorithmIdentifier{Algorithm: info.Algorithm.Algorithm, Parameters: asn1.RawValue{FullBytes: paramsDER}}
+	tampered, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tampered
+}
@@ -0,0 +1,132 @@
+// Package health reports whether the application can serve requests, for the liveness and readiness
+// probes of orchestrators and load balancers.
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Statuses of a readiness report and of its checks
+const (
+	StatusOK          = "ok"
+	StatusFailing     = "failing"
+	StatusDraining    = "draining"
+	StatusMaintenance = "maintenance"
+)
+
+// Check pings a dependency, returning an error when it is unavailable
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single check
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of a readiness probe
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Ready reports whether the instance should receive traffic
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Checker runs the readiness checks. It is safe for concurrent use.
+type Checker struct {
+	checks      []Check
+	timeout     time.Duration
+	maintenance *Maintenance
+	draining    atomic.Bool
+}
+
+// NewChecker creates a checker running every check with the given timeout. maintenance may be nil.
+func NewChecker(timeout time.Duration, maintenance *Maintenance, checks ...Check) *Checker {
+	return &Checker{
+		checks:      checks,
+		timeout:     timeout,
+		maintenance: maintenance,
+	}
+}
+
+// SetDraining makes readiness fail from now on, so load balancers stop sending requests before shutdown
+func (h *Checker) SetDraining() {
+	h.draining.Store(true)
+}
+
+// Draining reports whether the application is shutting down
+func (h *Checker) Draining() bool {
+	return h.draining.Load()
+}
+
+// Check runs all checks concurrently. Draining and maintenance take precedence over failing checks,
+// the checks are still run so the report shows the state of every dependency.
+func (h *Checker) Check(ctx context.Context) *Report {
+	report := &Report{
+		Status: StatusOK,
+		Checks: make([]CheckResult, len(h.checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = h.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != StatusOK {
+			report.Status = StatusFailing
+		}
+	}
+
+	switch {
+	case h.Draining():
+		report.Status = StatusDraining
+	case h.inMaintenance(ctx):
+		report.Status = StatusMaintenance
+	}
+
+	return report
+}
+
+func (h *Checker) inMaintenance(ctx context.Context) bool {
+	if h.maintenance == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	return h.maintenance.Enabled(ctx)
+}
+
+func (h *Checker) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Ping(ctx)
+	result := CheckResult{
+		Name:      check.Name,
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
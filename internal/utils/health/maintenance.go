@@ -0,0 +1,43 @@
+package health
+
+import (
+	"context"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maintenanceKey is the Redis flag shared by all instances, set with cmd/maintenance
+const maintenanceKey = "maintenance_mode"
+
+// Maintenance tells whether the application is in maintenance mode, either forced by configuration
+// or switched on at runtime through Redis
+type Maintenance struct {
+	forced bool
+	redis  *redis.Client
+}
+
+func NewMaintenance(forced bool, redisClient *redis.Client) *Maintenance {
+	return &Maintenance{
+		forced: forced,
+		redis:  redisClient,
+	}
+}
+
+// Enabled reports whether maintenance mode is on. When Redis is unavailable the flag can't be read and
+// maintenance is assumed off, the Redis check already fails readiness.
+func (m *Maintenance) Enabled(ctx context.Context) bool {
+	if m.forced {
+		return true
+	}
+
+	enabled, err := m.redis.Exists(ctx, maintenanceKey).Result()
+	return err == nil && enabled > 0
+}
+
+// Set switches maintenance mode on or off for every instance
+func (m *Maintenance) Set(ctx context.Context, enabled bool) error {
+	if enabled {
+		return m.redis.Set(ctx, maintenanceKey, "1", 0).Err()
+	}
+	return m.redis.Del(ctx, maintenanceKey).Err()
+}
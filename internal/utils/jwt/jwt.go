@@ -9,7 +9,9 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
 	"tasius.my.id/todolistapi/internal/domain/entities"
+	"tasius.my.id/todolistapi/internal/utils/metrics"
 )
 
 const (
@@ -17,13 +19,17 @@ const (
 	refreshTokenPrefix = "refresh:%d"
 	accessTokenPrefix = "access:%d"
 	blacklistPrefix   = "blacklist:%s"
+	sessionVersionPrefix = "session_version:%s"
 )
 
+var errSessionRevoked = errors.New("session has been revoked")
+
 type TokenType string
 
 const (
 	AccessToken  TokenType = "access"
 	RefreshToken TokenType = "refresh"
+	MFAToken     TokenType = "mfa"
 )
 
 type TokenManager struct {
@@ -33,8 +39,11 @@ type TokenManager struct {
 }
 
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID         string `json:"user_id"`
+	Email          string `json:"email"`
+	SessionVersion int64    `json:"session_version"`
+	Scopes         []string `json:"scopes"`
+	OrganizationID string   `json:"org_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -50,15 +59,22 @@ func NewTokenManager(cfg *config.JWTConfig, redisClient *redis.Client) (*TokenMa
 	}, nil
 }
 
-func (tm *TokenManager) GenerateTokenPair(user *entities.User) (map[TokenType]string, error) {
+// GenerateTokenPair issues an access and refresh token limited to the given scopes,
+// acting in the given organization or in the user's personal space when it's empty
+func (tm *TokenManager) GenerateTokenPair(user *entities.User, scopes []string, organizationID string) (map[TokenType]string, error) {
+	base, err := tm.baseClaims(user, scopes, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create access token
-	accessToken, accessClaims, err := tm.generateToken(user, AccessToken, tm.config.Expiration)
+	accessToken, accessClaims, err := tm.generateToken(base, AccessToken, tm.config.Expiration)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create refresh token
-	refreshToken, refreshClaims, err := tm.generateToken(user, RefreshToken, tm.config.RefreshExpiration)
+	refreshToken, refreshClaims, err := tm.generateToken(base, RefreshToken, tm.config.RefreshExpiration)
 	if err != nil {
 		return nil, err
 	}
@@ -93,12 +109,42 @@ func (tm *TokenManager) GenerateTokenPair(user *entities.User) (map[TokenType]st
 	}, nil
 }
 
-func (tm *TokenManager) generateToken(user *entities.User, tokenType TokenType, expiration time.Duration) (string, *Claims, error) {
+// GenerateMFAToken issues a short-lived challenge token proving the password step of a two-factor login
+// The scopes requested at login are carried along so the final tokens get the same ones.
+func (tm *TokenManager) GenerateMFAToken(user *entities.User, scopes []string, organizationID string) (string, error) {
+	base, err := tm.baseClaims(user, scopes, organizationID)
+	if err != nil {
+		return "", err
+	}
+
+	token, _, err := tm.generateToken(base, MFAToken, tm.config.MFAExpiration)
+	return token, err
+}
+
+func (tm *TokenManager) baseClaims(user *entities.User, scopes []string, organizationID string) (Claims, error) {
+	sessionVersion, err := tm.getSessionVersion(user.ID)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	return Claims{
+		UserID:         user.ID,
+		Email:          user.Email,
+		SessionVersion: sessionVersion,
+		Scopes:         scopes,
+		OrganizationID: organizationID,
+	}, nil
+}
+
+func (tm *TokenManager) generateToken(base Claims, tokenType TokenType, expiration time.Duration) (string, *Claims, error) {
 	now := time.Now()
 	expiresAt := now.Add(expiration)
 	claims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
+		UserID:         base.UserID,
+		Email:          base.Email,
+		SessionVersion: base.SessionVersion,
+		Scopes:         base.Scopes,
+		OrganizationID: base.OrganizationID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -114,6 +160,7 @@ func (tm *TokenManager) generateToken(user *entities.User, tokenType TokenType,
 		return "", nil, fmt.Errorf("failed to sign token: %w", err)
 	}
 
+	metrics.TokensIssued.WithLabelValues(string(tokenType)).Inc()
 	return tokenString, claims, nil
 }
 
@@ -130,6 +177,36 @@ func (tm *TokenManager) isTokenBlacklisted(tokenString string) (bool, error) {
 	return isBlacklisted == 1, nil
 }
 
+// getSessionVersion returns the current session version of a user, bumped every time all sessions are revoked
+func (tm *TokenManager) getSessionVersion(userID string) (int64, error) {
+	version, err := tm.redis.Get(
+		context.Background(),
+		fmt.Sprintf(sessionVersionPrefix, userID),
+	).Int64()
+
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get session version: %w", err)
+	}
+
+	return version, nil
+}
+
+func (tm *TokenManager) verifySessionVersion(claims *Claims) error {
+	version, err := tm.getSessionVersion(claims.UserID)
+	if err != nil {
+		return err
+	}
+
+	if claims.SessionVersion != version {
+		return errSessionRevoked
+	}
+
+	return nil
+}
+
 func (tm *TokenManager) verifyRefreshTokenInRedis(claims *Claims, tokenString string) error {
 	tokenInRedis, err := tm.redis.Get(
 		context.Background(),
@@ -147,10 +224,10 @@ func (tm *TokenManager) ValidateToken(tokenString string, tokenType TokenType) (
 	// Check if token is blacklisted
 	blacklisted, err := tm.isTokenBlacklisted(tokenString)
 	if err != nil {
-		return nil, err
+		return nil, rejectToken(tokenType, "error", err)
 	}
 	if blacklisted {
-		return nil, errors.New("token has been invalidated")
+		return nil, rejectToken(tokenType, "blacklisted", errors.New("token has been invalidated"))
 	}
 
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
@@ -161,29 +238,52 @@ func (tm *TokenManager) ValidateToken(tokenString string, tokenType TokenType) (
 	})
 
 	if err != nil {
-		return nil, fmt.Errorf("invalid token: %w", err)
+		reason := "invalid"
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			reason = "expired"
+		}
+		return nil, rejectToken(tokenType, reason, fmt.Errorf("invalid token: %w", err))
 	}
 
 	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, rejectToken(tokenType, "invalid", errors.New("invalid token"))
 	}
 
 	// Verify token type
 	if claims.Subject != string(tokenType) {
-		return nil, errors.New("invalid token type")
+		return nil, rejectToken(tokenType, "wrong_type", errors.New("invalid token type"))
+	}
+
+	// Reject tokens issued before the user's sessions were revoked
+	if err := tm.verifySessionVersion(claims); err != nil {
+		reason := "error"
+		if errors.Is(err, errSessionRevoked) {
+			reason = "revoked"
+		}
+		return nil, rejectToken(tokenType, reason, err)
 	}
 
 	// For refresh tokens, verify it exists in Redis
 	if tokenType == RefreshToken {
 		if err := tm.verifyRefreshTokenInRedis(claims, tokenString); err != nil {
-			return nil, err
+			return nil, rejectToken(tokenType, "not_stored", err)
 		}
 	}
 
 	return claims, nil
 }
 
+// rejectToken counts a failed validation by reason and returns err. Rejected tokens are reported
+// as unauthenticated with the code "token_<reason>", failures to check them are returned as is.
+func rejectToken(tokenType TokenType, reason string, err error) error {
+	metrics.TokenValidationFailures.WithLabelValues(string(tokenType), reason).Inc()
+	if reason == "error" {
+		return err
+	}
+	return apperrors.Unauthenticated("token_"+reason, err.Error())
+}
+
 // Logout invalidates all tokens for a user
 func (tm *TokenManager) Logout(userID string) error {
 	ctx := context.Background()
@@ -205,6 +305,18 @@ func (tm *TokenManager) Logout(userID string) error {
 	return err
 }
 
+// RevokeAllSessions logs the user out and invalidates every access and refresh token issued so far
+func (tm *TokenManager) RevokeAllSessions(userID string) error {
+	if err := tm.Logout(userID); err != nil {
+		return err
+	}
+
+	return tm.redis.Incr(
+		context.Background(),
+		fmt.Sprintf(sessionVersionPrefix, userID),
+	).Err()
+}
+
 // InvalidateToken adds a token to the blacklist
 func (tm *TokenManager) InvalidateToken(tokenString string, tokenType TokenType, expiration time.Duration) error {
 	// Add token to blacklist
@@ -229,5 +341,11 @@ func (tm *TokenManager) RefreshToken(refreshToken string) (map[TokenType]string,
 		Email: claims.Email,
 	}
 
-	return tm.GenerateTokenPair(user)
+	// Tokens issued before scopes existed get the default user scopes
+	scopes := claims.Scopes
+	if len(scopes) == 0 {
+		scopes = entities.UserScopes
+	}
+
+	return tm.GenerateTokenPair(user, scopes, claims.OrganizationID)
 }
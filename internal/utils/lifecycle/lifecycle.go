@@ -0,0 +1,137 @@
+// Package lifecycle starts the components of the application in order and stops them in reverse
+// order on shutdown, so the HTTP server drains before the connections it uses are closed.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Component is a part of the application that is started and stopped with it. Start must return once
+// the component runs, long running work is started with Manager.Go. Either function may be nil.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts and stops components. It is not safe to add components while it runs.
+type Manager struct {
+	components []Component
+	started    int
+	failed     chan error
+	failOnce   sync.Once
+}
+
+func NewManager() *Manager {
+	return &Manager{
+		failed: make(chan error, 1),
+	}
+}
+
+// Add appends a component, components are started in the order they are added
+func (m *Manager) Add(component Component) {
+	m.components = append(m.components, component)
+}
+
+// Go runs a long running function of a component. If it returns an error the application shuts down.
+func (m *Manager) Go(name string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			m.failOnce.Do(func() {
+				m.failed <- fmt.Errorf("%s: %w", name, err)
+			})
+		}
+	}()
+}
+
+// Start starts the components in order. If one fails, the ones already started are stopped again.
+func (m *Manager) Start(ctx context.Context) error {
+	for _, component := range m.components {
+		if component.Start != nil {
+			if err := component.Start(ctx); err != nil {
+				startErr := fmt.Errorf("failed to start %s: %w", component.Name, err)
+				return errors.Join(startErr, m.Stop(ctx))
+			}
+		}
+		m.started++
+	}
+	return nil
+}
+
+// Stop stops the started components in reverse order. Every component is stopped even if an earlier
+// one fails or ctx expires, so connections are always closed.
+func (m *Manager) Stop(ctx context.Context) error {
+	var errs []error
+	for ; m.started > 0; m.started-- {
+		component := m.components[m.started-1]
+		if component.Stop == nil {
+			continue
+		}
+
+		start := time.Now()
+		if err := component.Stop(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", component.Name, err))
+			continue
+		}
+		slog.Info("Stopped component", "component", component.Name, "duration", time.Since(start).Round(time.Millisecond))
+	}
+	return errors.Join(errs...)
+}
+
+// Run starts the components and blocks until ctx is done or a component fails, then stops them
+// within the drain timeout
+func (m *Manager) Run(ctx context.Context, drainTimeout time.Duration) error {
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		slog.Info("Shutting down", "drain_timeout", drainTimeout)
+	case runErr = <-m.failed:
+		slog.Error("Shutting down after an error", "error", runErr)
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	return errors.Join(runErr, m.Stop(stopCtx))
+}
+
+// Worker is a component running fn until the application stops, e.g. a scheduler or a file watcher.
+// Stopping cancels the context passed to fn and waits for it to return.
+func Worker(m *Manager, name string, fn func(ctx context.Context)) Component {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+
+	return Component{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			m.Go(name, func() error {
+				defer close(done)
+				fn(ctx)
+				return nil
+			})
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
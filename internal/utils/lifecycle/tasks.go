@@ -0,0 +1,83 @@
+package lifecycle
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Tasks runs background work spawned by requests, like cache invalidation, and lets shutdown wait for
+// it instead of dropping it. It is safe for concurrent use.
+type Tasks struct {
+	timeout time.Duration
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// NewTasks creates a task tracker, each task gets a context that expires after timeout
+func NewTasks(timeout time.Duration) *Tasks {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tasks{
+		timeout: timeout,
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// Go runs fn in the background. Once the tracker is stopped fn runs synchronously instead, so the work
+// of requests still in flight isn't lost.
+func (t *Tasks) Go(name string, fn func(ctx context.Context)) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		t.run(context.Background(), name, fn)
+		return
+	}
+	t.wg.Add(1)
+	t.mu.Unlock()
+
+	go func() {
+		defer t.wg.Done()
+		t.run(t.ctx, name, fn)
+	}()
+}
+
+func (t *Tasks) run(parent context.Context, name string, fn func(ctx context.Context)) {
+	ctx, cancel := context.WithTimeout(parent, t.timeout)
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Background task panicked", "task", name, "panic", r)
+		}
+	}()
+
+	fn(ctx)
+}
+
+// Stop waits for running tasks until ctx is done, then cancels the ones still running
+func (t *Tasks) Stop(ctx context.Context) error {
+	t.mu.Lock()
+	t.closed = true
+	t.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		t.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		t.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,140 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/domain/apperrors"
+)
+
+const (
+	// Redis key prefixes, the subject is "email:<email>" or "ip:<ip>"
+	attemptsPrefix = "login_attempts:%s"
+	lockPrefix     = "login_lock:%s"
+	delayPrefix    = "login_delay:%s"
+)
+
+// ErrLocked is returned when a login is rejected before the password is even checked. The returned
+// copies tell when the next attempt is allowed.
+var ErrLocked = apperrors.RateLimited("login_locked", "too many failed login attempts")
+
+func lockedError(retryAfter time.Duration) error {
+	return ErrLocked.
+		WithMessage(fmt.Sprintf("too many failed login attempts, try again in %s", retryAfter.Round(time.Second))).
+		WithRetryAfter(retryAfter)
+}
+
+// AttemptTracker counts failed logins per email and per IP, slowing down and
+// eventually locking out whoever keeps guessing passwords
+type AttemptTracker struct {
+	config *config.LockoutConfig
+	redis  *redis.Client
+}
+
+func NewAttemptTracker(cfg *config.LockoutConfig, redisClient *redis.Client) *AttemptTracker {
+	return &AttemptTracker{
+		config: cfg,
+		redis:  redisClient,
+	}
+}
+
+// Check returns ErrLocked if the email or IP is locked or still has to wait before the next attempt
+func (t *AttemptTracker) Check(ctx context.Context, email, ip string) error {
+	for _, subject := range []string{emailSubject(email), ipSubject(ip)} {
+		for _, prefix := range []string{lockPrefix, delayPrefix} {
+			ttl, err := t.redis.PTTL(ctx, fmt.Sprintf(prefix, subject)).Result()
+			if err != nil {
+				return fmt.Errorf("failed to check login attempts: %w", err)
+			}
+			if ttl > 0 {
+				return lockedError(ttl)
+			}
+		}
+	}
+
+	return nil
+}
+
+// RegisterFailure records a failed login, applying a progressive delay and locking
+// the email or IP once it reaches its maximum number of attempts
+func (t *AttemptTracker) RegisterFailure(ctx context.Context, email, ip string) error {
+	if err := t.registerFailure(ctx, emailSubject(email), t.config.MaxAttempts); err != nil {
+		return err
+	}
+	return t.registerFailure(ctx, ipSubject(ip), t.config.IPMaxAttempts)
+}
+
+// Reset clears the failed attempts of an email after a successful login.
+// The IP counter is kept so an attacker can't reset it by logging into their own account.
+func (t *AttemptTracker) Reset(ctx context.Context, email string) error {
+	return t.clear(ctx, emailSubject(email))
+}
+
+// Unlock lifts the lockout of an email
+func (t *AttemptTracker) Unlock(ctx context.Context, email string) error {
+	return t.clear(ctx, emailSubject(email))
+}
+
+// UnlockIP lifts the lockout of an IP address
+func (t *AttemptTracker) UnlockIP(ctx context.Context, ip string) error {
+	return t.clear(ctx, ipSubject(ip))
+}
+
+func (t *AttemptTracker) registerFailure(ctx context.Context, subject string, maxAttempts int) error {
+	attemptsKey := fmt.Sprintf(attemptsPrefix, subject)
+
+	attempts, err := t.redis.Incr(ctx, attemptsKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	if attempts == 1 {
+		t.redis.Expire(ctx, attemptsKey, t.config.Window)
+	}
+
+	if maxAttempts > 0 && attempts >= int64(maxAttempts) {
+		pipe := t.redis.TxPipeline()
+		pipe.Set(ctx, fmt.Sprintf(lockPrefix, subject), "1", t.config.Duration)
+		pipe.Del(ctx, attemptsKey)
+		_, err = pipe.Exec(ctx)
+		return err
+	}
+
+	delay := t.delay(attempts)
+	if delay <= 0 {
+		return nil
+	}
+	return t.redis.Set(ctx, fmt.Sprintf(delayPrefix, subject), "1", delay).Err()
+}
+
+// delay doubles with every failed attempt, up to the configured maximum
+func (t *AttemptTracker) delay(attempts int64) time.Duration {
+	delay := t.config.BaseDelay
+	for i := int64(1); i < attempts && delay < t.config.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > t.config.MaxDelay {
+		delay = t.config.MaxDelay
+	}
+	return delay
+}
+
+func (t *AttemptTracker) clear(ctx context.Context, subject string) error {
+	return t.redis.Del(
+		ctx,
+		fmt.Sprintf(attemptsPrefix, subject),
+		fmt.Sprintf(lockPrefix, subject),
+		fmt.Sprintf(delayPrefix, subject),
+	).Err()
+}
+
+func emailSubject(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipSubject(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,151 @@
+// Package logging builds the structured logger of the application. Records are enriched with the
+// fields of the request in their context and sensitive values are redacted.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+	"tasius.my.id/todolistapi/internal/config"
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+)
+
+// Formats LOG_FORMAT may be set to
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Levels LOG_LEVEL may be set to
+var Levels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are parts of attribute keys whose values are never logged
+var sensitiveKeys = []string{
+	"password", "passphrase", "secret", "token", "authorization", "cookie",
+	"api_key", "apikey", "private_key", "otp", "recovery_code",
+	"ciphertext", "encrypted", "envelope", "payload",
+}
+
+// encryptedPrefixes mark encrypted payloads whatever their key: encrypted fields and request envelopes
+var encryptedPrefixes = []string{"enc:v1:", crypto.EnvelopeVersion + "."}
+
+// sensitiveParams matches the values of sensitive query parameters in URLs inside any string, e.g. the
+// token of a password reset link in the body of a mail
+var sensitiveParams = regexp.MustCompile(`(?i)([?&][a-z_]*(?:token|secret|password|code)=)[^&#\s"']+`)
+
+// New creates a logger writing to w in the configured format and level
+func New(cfg *config.LogConfig, w io.Writer) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level:       Levels[cfg.Level],
+		ReplaceAttr: redact,
+	}
+
+	var handler slog.Handler
+	if cfg.Format == FormatText {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// redact masks the values of sensitive attributes, including attributes nested in groups
+func redact(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindGroup {
+		return attr
+	}
+
+	// Header names like X-API-Key match the keys like api_key
+	key := strings.ReplaceAll(strings.ToLower(attr.Key), "-", "_")
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return slog.String(attr.Key, redacted)
+		}
+	}
+
+	if attr.Value.Kind() == slog.KindString {
+		value := attr.Value.String()
+		for _, prefix := range encryptedPrefixes {
+			if strings.HasPrefix(value, prefix) {
+				return slog.String(attr.Key, redacted)
+			}
+		}
+		if strings.Contains(value, "=") {
+			if masked := sensitiveParams.ReplaceAllString(value, "${1}"+redacted); masked != value {
+				return slog.String(attr.Key, masked)
+			}
+		}
+	}
+
+	return attr
+}
+
+type fieldsKey struct{}
+
+// fields are the attributes of a request, shared by everything logging with its context
+type fields struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// WithFields returns a context whose log records carry attrs. Fields added to the returned context
+// later with AddFields are seen by every logger using it.
+func WithFields(ctx context.Context, attrs ...slog.Attr) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, &fields{attrs: attrs})
+}
+
+// AddFields adds attrs to the fields of the context, e.g. the user once the request is authenticated.
+// It does nothing if the context has no fields.
+func AddFields(ctx context.Context, attrs ...slog.Attr) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attrs = append(f.attrs, attrs...)
+}
+
+// contextHandler adds the fields and the trace of the context to every record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.mu.Lock()
+		record.AddAttrs(f.attrs...)
+		f.mu.Unlock()
+	}
+
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		record.AddAttrs(
+			slog.String("trace_id", spanContext.TraceID().String()),
+			slog.String("span_id", spanContext.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"tasius.my.id/todolistapi/internal/config"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name string
+		attr slog.Attr
+		want string
+	}{
+		{name: "sensitive key", attr: slog.String("refresh_token", "abc"), want: redacted},
+		{name: "sensitive key in other case", attr: slog.String("X-API-Key", "abc"), want: redacted},
+		{name: "encrypted field", attr: slog.String("title", "enc:v1:abc"), want: redacted},
+		{name: "other value", attr: slog.String("path", "/api/todos"), want: "/api/todos"},
+		{
+			name: "token in a link",
+			attr: slog.String("body", "Reset it:\n\nhttp://localhost:3000/reset-password?token=s3cr3t-t0ken\n\nBye"),
+			want: "Reset it:\n\nhttp://localhost:3000/reset-password?token=" + redacted + "\n\nBye",
+		},
+		{
+			name: "several parameters",
+			attr: slog.String("url", "https://example.com/cb?state=x&code=abc123&invite_token=t0k#frag"),
+			want: "https://example.com/cb?state=x&code=" + redacted + "&invite_token=" + redacted + "#frag",
+		},
+		{name: "parameter outside a URL", attr: slog.String("query", "token=abc"), want: "token=abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := redact(nil, tt.attr).Value.String(); got != tt.want {
+				t.Errorf("redact() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoggerRedactsMailTokens(t *testing.T) {
+	var out bytes.Buffer
+	logger := New(&config.LogConfig{Level: "info", Format: FormatJSON}, &out)
+
+	logger.Info("Mail not sent", "to", "bob@example.com", "body", "Accept the invite:\nhttp://localhost:3000/accept-invite?token=invite-token-123\n")
+
+	if strings.Contains(out.String(), "invite-token-123") {
+		t.Fatalf("log contains the token: %s", out.String())
+	}
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["to"] != "bob@example.com" {
+		t.Errorf("to = %v, want it logged as is", record["to"])
+	}
+}
@@ -0,0 +1,87 @@
+// Package metrics holds the Prometheus metrics of the application, exposed on /metrics
+package metrics
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/redis/go-redis/v9"
+)
+
+// Caches of the todo service
+const (
+	TodoCache     = "todo"
+	TodoListCache = "todo_list"
+)
+
+// Results of a cache lookup
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+// Registry holds every metric of the application along with Go runtime and process metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	HTTPRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route template and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Database query latency by operation and table.",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	CacheRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_requests_total",
+		Help: "Cache lookups by cache and result (hit, miss or error).",
+	}, []string{"cache", "result"})
+
+	TokensIssued = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_tokens_issued_total",
+		Help: "JWTs issued by token type.",
+	}, []string{"type"})
+
+	TokenValidationFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_token_validation_failures_total",
+		Help: "Rejected JWTs by token type and reason.",
+	}, []string{"type", "reason"})
+
+	DecryptionFailures = factory.NewCounterVec(prometheus.CounterOpts{
+		Name: "encryption_decryption_failures_total",
+		Help: "Encrypted request bodies that could not be decrypted, by reason.",
+	}, []string{"reason"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// CacheLookup records the result of a Redis cache read, redis.Nil being a miss
+func CacheLookup(cache string, err error) {
+	result := CacheHit
+	switch {
+	case errors.Is(err, redis.Nil):
+		result = CacheMiss
+	case err != nil:
+		result = CacheError
+	}
+	CacheRequests.WithLabelValues(cache, result).Inc()
+}
@@ -0,0 +1,222 @@
+// Package oidctest provides a stand-in OpenID Connect provider for tests. It serves discovery, a
+// JWKS and a token endpoint that enforces PKCE, and signs ID tokens for the identities tests log in.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"tasius.my.id/todolistapi/internal/config"
+)
+
+const (
+	// ClientID is the client the issuer accepts
+	ClientID = "todolistapi"
+	// RedirectURL is the redirect URI the issuer accepts
+	RedirectURL = "https://todo.example.com/api/auth/oidc/test/callback"
+
+	keyID = "test-key"
+)
+
+// Identity is the user that logs in at the issuer
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type authorization struct {
+	identity      Identity
+	nonce         string
+	codeChallenge string
+}
+
+// Issuer is a running stand-in provider, close it with Close
+type Issuer struct {
+	*httptest.Server
+
+	// SigningKey signs ID tokens. Replace it with a key missing from the JWKS to forge signatures.
+	SigningKey *rsa.PrivateKey
+	// Claims, if set, changes the claims of ID tokens before they are signed, e.g. to forge the nonce
+	Claims func(claims jwt.MapClaims)
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]*authorization
+}
+
+// NewIssuer starts an issuer, it is closed when the test ends
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+
+	issuer := &Issuer{
+		key:   GenerateKey(t),
+		codes: make(map[string]*authorization),
+	}
+	issuer.SigningKey = issuer.key
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("GET /jwks", issuer.jwks)
+	mux.HandleFunc("POST /token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+
+	return issuer
+}
+
+// GenerateKey returns a new RSA key, e.g. to forge signatures with
+func GenerateKey(t testing.TB) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return key
+}
+
+// Config returns the configuration of a provider that logs in at the issuer
+func (i *Issuer) Config(name string) *config.OIDCProviderConfig {
+	return &config.OIDCProviderConfig{
+		Name:        name,
+		IssuerURL:   i.URL,
+		ClientID:    ClientID,
+		RedirectURL: RedirectURL,
+		Scopes:      []string{"openid", "email", "profile"},
+	}
+}
+
+// Authorize plays the user logging in as identity at the authorization endpoint. It checks the
+// authorization request and returns the code and state the provider redirects back with.
+func (i *Issuer) Authorize(t testing.TB, authURL string, identity Identity) (code, state string) {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL: %v", err)
+	}
+	query := parsed.Query()
+
+	expected := map[string]string{
+		"response_type":         "code",
+		"client_id":             ClientID,
+		"redirect_uri":          RedirectURL,
+		"code_challenge_method": "S256",
+	}
+	for name, value := range expected {
+		if got := query.Get(name); got != value {
+			t.Fatalf("authorization request has %s %q, want %q", name, got, value)
+		}
+	}
+	for _, name := range []string{"state", "nonce", "code_challenge"} {
+		if query.Get(name) == "" {
+			t.Fatalf("authorization request has no %s", name)
+		}
+	}
+
+	code = rand.Text()
+	i.mu.Lock()
+	i.codes[code] = &authorization{
+		identity:      identity,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	i.mu.Unlock()
+
+	return code, query.Get("state")
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 i.URL,
+		"authorization_endpoint": i.URL + "/authorize",
+		"token_endpoint":         i.URL + "/token",
+		"jwks_uri":               i.URL + "/jwks",
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"n":   encode(i.key.N.Bytes()),
+			"e":   encode(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+// token redeems a code once, for the client and redirect URI it was issued to and the verifier of
+// its challenge
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	i.mu.Lock()
+	auth, ok := i.codes[r.PostForm.Get("code")]
+	delete(i.codes, r.PostForm.Get("code"))
+	i.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok ||
+		r.PostForm.Get("grant_type") != "authorization_code" ||
+		r.PostForm.Get("client_id") != ClientID ||
+		r.PostForm.Get("redirect_uri") != RedirectURL ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            i.URL,
+		"aud":            ClientID,
+		"sub":            auth.identity.Subject,
+		"email":          auth.identity.Email,
+		"email_verified": auth.identity.EmailVerified,
+		"name":           auth.identity.Name,
+		"nonce":          auth.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	if i.Claims != nil {
+		i.Claims(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(i.SigningKey)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,174 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"tasius.my.id/todolistapi/internal/utils/oidc/oidctest"
+)
+
+var alice = oidctest.Identity{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+
+// login starts a login at the provider and returns the code of the authorization
+func login(t *testing.T, issuer *oidctest.Issuer, provider *Provider, nonce, codeVerifier string) string {
+	t.Helper()
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", nonce, codeVerifier)
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+	code, _ := issuer.Authorize(t, authURL, alice)
+	return code
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	provider := NewProvider(issuer.Config("test"), nil)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL() error = %v", err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid URL %q: %v", authURL, err)
+	}
+	if got, want := parsed.Scheme+"://"+parsed.Host+parsed.Path, issuer.URL+"/authorize"; got != want {
+		t.Errorf("endpoint = %q, want %q", got, want)
+	}
+
+	query := parsed.Query()
+	expected := map[string]string{
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        CodeChallenge("the-verifier"),
+		"code_challenge_method": "S256",
+		"scope":                 "openid email profile",
+	}
+	for name, want := range expected {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestExchange(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	provider := NewProvider(issuer.Config("test"), nil)
+
+	code := login(t, issuer, provider, "the-nonce", "the-verifier")
+	claims, err := provider.Exchange(context.Background(), code, "the-verifier", "the-nonce")
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+
+	if claims.Subject != alice.Subject || claims.Email != alice.Email || !bool(claims.EmailVerified) || claims.Name != alice.Name {
+		t.Errorf("Exchange() claims = %+v, want %+v", claims, alice)
+	}
+
+	// Codes are single-use
+	if _, err := provider.Exchange(context.Background(), code, "the-verifier", "the-nonce"); err == nil {
+		t.Error("Exchange() of a redeemed code succeeded")
+	}
+}
+
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name         string
+		codeVerifier string
+		nonce        string
+		tamper       func(t *testing.T, issuer *oidctest.Issuer)
+		wantErr      string
+	}{
+		{
+			name:         "wrong code verifier",
+			codeVerifier: "another-verifier",
+			nonce:        "the-nonce",
+			wantErr:      "invalid_grant",
+		},
+		{
+			name:         "nonce mismatch",
+			codeVerifier: "the-verifier",
+			nonce:        "another-nonce",
+			wantErr:      "nonce mismatch",
+		},
+		{
+			name:         "bad signature",
+			codeVerifier: "the-verifier",
+			nonce:        "the-nonce",
+			tamper: func(t *testing.T, issuer *oidctest.Issuer) {
+				issuer.SigningKey = oidctest.GenerateKey(t)
+			},
+			wantErr: "signature is invalid",
+		},
+		{
+			name:         "other audience",
+			codeVerifier: "the-verifier",
+			nonce:        "the-nonce",
+			tamper: func(t *testing.T, issuer *oidctest.Issuer) {
+				issuer.Claims = func(claims jwt.MapClaims) { claims["aud"] = "another-client" }
+			},
+			wantErr: "invalid audience",
+		},
+		{
+			name:         "other issuer",
+			codeVerifier: "the-verifier",
+			nonce:        "the-nonce",
+			tamper: func(t *testing.T, issuer *oidctest.Issuer) {
+				issuer.Claims = func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" }
+			},
+			wantErr: "invalid issuer",
+		},
+		{
+			name:         "expired",
+			codeVerifier: "the-verifier",
+			nonce:        "the-nonce",
+			tamper: func(t *testing.T, issuer *oidctest.Issuer) {
+				issuer.Claims = func(claims jwt.MapClaims) { claims["exp"] = 1 }
+			},
+			wantErr: "token is expired",
+		},
+		{
+			name:         "missing subject",
+			codeVerifier: "the-verifier",
+			nonce:        "the-nonce",
+			tamper: func(t *testing.T, issuer *oidctest.Issuer) {
+				issuer.Claims = func(claims jwt.MapClaims) { delete(claims, "sub") }
+			},
+			wantErr: "missing subject",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := oidctest.NewIssuer(t)
+			provider := NewProvider(issuer.Config("test"), nil)
+			if tt.tamper != nil {
+				tt.tamper(t, issuer)
+			}
+
+			code := login(t, issuer, provider, "the-nonce", "the-verifier")
+			_, err := provider.Exchange(context.Background(), code, tt.codeVerifier, tt.nonce)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Exchange() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	cfg := issuer.Config("test")
+	// The same server under another name, its discovery document names the issuer differently
+	cfg.IssuerURL = strings.Replace(issuer.URL, "127.0.0.1", "localhost", 1)
+	provider := NewProvider(cfg, nil)
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	if err == nil || !strings.Contains(err.Error(), "issuer mismatch") {
+		t.Errorf("AuthCodeURL() error = %v, want issuer mismatch", err)
+	}
+}
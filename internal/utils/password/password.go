@@ -2,7 +2,9 @@ package password
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strings"
@@ -13,6 +15,9 @@ import (
 const (
 	// DefaultCost is the default cost for bcrypt hashing
 	DefaultCost = 12
+
+	// dummyHash is a bcrypt hash with DefaultCost used by CheckDummyPassword
+	dummyHash = "$2a$12$E1OXntDvO27kuGc8woAwUemfU7/N1nhkYg0Tn.ZJb4jLxhokTBQh6"
 )
 
 // HashPassword hashes a password using bcrypt with a random salt
@@ -36,6 +41,12 @@ func CheckPassword(password, hashedPassword string) error {
     return nil
 }
 
+// CheckDummyPassword spends as much time as CheckPassword, so a login for an unknown
+// user can't be told apart from a wrong password by its response time
+func CheckDummyPassword(password string) {
+	_ = bcrypt.CompareHashAndPassword([]byte(dummyHash), []byte(password))
+}
+
 // GenerateRandomString generates a random string of the given length
 func GenerateRandomString(length int) (string, error) {
 	b := make([]byte, length)
@@ -60,6 +71,12 @@ func GenerateToken() (string, error) {
 	return GenerateRandomString(32) // 32 bytes = 256 bits
 }
 
+// HashToken hashes a high-entropy token with SHA-256 so it can be stored and looked up safely
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 // SanitizeString removes potentially dangerous characters from a string
 func SanitizeString(input string) string {
 	// Remove whitespace and control characters
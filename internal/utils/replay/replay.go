@@ -0,0 +1,54 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"tasius.my.id/todolistapi/internal/config"
+)
+
+// noncePrefix is the Redis key of a nonce that has been used
+const noncePrefix = "encryption_nonce:%s:%s"
+
+var (
+	ErrStaleTimestamp = errors.New("encrypted request timestamp is outside the allowed window")
+	ErrReplayedNonce  = errors.New("encrypted request has already been used")
+)
+
+// Guard rejects encrypted requests that are too old or whose nonce was seen before
+type Guard struct {
+	config *config.HybridEncryptionConfig
+	redis  *redis.Client
+}
+
+func NewGuard(cfg *config.HybridEncryptionConfig, redisClient *redis.Client) *Guard {
+	return &Guard{
+		config: cfg,
+		redis:  redisClient,
+	}
+}
+
+// CheckTimestamp returns ErrStaleTimestamp if the timestamp is further off than the allowed clock skew
+func (g *Guard) CheckTimestamp(timestamp time.Time) error {
+	age := time.Since(timestamp)
+	if age > g.config.MaxClockSkew || age < -g.config.MaxClockSkew {
+		return ErrStaleTimestamp
+	}
+	return nil
+}
+
+// UseNonce records the nonce, returning ErrReplayedNonce if it was already used. Nonces are kept
+// until any timestamp they could have been sent with is stale.
+func (g *Guard) UseNonce(ctx context.Context, keyID, nonce string) error {
+	ok, err := g.redis.SetNX(ctx, fmt.Sprintf(noncePrefix, keyID, nonce), 1, 2*g.config.MaxClockSkew).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check nonce: %w", err)
+	}
+	if !ok {
+		return ErrReplayedNonce
+	}
+	return nil
+}
@@ -7,7 +7,6 @@ type Response struct {
 	Success   bool   `json:"success"`
 	Message   string `json:"message"`
 	Data      any    `json:"data,omitempty"`
-	Error     string `json:"error,omitempty"`
 }
 
 func SuccessResponse(c *fiber.Ctx, message string, data interface{}) error {
@@ -27,21 +26,3 @@ func CreatedResponse(c *fiber.Ctx, message string, data interface{}) error {
 		Data:    data,
 	})
 }
-
-func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
-	return c.Status(statusCode).JSON(Response{
-		RequestId: c.Locals("requestid").(string),
-		Success: false,
-		Message: message,
-		Error:   message,
-	})
-}
-
-func ValidationErrorResponse(c *fiber.Ctx, errors []string) error {
-	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-		"requestId": c.Locals("requestid").(string),
-		"success": false,
-		"message": "Validation failed",
-		"errors":  errors,
-	})
-}
\ No newline at end of file
@@ -1,43 +0,0 @@
-package response
-
-import (
-	"github.com/go-playground/validator/v10"
-	"github.com/gofiber/fiber/v2"
-)
-
-var validate *validator.Validate
-
-func init() {
-	validate = validator.New()
-}
-
-// SuccessResponse sends a successful JSON response
-func SuccessResponse(c *fiber.Ctx, message string, data interface{}) error {
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": message,
-		"data":    data,
-	})
-}
-
-// ErrorResponse sends an error JSON response
-func ErrorResponse(c *fiber.Ctx, statusCode int, message string) error {
-	return c.Status(statusCode).JSON(fiber.Map{
-		"success": false,
-		"error":   message,
-	})
-}
-
-// ValidationErrorResponse sends a validation error JSON response
-func ValidationErrorResponse(c *fiber.Ctx, err error) error {
-	errs := make(map[string]string)
-	for _, err := range err.(validator.ValidationErrors) {
-		errs[err.Field()] = err.Tag()
-	}
-
-	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-		"success": false,
-		"error":   "Validation failed",
-		"errors":  errs,
-	})
-}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+)
+
+// Register creates an account and logs the client in
+func (c *Client) Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error) {
+	return c.authenticate(ctx, "/api/auth/register", req)
+}
+
+// Login logs the client in. If the account has two-factor authentication enabled the response has
+// MFARequired set and no tokens, finish the login with VerifyTwoFactor.
+func (c *Client) Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error) {
+	return c.authenticate(ctx, "/api/auth/login", req)
+}
+
+// VerifyTwoFactor finishes a login with the MFA token of the login response and a TOTP or recovery code
+func (c *Client) VerifyTwoFactor(ctx context.Context, mfaToken, code string) (*AuthResponse, error) {
+	return c.authenticate(ctx, "/api/auth/2fa/verify", &dto.TwoFactorVerifyRequest{MFAToken: mfaToken, Code: code})
+}
+
+// RefreshToken exchanges a refresh token for new tokens. Authenticated calls do this automatically.
+func (c *Client) RefreshToken(ctx context.Context, refreshToken string) (*AuthResponse, error) {
+	return c.authenticate(ctx, "/api/auth/refresh", &dto.RefreshTokenRequest{RefreshToken: refreshToken})
+}
+
+func (c *Client) authenticate(ctx context.Context, path string, body any) (*AuthResponse, error) {
+	var response AuthResponse
+	if err := c.call(ctx, &request{method: http.MethodPost, path: path, body: body, encrypted: true}, &response); err != nil {
+		return nil, err
+	}
+
+	c.storeTokens(&response)
+	return &response, nil
+}
+
+// Logout revokes every session of the account and forgets the tokens
+func (c *Client) Logout(ctx context.Context) error {
+	if err := c.call(ctx, &request{method: http.MethodPost, path: "/api/auth/logout", authenticated: true}, nil); err != nil {
+		return err
+	}
+
+	c.SetTokens(Tokens{})
+	return nil
+}
+
+// ForgotPassword sends a password reset link if the email is registered
+func (c *Client) ForgotPassword(ctx context.Context, req *ForgotPasswordRequest) error {
+	return c.call(ctx, &request{method: http.MethodPost, path: "/api/auth/forgot-password", body: req, encrypted: true}, nil)
+}
+
+// ResetPassword sets a new password with the token of a reset link
+func (c *Client) ResetPassword(ctx context.Context, req *ResetPasswordRequest) error {
+	return c.call(ctx, &request{method: http.MethodPost, path: "/api/auth/reset-password", body: req, encrypted: true}, nil)
+}
+
+// SetupTwoFactor starts enabling two-factor authentication, returning the TOTP secret
+func (c *Client) SetupTwoFactor(ctx context.Context) (*TwoFactorSetupResponse, error) {
+	var response TwoFactorSetupResponse
+	if err := c.call(ctx, &request{method: http.MethodPost, path: "/api/auth/2fa/setup", authenticated: true}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// EnableTwoFactor confirms the setup with a TOTP code, returning the recovery codes. Like every body
+// sent to /api/auth, the code is encrypted.
+func (c *Client) EnableTwoFactor(ctx context.Context, code string) (*TwoFactorEnableResponse, error) {
+	var response TwoFactorEnableResponse
+	req := &request{method: http.MethodPost, path: "/api/auth/2fa/enable", body: &dto.TwoFactorCodeRequest{Code: code}, encrypted: true, authenticated: true}
+	if err := c.call(ctx, req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off, confirmed with a TOTP or recovery code
+func (c *Client) DisableTwoFactor(ctx context.Context, code string) error {
+	req := &request{method: http.MethodPost, path: "/api/auth/2fa/disable", body: &dto.TwoFactorCodeRequest{Code: code}, encrypted: true, authenticated: true}
+	return c.call(ctx, req, nil)
+}
+
+// OIDCLoginURL returns the URL of the identity provider the user logs in at
+func (c *Client) OIDCLoginURL(ctx context.Context, provider string) (string, error) {
+	var response OIDCLoginResponse
+	req := &request{method: http.MethodGet, path: "/api/auth/oidc/" + url.PathEscape(provider) + "/login"}
+	if err := c.call(ctx, req, &response); err != nil {
+		return "", err
+	}
+	return response.AuthorizationURL, nil
+}
+
+// OIDCCallback finishes a login at an identity provider with the code and state it redirected back with
+func (c *Client) OIDCCallback(ctx context.Context, provider, code, state string) (*AuthResponse, error) {
+	var response AuthResponse
+	req := &request{
+		method: http.MethodGet,
+		path:   "/api/auth/oidc/" + url.PathEscape(provider) + "/callback",
+		query:  url.Values{"code": {code}, "state": {state}},
+	}
+	if err := c.call(ctx, req, &response); err != nil {
+		return nil, err
+	}
+
+	c.storeTokens(&response)
+	return &response, nil
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"testing"
+
+	"tasius.my.id/todolistapi/internal/application/dto"
+)
+
+func TestTwoFactorEnableAndDisable(t *testing.T) {
+	api := newFakeAPI(t)
+
+	var codes []string
+	twoFactorCode := func(t *testing.T, r *http.Request, body []byte) {
+		if got := r.Header.Get("Authorization"); got != "Bearer the-access-token" {
+			t.Errorf("Authorization = %q, want the access token", got)
+		}
+		var req dto.TwoFactorCodeRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("invalid request body %q: %v", body, err)
+		}
+		codes = append(codes, req.Code)
+	}
+	api.handleEncrypted("POST /api/auth/2fa/enable", func(r *http.Request, body []byte) any {
+		twoFactorCode(t, r, body)
+		return TwoFactorEnableResponse{RecoveryCodes: []string{"recovery-1", "recovery-2"}}
+	})
+	api.handleEncrypted("POST /api/auth/2fa/disable", func(r *http.Request, body []byte) any {
+		twoFactorCode(t, r, body)
+		return nil
+	})
+
+	c := newTestClient(t, api, WithTokens(Tokens{AccessToken: "the-access-token"}))
+
+	enabled, err := c.EnableTwoFactor(context.Background(), "123456")
+	if err != nil {
+		t.Fatalf("EnableTwoFactor() error = %v", err)
+	}
+	if !slices.Equal(enabled.RecoveryCodes, []string{"recovery-1", "recovery-2"}) {
+		t.Errorf("EnableTwoFactor() recovery codes = %v", enabled.RecoveryCodes)
+	}
+
+	if err := c.DisableTwoFactor(context.Background(), "654321"); err != nil {
+		t.Fatalf("DisableTwoFactor() error = %v", err)
+	}
+
+	if !slices.Equal(codes, []string{"123456", "654321"}) {
+		t.Errorf("server received codes %v, want 123456 and 654321", codes)
+	}
+}
+
+func TestTwoFactorRequiresLogin(t *testing.T) {
+	api := newFakeAPI(t)
+	c := newTestClient(t, api)
+
+	if _, err := c.EnableTwoFactor(context.Background(), "123456"); err != ErrNotAuthenticated {
+		t.Errorf("EnableTwoFactor() error = %v, want %v", err, ErrNotAuthenticated)
+	}
+	if err := c.DisableTwoFactor(context.Background(), "123456"); err != ErrNotAuthenticated {
+		t.Errorf("DisableTwoFactor() error = %v, want %v", err, ErrNotAuthenticated)
+	}
+}
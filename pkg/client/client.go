@@ -0,0 +1,321 @@
+// Package client is a Go client for the To-Do List API. It encrypts request bodies with the
+// hybrid encryption protocol where the API expects it, decrypts encrypted responses, keeps the
+// access token fresh and turns error responses into *APIError values.
+//
+//	c, err := client.New("https://todo.example.com")
+//	auth, err := c.Login(ctx, &client.LoginRequest{Email: "me@example.com", Password: "secret"})
+//	todos, err := c.ListTodos(ctx)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	// refreshMargin refreshes the access token slightly before it expires
+	refreshMargin = 30 * time.Second
+	// maxResponseSize guards against unexpectedly large responses
+	maxResponseSize = 10 << 20
+)
+
+// ErrNotAuthenticated is returned when an endpoint needs a login and the client has no tokens
+var ErrNotAuthenticated = errors.New("client: not authenticated, log in first")
+
+// Tokens are the credentials of a logged in session
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresAt is when the access token expires, zero if unknown
+	ExpiresAt time.Time
+}
+
+// Client talks to the API. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	onTokens   func(Tokens)
+
+	mu        sync.Mutex
+	tokens    Tokens
+	publicKey *serverKey
+
+	refreshMu sync.Mutex
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTokens restores a previous session
+func WithTokens(tokens Tokens) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// OnTokenRefresh is called whenever the client receives new tokens, so they can be persisted
+func OnTokenRefresh(fn func(Tokens)) Option {
+	return func(c *Client) {
+		c.onTokens = fn
+	}
+}
+
+// New creates a client for the API at baseURL, e.g. "https://todo.example.com"
+func New(baseURL string, opts ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimRight(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("client: invalid base URL: %w", err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("client: base URL must be absolute, got %q", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+// Tokens returns the current session tokens
+func (c *Client) Tokens() Tokens {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.tokens
+}
+
+// SetTokens replaces the session tokens, an empty value logs the client out locally
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mu.Lock()
+	c.tokens = tokens
+	c.mu.Unlock()
+}
+
+// request describes a single API call
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   any
+	// encrypted sends the body as a hybrid encrypted envelope
+	encrypted bool
+	// authenticated sends the access token, refreshing it when needed
+	authenticated bool
+}
+
+// responseEnvelope is the utils.Response body every endpoint answers with on success
+type responseEnvelope struct {
+	RequestID string          `json:"requestId"`
+	Success   bool            `json:"success"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// problemDetails is the application/problem+json body of error responses
+type problemDetails struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"requestId"`
+	Errors    map[string]string `json:"errors"`
+}
+
+// call sends the request and decodes the data of the response into out, which may be nil
+func (c *Client) call(ctx context.Context, req *request, out any) error {
+	if req.authenticated {
+		if err := c.ensureFreshToken(ctx); err != nil {
+			return err
+		}
+	}
+
+	err := c.send(ctx, req, out)
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	switch {
+	// The server rotated its encryption key, fetch the new one and try once more
+	case req.encrypted && apiErr.IsUnknownKey():
+		c.resetPublicKey()
+		return c.send(ctx, req, out)
+	// The access token was revoked or expired early
+	case req.authenticated && apiErr.StatusCode == http.StatusUnauthorized && c.Tokens().RefreshToken != "":
+		if err := c.refresh(ctx, c.Tokens().AccessToken); err != nil {
+			return err
+		}
+		return c.send(ctx, req, out)
+	default:
+		return err
+	}
+}
+
+func (c *Client) send(ctx context.Context, req *request, out any) error {
+	// Paths are already escaped, they are appended as is
+	target, err := url.Parse(c.baseURL.String() + req.path)
+	if err != nil {
+		return err
+	}
+	if len(req.query) > 0 {
+		target.RawQuery = req.query.Encode()
+	}
+
+	var (
+		body       io.Reader
+		sessionKey []byte
+	)
+	if req.body != nil {
+		payload, err := json.Marshal(req.body)
+		if err != nil {
+			return fmt.Errorf("client: failed to encode request: %w", err)
+		}
+
+		if req.encrypted {
+			// The server binds the envelope to the raw request path
+			payload, sessionKey, err = c.encryptBody(ctx, req.method, target.EscapedPath(), payload)
+			if err != nil {
+				return err
+			}
+		}
+		body = bytes.NewReader(payload)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, target.String(), body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if req.authenticated {
+		accessToken := c.Tokens().AccessToken
+		if accessToken == "" {
+			return ErrNotAuthenticated
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("client: failed to read response: %w", err)
+	}
+
+	if resp.Header.Get(encryptedResponseHeader) != "" {
+		if respBody, err = decryptResponse(resp.Header.Get(encryptedResponseHeader), sessionKey, respBody); err != nil {
+			return err
+		}
+	}
+
+	return decodeResponse(resp.StatusCode, respBody, out)
+}
+
+func decodeResponse(statusCode int, body []byte, out any) error {
+	if statusCode >= http.StatusBadRequest {
+		var problem problemDetails
+		if err := json.Unmarshal(body, &problem); err != nil {
+			return &APIError{StatusCode: statusCode, Message: strings.TrimSpace(string(body))}
+		}
+		return newAPIError(statusCode, &problem)
+	}
+
+	var envelope responseEnvelope
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &envelope); err != nil {
+			return fmt.Errorf("client: invalid response: %w", err)
+		}
+	}
+
+	if out == nil || len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("client: failed to decode response data: %w", err)
+	}
+	return nil
+}
+
+// ensureFreshToken refreshes the access token if it is about to expire
+func (c *Client) ensureFreshToken(ctx context.Context) error {
+	tokens := c.Tokens()
+	if tokens.AccessToken == "" {
+		if tokens.RefreshToken == "" {
+			return ErrNotAuthenticated
+		}
+		return c.refresh(ctx, "")
+	}
+
+	if !tokens.ExpiresAt.IsZero() && time.Until(tokens.ExpiresAt) < refreshMargin && tokens.RefreshToken != "" {
+		return c.refresh(ctx, tokens.AccessToken)
+	}
+	return nil
+}
+
+// refresh exchanges the refresh token for new tokens. Concurrent callers that saw the same stale
+// access token share one refresh, refresh tokens are single-use.
+func (c *Client) refresh(ctx context.Context, staleAccessToken string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	tokens := c.Tokens()
+	if tokens.AccessToken != staleAccessToken {
+		return nil
+	}
+	if tokens.RefreshToken == "" {
+		return ErrNotAuthenticated
+	}
+
+	_, err := c.RefreshToken(ctx, tokens.RefreshToken)
+	return err
+}
+
+// storeTokens keeps the tokens of a successful login or refresh
+func (c *Client) storeTokens(auth *AuthResponse) {
+	if auth == nil || auth.AccessToken == "" {
+		return
+	}
+
+	tokens := Tokens{
+		AccessToken:  auth.AccessToken,
+		RefreshToken: auth.RefreshToken,
+	}
+	if auth.ExpiresIn > 0 {
+		tokens.ExpiresAt = time.Now().Add(time.Duration(auth.ExpiresIn) * time.Second)
+	}
+
+	c.SetTokens(tokens)
+	if c.onTokens != nil {
+		c.onTokens(tokens)
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+)
+
+// fakeAPI stands in for the API. Like the decrypt middleware of the auth routes it only accepts
+// JSON bodies sealed for its key, and encrypts the responses to them with the session key.
+type fakeAPI struct {
+	*httptest.Server
+
+	key   crypto.PrivateKey
+	keyID string
+	mux   *http.ServeMux
+}
+
+func newFakeAPI(t *testing.T) *fakeAPI {
+	t.Helper()
+
+	key, err := crypto.GenerateKey(crypto.KeyTypeX25519)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	keyID, err := crypto.KeyID(key.Public())
+	if err != nil {
+		t.Fatalf("KeyID() error = %v", err)
+	}
+	publicKey, err := crypto.EncodePublicKeyPEM(key.Public())
+	if err != nil {
+		t.Fatalf("EncodePublicKeyPEM() error = %v", err)
+	}
+
+	api := &fakeAPI{key: key, keyID: keyID, mux: http.NewServeMux()}
+	api.mux.HandleFunc("GET "+publicKeyPath, func(w http.ResponseWriter, _ *http.Request) {
+		writeData(w, nil, PublicKeyResponse{KeyID: keyID, Algorithm: key.Public().Algorithm(), PublicKey: publicKey})
+	})
+	api.Server = httptest.NewServer(api.mux)
+	t.Cleanup(api.Close)
+
+	return api
+}
+
+// handleEncrypted serves pattern with handler, which gets the decrypted body and answers with the
+// data of the response. Requests with a plaintext body are rejected.
+func (a *fakeAPI) handleEncrypted(pattern string, handler func(r *http.Request, body []byte) any) {
+	a.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			writeData(w, nil, handler(r, nil))
+			return
+		}
+
+		var encrypted encryptedBody
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &encrypted); err != nil || !crypto.IsEnvelope(encrypted.Data) {
+			writeProblem(w, http.StatusBadRequest, "malformed_envelope")
+			return
+		}
+		envelope, err := crypto.ParseEnvelope(encrypted.Data)
+		if err != nil || envelope.KeyID != a.keyID {
+			writeProblem(w, http.StatusBadRequest, "unknown_key")
+			return
+		}
+		body, sessionKey, err := envelope.Open(a.key, r.Method, r.URL.EscapedPath())
+		if err != nil {
+			writeProblem(w, http.StatusBadRequest, "decryption_failed")
+			return
+		}
+
+		writeData(w, sessionKey, handler(r, body))
+	})
+}
+
+// writeData answers with data in the response envelope, encrypted if there is a session key
+func writeData(w http.ResponseWriter, sessionKey []byte, data any) {
+	body, _ := json.Marshal(map[string]any{"success": true, "data": data})
+	if sessionKey != nil {
+		ciphertext, err := crypto.EncryptAES(sessionKey, body, nil)
+		if err != nil {
+			writeProblem(w, http.StatusInternalServerError, "internal_error")
+			return
+		}
+		body, _ = json.Marshal(encryptedBody{Data: hex.EncodeToString(ciphertext)})
+		w.Header().Set(encryptedResponseHeader, sessionEncryption)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+func writeProblem(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(problemDetails{Status: status, Code: code, Detail: strings.ReplaceAll(code, "_", " ")})
+}
+
+func newTestClient(t *testing.T, api *fakeAPI, opts ...Option) *Client {
+	t.Helper()
+
+	c, err := New(api.URL, opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"tasius.my.id/todolistapi/internal/utils/crypto"
+)
+
+const (
+	publicKeyPath           = "/api/encryption/public-key"
+	encryptedResponseHeader = "X-Encrypted-Response"
+	sessionEncryption       = "session"
+)
+
+// serverKey is the public key requests are currently encrypted for
+type serverKey struct {
+	id        string
+	algorithm string
+	key       crypto.PublicKey
+}
+
+// PublicKey returns the ID and algorithm of the server key request bodies are encrypted for,
+// fetching it if needed
+func (c *Client) PublicKey(ctx context.Context) (string, string, error) {
+	key, err := c.serverKey(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	return key.id, key.algorithm, nil
+}
+
+func (c *Client) serverKey(ctx context.Context) (*serverKey, error) {
+	c.mu.Lock()
+	key := c.publicKey
+	c.mu.Unlock()
+	if key != nil {
+		return key, nil
+	}
+
+	var response PublicKeyResponse
+	if err := c.send(ctx, &request{method: http.MethodGet, path: publicKeyPath}, &response); err != nil {
+		return nil, fmt.Errorf("client: failed to fetch the encryption key: %w", err)
+	}
+
+	block, _ := pem.Decode([]byte(response.PublicKey))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New("client: server returned an invalid public key")
+	}
+	publicKey, err := crypto.ParsePublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("client: server returned an unsupported public key: %w", err)
+	}
+
+	// The key ID is derived from the key, a mismatch means the response was tampered with
+	keyID, err := crypto.KeyID(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if keyID != response.KeyID {
+		return nil, errors.New("client: server public key does not match its key ID")
+	}
+
+	key = &serverKey{id: keyID, algorithm: publicKey.Algorithm(), key: publicKey}
+
+	c.mu.Lock()
+	c.publicKey = key
+	c.mu.Unlock()
+
+	return key, nil
+}
+
+func (c *Client) resetPublicKey() {
+	c.mu.Lock()
+	c.publicKey = nil
+	c.mu.Unlock()
+}
+
+// encryptBody seals the JSON body for the request, returning the request body and the AES session
+// key the response is encrypted with
+func (c *Client) encryptBody(ctx context.Context, method, path string, payload []byte) ([]byte, []byte, error) {
+	key, err := c.serverKey(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envelope, sessionKey, err := crypto.SealEnvelope(key.key, key.id, method, path, payload)
+	if err != nil {
+		return nil, nil, fmt.Errorf("client: failed to encrypt request: %w", err)
+	}
+
+	body, err := json.Marshal(encryptedBody{Data: envelope.String()})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return body, sessionKey, nil
+}
+
+type encryptedBody struct {
+	Data string `json:"data"`
+}
+
+// decryptResponse decrypts a response encrypted with the session key of the request
+func decryptResponse(method string, sessionKey, body []byte) ([]byte, error) {
+	if method != sessionEncryption || sessionKey == nil {
+		return nil, fmt.Errorf("client: unsupported response encryption %q", method)
+	}
+
+	var encrypted encryptedBody
+	if err := json.Unmarshal(body, &encrypted); err != nil {
+		return nil, fmt.Errorf("client: invalid encrypted response: %w", err)
+	}
+
+	ciphertext, err := hex.DecodeString(encrypted.Data)
+	if err != nil {
+		return nil, errors.New("client: invalid encrypted response: not valid hex")
+	}
+
+	plaintext, err := crypto.DecryptAES(sessionKey, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to decrypt response: %w", err)
+	}
+
+	return plaintext, nil
+}
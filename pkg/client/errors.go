@@ -0,0 +1,90 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// APIError is an error response of the API
+type APIError struct {
+	StatusCode int
+	RequestID  string
+	// Code identifies the error, e.g. "todo_not_found", empty if the body wasn't problem details
+	Code    string
+	Message string
+	// Errors maps the fields of a request that failed validation to their problem, e.g.
+	// "title" to "title must be at least 3 characters in length"
+	Errors map[string]string
+}
+
+func newAPIError(statusCode int, problem *problemDetails) *APIError {
+	message := problem.Detail
+	if message == "" {
+		message = problem.Title
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+
+	return &APIError{
+		StatusCode: statusCode,
+		RequestID:  problem.RequestID,
+		Code:       problem.Code,
+		Message:    message,
+		Errors:     problem.Errors,
+	}
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if len(e.Errors) > 0 {
+		problems := make([]string, 0, len(e.Errors))
+		for _, field := range slices.Sorted(maps.Keys(e.Errors)) {
+			problems = append(problems, e.Errors[field])
+		}
+		message += ": " + strings.Join(problems, ", ")
+	}
+	if e.RequestID != "" {
+		return fmt.Sprintf("api error %d: %s (request %s)", e.StatusCode, message, e.RequestID)
+	}
+	return fmt.Sprintf("api error %d: %s", e.StatusCode, message)
+}
+
+// IsValidation reports whether the request failed validation, see Errors for the details
+func (e *APIError) IsValidation() bool {
+	return e.Code == "validation_failed"
+}
+
+// IsUnknownKey reports whether the request was encrypted for a key the server no longer has
+func (e *APIError) IsUnknownKey() bool {
+	return e.Code == "unknown_key"
+}
+
+// IsUnauthorized reports whether err is a 401 response
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized)
+}
+
+// IsForbidden reports whether err is a 403 response
+func IsForbidden(err error) bool {
+	return hasStatus(err, http.StatusForbidden)
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+// IsRateLimited reports whether err is a 429 response, e.g. a login lockout
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+func hasStatus(err error, statusCode int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == statusCode
+}
@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// ListTodos returns the todos of the personal space, or of the organization the session acts in
+func (c *Client) ListTodos(ctx context.Context) ([]TodoResponse, error) {
+	var todos []TodoResponse
+	if err := c.call(ctx, &request{method: http.MethodGet, path: "/api/todos", authenticated: true}, &todos); err != nil {
+		return nil, err
+	}
+	return todos, nil
+}
+
+// GetTodo returns a single todo
+func (c *Client) GetTodo(ctx context.Context, id string) (*TodoResponse, error) {
+	var todo TodoResponse
+	if err := c.call(ctx, &request{method: http.MethodGet, path: todoPath(id), authenticated: true}, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// CreateTodo creates a todo
+func (c *Client) CreateTodo(ctx context.Context, req *CreateTodoRequest) (*TodoResponse, error) {
+	var todo TodoResponse
+	if err := c.call(ctx, &request{method: http.MethodPost, path: "/api/todos", body: req, authenticated: true}, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// UpdateTodo replaces the title and description of a todo
+func (c *Client) UpdateTodo(ctx context.Context, id string, req *UpdateTodoRequest) (*TodoResponse, error) {
+	var todo TodoResponse
+	if err := c.call(ctx, &request{method: http.MethodPost, path: todoPath(id), body: req, authenticated: true}, &todo); err != nil {
+		return nil, err
+	}
+	return &todo, nil
+}
+
+// DeleteTodo deletes a todo
+func (c *Client) DeleteTodo(ctx context.Context, id string) error {
+	return c.call(ctx, &request{method: http.MethodDelete, path: todoPath(id), authenticated: true}, nil)
+}
+
+func todoPath(id string) string {
+	return "/api/todos/" + url.PathEscape(id)
+}
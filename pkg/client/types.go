@@ -0,0 +1,21 @@
+package client
+
+import "tasius.my.id/todolistapi/internal/application/dto"
+
+// Request and response types of the API
+type (
+	RegisterRequest         = dto.RegisterRequest
+	LoginRequest            = dto.LoginRequest
+	AuthResponse            = dto.AuthResponse
+	UserResponse            = dto.UserResponse
+	ForgotPasswordRequest   = dto.ForgotPasswordRequest
+	ResetPasswordRequest    = dto.ResetPasswordRequest
+	TwoFactorSetupResponse  = dto.TwoFactorSetupResponse
+	TwoFactorEnableResponse = dto.TwoFactorEnableResponse
+	OIDCLoginResponse       = dto.OIDCLoginResponse
+	PublicKeyResponse       = dto.PublicKeyResponse
+
+	CreateTodoRequest = dto.TodoDTO
+	UpdateTodoRequest = dto.UpdateTodoRequest
+	TodoResponse      = dto.TodoResponse
+)